@@ -13,13 +13,17 @@ import (
 )
 
 import (
+	"github.com/bytepowered/fluxgo/pkg/admin"
 	"github.com/bytepowered/fluxgo/pkg/cmd"
+	"github.com/bytepowered/fluxgo/pkg/discovery"
 	"github.com/bytepowered/fluxgo/pkg/flux"
 	"github.com/bytepowered/fluxgo/pkg/listener"
 	"github.com/bytepowered/fluxgo/pkg/logger"
+	_ "github.com/bytepowered/fluxgo/pkg/ratelimit"
 	"github.com/bytepowered/fluxgo/pkg/server"
 	_ "github.com/bytepowered/fluxgo/pkg/transporter/dubbo"
 	_ "github.com/bytepowered/fluxgo/pkg/transporter/echo"
+	_ "github.com/bytepowered/fluxgo/pkg/transporter/grpc"
 	_ "github.com/bytepowered/fluxgo/pkg/transporter/http"
 )
 
@@ -76,6 +80,9 @@ func newDispatcherManager(options ...server.OptionFunc) *server.DispatchServer {
 				listener.WithHandlers([]listener.WebHandlerTuple{
 					{Method: "GET", Pattern: "/inspect/metrics", Handler: flux.WrapHttpHandler(promhttp.Handler())},
 				}),
+				listener.WithHandlers(admin.InspectHandlers()),
+				listener.WithHandlers(admin.DiscoveryHandlers(
+					flux.NewConfigurationByKeys(flux.NamespaceDiscoveries, discovery.AdminId))),
 			),
 			server.WithRequestVersionLocator(server.DefaultRequestVersionLocateFunc),
 		),
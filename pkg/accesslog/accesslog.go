@@ -0,0 +1,234 @@
+package accesslog
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+const (
+	ConfigKeyEnable      = "enable"
+	ConfigKeyFormat      = "format"
+	ConfigKeyFields      = "fields"
+	ConfigKeyMaskHeaders = "mask_headers"
+	ConfigKeyMaskSize    = "mask_size"
+	ConfigKeyMaskBody    = "mask_body"
+	ConfigKeyBodyLimit   = "body_limit"
+	ConfigKeySampling    = "sampling"
+	ConfigKeySink        = "sink"
+	ConfigKeyFile        = "file"
+	ConfigKeyAsync       = "async"
+)
+
+const (
+	ConfigKeyFilePath       = "path"
+	ConfigKeyFileMaxSize    = "max_size"
+	ConfigKeyFileMaxBackups = "max_backups"
+	ConfigKeyAsyncEnable    = "enable"
+	ConfigKeyAsyncBuffer    = "buffer_size"
+)
+
+var (
+	DefaultMaskHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+)
+
+// AccessLogger 访问日志组件：按状态码分类采样，屏蔽敏感Header和Body，格式化后输出到Sink
+type AccessLogger struct {
+	formatter    Formatter
+	sink         Sink
+	maskHeaders  map[string]struct{}
+	maskSize     int
+	maskBody     bool
+	bodyLimit    int
+	captureReq   bool
+	captureResp  bool
+	captureReqH  bool
+	captureRespH bool
+	sampling     map[string]float64
+}
+
+// NewAccessLogger 使用指定Formatter和Sink创建访问日志组件；默认屏蔽 DefaultMaskHeaders，不采样
+func NewAccessLogger(formatter Formatter, sink Sink) *AccessLogger {
+	l := &AccessLogger{
+		formatter: formatter,
+		sink:      sink,
+		maskSize:  4,
+		bodyLimit: 1024,
+		sampling:  make(map[string]float64, 0),
+	}
+	l.SetMaskHeaders(DefaultMaskHeaders)
+	return l
+}
+
+// NewAccessLoggerWith 根据配置创建访问日志组件
+func NewAccessLoggerWith(config *flux.Configuration) (*AccessLogger, error) {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyFormat:      FormatJSON,
+		ConfigKeyMaskHeaders: DefaultMaskHeaders,
+		ConfigKeyMaskSize:    4,
+		ConfigKeyMaskBody:    false,
+		ConfigKeyBodyLimit:   1024,
+		ConfigKeySink:        SinkTypeLogger,
+	})
+	fields := config.GetStringSlice(ConfigKeyFields)
+	formatter, err := NewFormatter(config.GetString(ConfigKeyFormat), fields)
+	if nil != err {
+		return nil, err
+	}
+	sink, err := NewSinkWith(config)
+	if nil != err {
+		return nil, err
+	}
+	l := NewAccessLogger(formatter, sink)
+	l.SetMaskHeaders(config.GetStringSlice(ConfigKeyMaskHeaders))
+	l.maskSize = config.GetInt(ConfigKeyMaskSize)
+	l.maskBody = config.GetBool(ConfigKeyMaskBody)
+	l.bodyLimit = config.GetInt(ConfigKeyBodyLimit)
+	// 仅JSON格式输出Header和Body，且需要在字段中声明
+	if len(fields) == 0 || config.GetString(ConfigKeyFormat) != FormatJSON {
+		fields = DefaultFields
+	}
+	l.captureReq, l.captureResp = contains(fields, FieldRequestBody), contains(fields, FieldResponseBody)
+	l.captureReqH, l.captureRespH = contains(fields, FieldRequestHeader), contains(fields, FieldResponseHeader)
+	for class, rate := range config.GetStringMap(ConfigKeySampling) {
+		l.SetSampling(class, cast.ToFloat64(rate))
+	}
+	return l, nil
+}
+
+// NewSinkWith 根据配置创建Sink；默认启用异步输出
+func NewSinkWith(config *flux.Configuration) (Sink, error) {
+	file := config.Sub(ConfigKeyFile)
+	file.SetDefaults(map[string]interface{}{
+		ConfigKeyFilePath:       "logs/access.log",
+		ConfigKeyFileMaxSize:    100 * 1024 * 1024,
+		ConfigKeyFileMaxBackups: 7,
+	})
+	async := config.Sub(ConfigKeyAsync)
+	async.SetDefaults(map[string]interface{}{
+		ConfigKeyAsyncEnable: true,
+		ConfigKeyAsyncBuffer: 4096,
+	})
+	var sink Sink
+	switch typ := config.GetString(ConfigKeySink); typ {
+	case SinkTypeLogger, "":
+		sink = NewLoggerSink()
+	case SinkTypeStdout:
+		sink = NewWriterSink(os.Stdout)
+	case SinkTypeFile:
+		fs, err := NewFileSink(file.GetString(ConfigKeyFilePath), file.GetInt64(ConfigKeyFileMaxSize), file.GetInt(ConfigKeyFileMaxBackups))
+		if nil != err {
+			return nil, err
+		}
+		sink = fs
+	default:
+		return nil, fmt.Errorf("accesslog: unsupported sink: %s", typ)
+	}
+	if async.GetBool(ConfigKeyAsyncEnable) {
+		sink = NewAsyncSink(sink, async.GetInt(ConfigKeyAsyncBuffer))
+	}
+	return sink, nil
+}
+
+// SetMaskHeaders 设置需要屏蔽的Header名称，忽略大小写
+func (l *AccessLogger) SetMaskHeaders(headers []string) {
+	l.maskHeaders = make(map[string]struct{}, len(headers))
+	for _, h := range headers {
+		l.maskHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+}
+
+// SetSampling 设置状态码分类的采样率，例如：2xx=0.1；未设置的分类全部记录
+func (l *AccessLogger) SetSampling(class string, rate float64) {
+	l.sampling[strings.ToLower(class)] = rate
+}
+
+// Sampled 判断指定状态码的请求是否需要记录
+func (l *AccessLogger) Sampled(status int) bool {
+	rate, ok := l.sampling[StatusClassOf(status)]
+	if !ok || rate >= 1 {
+		return true
+	}
+	return rate > 0 && rand.Float64() < rate
+}
+
+// CaptureBody 返回是否需要记录请求和响应的Body数据
+func (l *AccessLogger) CaptureBody() (request, response bool) {
+	return l.captureReq, l.captureResp
+}
+
+// CaptureHeader 返回是否需要记录请求和响应的Header数据
+func (l *AccessLogger) CaptureHeader() (request, response bool) {
+	return l.captureReqH, l.captureRespH
+}
+
+// BodyLimit 返回记录Body数据的最大长度
+func (l *AccessLogger) BodyLimit() int {
+	return l.bodyLimit
+}
+
+// MaskHeader 复制Header，并屏蔽敏感Header的值
+func (l *AccessLogger) MaskHeader(header http.Header) http.Header {
+	out := make(http.Header, len(header))
+	for k, vs := range header {
+		values := make([]string, len(vs))
+		_, mask := l.maskHeaders[http.CanonicalHeaderKey(k)]
+		for i, v := range vs {
+			if mask {
+				values[i] = toolkit.Mask(v, l.maskSize)
+			} else {
+				values[i] = v
+			}
+		}
+		out[k] = values
+	}
+	return out
+}
+
+// MaskBody 截断Body数据到最大长度，并按配置屏蔽数据；返回复制的数据
+func (l *AccessLogger) MaskBody(body []byte) []byte {
+	if l.bodyLimit > 0 && len(body) > l.bodyLimit {
+		body = body[:l.bodyLimit]
+	}
+	out := make([]byte, len(body))
+	copy(out, body)
+	if l.maskBody {
+		return toolkit.MaskBytes(out, l.maskSize)
+	}
+	return out
+}
+
+// Log 格式化并输出访问日志；Sink为异步时不阻塞调用方
+func (l *AccessLogger) Log(r *Record) {
+	line, err := l.formatter.Format(r)
+	if nil != err {
+		logger.Warnw("ACCESSLOG:FORMAT:ERROR", "request-id", r.RequestId, "error", err)
+		return
+	}
+	if _, err := l.sink.Write(line); nil != err {
+		logger.Warnw("ACCESSLOG:SINK:ERROR", "request-id", r.RequestId, "error", err)
+	}
+}
+
+// Close 关闭Sink，异步Sink将输出缓冲区内的全部日志
+func (l *AccessLogger) Close() error {
+	return l.sink.Close()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRecord() *Record {
+	return &Record{
+		Time:         time.Date(2021, 5, 1, 10, 20, 30, 0, time.UTC),
+		RequestId:    "rid-1",
+		RemoteAddr:   "10.0.0.1",
+		Method:       "GET",
+		URI:          "/api/users?id=1",
+		Proto:        "HTTP/1.1",
+		Referer:      "http://example.com/",
+		UserAgent:    "curl/7.64",
+		Status:       200,
+		ResponseSize: 128,
+		Latency:      1500 * time.Microsecond,
+		Pattern:      "/api/users",
+		ServiceId:    "user.service:get",
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	tester := assert.New(t)
+	rec := newTestRecord()
+	// JSON: fields order
+	f, err := NewFormatter(FormatJSON, []string{FieldRequestId, FieldStatus, FieldLatency, FieldEndpoint})
+	tester.NoError(err)
+	line, err := f.Format(rec)
+	tester.NoError(err)
+	tester.Equal(`{"request_id":"rid-1","status":200,"latency_ms":1.5,"endpoint":"/api/users"}`, string(line))
+	// Common
+	f, _ = NewFormatter(FormatCommon, nil)
+	line, _ = f.Format(rec)
+	tester.Equal(`10.0.0.1 - - [01/May/2021:10:20:30 +0000] "GET /api/users?id=1 HTTP/1.1" 200 128`, string(line))
+	// Combined
+	f, _ = NewFormatter(FormatCombined, nil)
+	line, _ = f.Format(rec)
+	tester.Equal(`10.0.0.1 - - [01/May/2021:10:20:30 +0000] "GET /api/users?id=1 HTTP/1.1" 200 128 "http://example.com/" "curl/7.64"`, string(line))
+	// Invalid
+	_, err = NewFormatter(FormatJSON, []string{"unknown"})
+	tester.Error(err)
+	_, err = NewFormatter("xml", nil)
+	tester.Error(err)
+}
+
+func TestAccessLoggerMaskAndSampling(t *testing.T) {
+	tester := assert.New(t)
+	buffer := new(bytes.Buffer)
+	f, _ := NewJSONFormatter([]string{FieldRequestHeader, FieldRequestBody})
+	al := NewAccessLogger(f, NewWriterSink(buffer))
+	al.maskBody = true
+	header := http.Header{"Authorization": []string{"Bearer 0123456789abcdef"}, "Accept": []string{"*/*"}}
+	masked := al.MaskHeader(header)
+	tester.Equal("Bear**cdef", masked.Get("Authorization"))
+	tester.Equal("*/*", masked.Get("Accept"))
+	tester.Equal("Bearer 0123456789abcdef", header.Get("Authorization"), "must not modify origin header")
+	body := []byte("password=0123456789")
+	tester.Equal("pass**6789", string(al.MaskBody(body)))
+	tester.Equal("password=0123456789", string(body), "must not modify origin body")
+	al.Log(&Record{RequestHeader: masked, RequestBody: al.MaskBody(body)})
+	out := make(map[string]interface{})
+	tester.NoError(json.Unmarshal(buffer.Bytes(), &out))
+	tester.Equal("pass**6789", out[FieldRequestBody])
+	// Sampling
+	al.SetSampling("2XX", 0)
+	al.SetSampling("4xx", 0.5)
+	tester.False(al.Sampled(200))
+	tester.True(al.Sampled(500))
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if al.Sampled(404) {
+			sampled++
+		}
+	}
+	tester.InDelta(500, sampled, 100)
+}
+
+func TestAccessLoggerWithConfig(t *testing.T) {
+	tester := assert.New(t)
+	config := flux.NewConfiguration("accesslog_test")
+	config.Set(ConfigKeyFields, []string{FieldStatus, FieldResponseBody})
+	config.Set(ConfigKeySink, SinkTypeStdout)
+	config.Set(ConfigKeySampling, map[string]interface{}{"2xx": 0, "5xx": 1})
+	al, err := NewAccessLoggerWith(config)
+	tester.NoError(err)
+	defer al.Close()
+	req, resp := al.CaptureBody()
+	tester.False(req)
+	tester.True(resp)
+	tester.False(al.Sampled(201))
+	tester.True(al.Sampled(503))
+	_, ok := al.sink.(*AsyncSink)
+	tester.True(ok, "async sink enabled by default")
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	tester := assert.New(t)
+	dir, err := ioutil.TempDir("", "accesslog")
+	tester.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	sink, err := NewFileSink(path, 20, 2)
+	tester.NoError(err)
+	for _, line := range []string{"line-0000001", "line-0000002", "line-0000003", "line-0000004"} {
+		_, err := sink.Write([]byte(line))
+		tester.NoError(err)
+	}
+	tester.NoError(sink.Close())
+	current, _ := ioutil.ReadFile(path)
+	tester.Equal("line-0000004\n", string(current))
+	backup1, _ := ioutil.ReadFile(path + ".1")
+	tester.Equal("line-0000003\n", string(backup1))
+	backup2, _ := ioutil.ReadFile(path + ".2")
+	tester.Equal("line-0000002\n", string(backup2))
+	_, err = os.Stat(path + ".3")
+	tester.True(os.IsNotExist(err))
+	_, err = sink.Write([]byte("closed"))
+	tester.Equal(ErrSinkClosed, err)
+}
+
+type blockingSink struct {
+	mu      sync.Mutex
+	release chan struct{}
+	lines   []string
+}
+
+func (s *blockingSink) Write(line []byte) (int, error) {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, string(line))
+	return len(line), nil
+}
+
+func (s *blockingSink) Close() error {
+	return nil
+}
+
+func TestAsyncSinkNonBlocking(t *testing.T) {
+	tester := assert.New(t)
+	next := &blockingSink{release: make(chan struct{})}
+	sink := NewAsyncSink(next, 2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			_, _ = sink.Write([]byte("line"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		tester.Fail("async sink must not block writer")
+	}
+	tester.True(sink.Dropped() > 0)
+	close(next.release)
+	tester.NoError(sink.Close())
+	tester.Equal(uint64(10), uint64(len(next.lines))+sink.Dropped())
+	_, err := sink.Write([]byte("closed"))
+	tester.Equal(ErrSinkClosed, err)
+}
@@ -0,0 +1,171 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatCommon   = "common"
+	FormatCombined = "combined"
+)
+
+// 访问日志字段名，用于JSON格式的字段选择
+const (
+	FieldTime            = "time"
+	FieldRequestId       = "request_id"
+	FieldListenerId      = "listener_id"
+	FieldRemoteAddr      = "remote_addr"
+	FieldMethod          = "method"
+	FieldURI             = "uri"
+	FieldProto           = "proto"
+	FieldHost            = "host"
+	FieldReferer         = "referer"
+	FieldUserAgent       = "user_agent"
+	FieldRequestHeader   = "request_header"
+	FieldRequestBody     = "request_body"
+	FieldRequestSize     = "request_size"
+	FieldStatus          = "status"
+	FieldResponseHeader  = "response_header"
+	FieldResponseBody    = "response_body"
+	FieldResponseSize    = "response_size"
+	FieldLatency         = "latency_ms"
+	FieldApplication     = "application"
+	FieldEndpoint        = "endpoint"
+	FieldVersion         = "version"
+	FieldServiceId       = "service_id"
+	FieldErrorCode       = "error_code"
+	FieldUpstreamLatency = "upstream_latency_ms"
+)
+
+const (
+	commonLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+	commonLogEmpty      = "-"
+)
+
+var (
+	// DefaultFields 默认输出的字段，不包含Header和Body
+	DefaultFields = []string{
+		FieldTime, FieldRequestId, FieldListenerId, FieldRemoteAddr, FieldMethod, FieldURI, FieldProto,
+		FieldStatus, FieldRequestSize, FieldResponseSize, FieldLatency,
+		FieldApplication, FieldEndpoint, FieldVersion, FieldServiceId, FieldErrorCode, FieldUpstreamLatency,
+	}
+)
+
+var fieldValues = map[string]func(r *Record) interface{}{
+	FieldTime:            func(r *Record) interface{} { return r.Time.Format(time.RFC3339Nano) },
+	FieldRequestId:       func(r *Record) interface{} { return r.RequestId },
+	FieldListenerId:      func(r *Record) interface{} { return r.ListenerId },
+	FieldRemoteAddr:      func(r *Record) interface{} { return r.RemoteAddr },
+	FieldMethod:          func(r *Record) interface{} { return r.Method },
+	FieldURI:             func(r *Record) interface{} { return r.URI },
+	FieldProto:           func(r *Record) interface{} { return r.Proto },
+	FieldHost:            func(r *Record) interface{} { return r.Host },
+	FieldReferer:         func(r *Record) interface{} { return r.Referer },
+	FieldUserAgent:       func(r *Record) interface{} { return r.UserAgent },
+	FieldRequestHeader:   func(r *Record) interface{} { return r.RequestHeader },
+	FieldRequestBody:     func(r *Record) interface{} { return string(r.RequestBody) },
+	FieldRequestSize:     func(r *Record) interface{} { return r.RequestSize },
+	FieldStatus:          func(r *Record) interface{} { return r.Status },
+	FieldResponseHeader:  func(r *Record) interface{} { return r.ResponseHeader },
+	FieldResponseBody:    func(r *Record) interface{} { return string(r.ResponseBody) },
+	FieldResponseSize:    func(r *Record) interface{} { return r.ResponseSize },
+	FieldLatency:         func(r *Record) interface{} { return millis(r.Latency) },
+	FieldApplication:     func(r *Record) interface{} { return r.Application },
+	FieldEndpoint:        func(r *Record) interface{} { return r.Pattern },
+	FieldVersion:         func(r *Record) interface{} { return r.Version },
+	FieldServiceId:       func(r *Record) interface{} { return r.ServiceId },
+	FieldErrorCode:       func(r *Record) interface{} { return r.ErrorCode },
+	FieldUpstreamLatency: func(r *Record) interface{} { return millis(r.UpstreamLatency) },
+}
+
+// Formatter 将访问日志记录格式化为单行日志数据
+type Formatter interface {
+	Format(r *Record) ([]byte, error)
+}
+
+// FormatterFunc 函数实现 Formatter 接口
+type FormatterFunc func(r *Record) ([]byte, error)
+
+func (f FormatterFunc) Format(r *Record) ([]byte, error) {
+	return f(r)
+}
+
+// NewFormatter 根据格式名称创建Formatter；fields 仅对JSON格式有效
+func NewFormatter(format string, fields []string) (Formatter, error) {
+	switch format {
+	case FormatJSON, "":
+		return NewJSONFormatter(fields)
+	case FormatCommon:
+		return FormatterFunc(FormatCommonLog), nil
+	case FormatCombined:
+		return FormatterFunc(FormatCombinedLog), nil
+	default:
+		return nil, fmt.Errorf("accesslog: unsupported format: %s", format)
+	}
+}
+
+// NewJSONFormatter 创建按字段顺序输出的JSON格式Formatter；fields为空时使用 DefaultFields
+func NewJSONFormatter(fields []string) (Formatter, error) {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+	for _, field := range fields {
+		if _, ok := fieldValues[field]; !ok {
+			return nil, fmt.Errorf("accesslog: unsupported field: %s", field)
+		}
+	}
+	keys := make([][]byte, len(fields))
+	for i, field := range fields {
+		keys[i], _ = json.Marshal(field)
+	}
+	return FormatterFunc(func(r *Record) ([]byte, error) {
+		buf := bytes.NewBuffer(make([]byte, 0, 512))
+		buf.WriteByte('{')
+		for i, field := range fields {
+			value, err := json.Marshal(fieldValues[field](r))
+			if nil != err {
+				return nil, fmt.Errorf("accesslog: marshal field: %s, error: %w", field, err)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+	}), nil
+}
+
+// FormatCommonLog 输出 Common Log Format 格式：host ident authuser [date] "request" status bytes
+func FormatCommonLog(r *Record) ([]byte, error) {
+	size := commonLogEmpty
+	if r.ResponseSize > 0 {
+		size = strconv.FormatInt(r.ResponseSize, 10)
+	}
+	return []byte(fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s",
+		orEmpty(r.RemoteAddr), r.Time.Format(commonLogTimeLayout), r.Method, r.URI, r.Proto, r.Status, size)), nil
+}
+
+// FormatCombinedLog 输出 Combined Log Format 格式：在Common格式后追加 "referer" "user-agent"
+func FormatCombinedLog(r *Record) ([]byte, error) {
+	line, _ := FormatCommonLog(r)
+	return append(line, fmt.Sprintf(" %q %q", orEmpty(r.Referer), orEmpty(r.UserAgent))...), nil
+}
+
+func orEmpty(v string) string {
+	if v == "" {
+		return commonLogEmpty
+	}
+	return v
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
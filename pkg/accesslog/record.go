@@ -0,0 +1,66 @@
+package accesslog
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// VarKeyRecord 访问日志记录在WebContext变量域的键名
+	VarKeyRecord = "flux.go/accesslog.record"
+)
+
+// Record 单个请求的访问日志记录
+type Record struct {
+	Time           time.Time
+	RequestId      string
+	ListenerId     string
+	RemoteAddr     string
+	Method         string
+	URI            string
+	Proto          string
+	Host           string
+	Referer        string
+	UserAgent      string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	RequestSize    int64
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	ResponseSize   int64
+	Latency        time.Duration
+	// 以下字段由Dispatcher在匹配Endpoint后填充
+	Application     string
+	Pattern         string
+	Version         string
+	ServiceId       string
+	ErrorCode       string
+	UpstreamLatency time.Duration
+}
+
+// StatusClass 返回响应状态码的分类，例如：2xx；状态码无效时返回 unknown
+func (r *Record) StatusClass() string {
+	return StatusClassOf(r.Status)
+}
+
+type variables interface {
+	Variable(key string) interface{}
+}
+
+// RecordOf 返回当前请求绑定的访问日志记录；未启用访问日志时，返回nil
+func RecordOf(vars variables) *Record {
+	if rec, ok := vars.Variable(VarKeyRecord).(*Record); ok {
+		return rec
+	}
+	return nil
+}
+
+// StatusClassOf 返回Http状态码的分类
+func StatusClassOf(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
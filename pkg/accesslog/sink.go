@@ -0,0 +1,231 @@
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/logger"
+)
+
+const (
+	SinkTypeLogger = "logger"
+	SinkTypeStdout = "stdout"
+	SinkTypeFile   = "file"
+)
+
+var (
+	ErrSinkClosed = errors.New("accesslog: sink closed")
+)
+
+// Sink 访问日志的输出目标；每次写入一行完整的日志数据
+type Sink interface {
+	io.Writer
+	io.Closer
+}
+
+//// Logger
+
+// LoggerSink 输出到系统日志组件
+type LoggerSink struct{}
+
+func NewLoggerSink() *LoggerSink {
+	return new(LoggerSink)
+}
+
+func (s *LoggerSink) Write(line []byte) (int, error) {
+	logger.Info(string(line))
+	return len(line), nil
+}
+
+func (s *LoggerSink) Close() error {
+	return nil
+}
+
+//// Writer
+
+type writerSink struct {
+	mu sync.Mutex
+	io.Writer
+}
+
+// NewWriterSink 输出到指定Writer，例如 os.Stdout；Close不会关闭Writer
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{Writer: w}
+}
+
+func (s *writerSink) Write(line []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Writer.Write(append(line, '\n'))
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+//// File
+
+// FileSink 输出到本地文件；文件大小超过 maxSize 时滚动，保留最多 maxBackups 个历史文件：
+// access.log -> access.log.1 -> access.log.2 ...
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	if path == "" {
+		return nil, errors.New("accesslog: file sink path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+		return nil, fmt.Errorf("accesslog: create dir, path: %s, error: %w", path, err)
+	}
+	s := &FileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); nil != err {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Write(line []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return 0, ErrSinkClosed
+	}
+	data := append(line, '\n')
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); nil != err {
+			return 0, err
+		}
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return n, err
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if nil != err {
+		return fmt.Errorf("accesslog: open file, path: %s, error: %w", s.path, err)
+	}
+	info, err := file.Stat()
+	if nil != err {
+		_ = file.Close()
+		return fmt.Errorf("accesslog: stat file, path: %s, error: %w", s.path, err)
+	}
+	s.file, s.size = file, info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); nil != err {
+		return fmt.Errorf("accesslog: close file, path: %s, error: %w", s.path, err)
+	}
+	s.file = nil
+	if s.maxBackups > 0 {
+		_ = os.Remove(s.backupOf(s.maxBackups))
+		for i := s.maxBackups - 1; i > 0; i-- {
+			_ = os.Rename(s.backupOf(i), s.backupOf(i+1))
+		}
+		if err := os.Rename(s.path, s.backupOf(1)); nil != err {
+			return fmt.Errorf("accesslog: rotate file, path: %s, error: %w", s.path, err)
+		}
+	} else if err := os.Truncate(s.path, 0); nil != err {
+		return fmt.Errorf("accesslog: truncate file, path: %s, error: %w", s.path, err)
+	}
+	return s.open()
+}
+
+func (s *FileSink) backupOf(index int) string {
+	return fmt.Sprintf("%s.%d", s.path, index)
+}
+
+//// Async
+
+// AsyncSink 基于缓冲Channel的异步输出；缓冲区已满时丢弃日志，确保不阻塞请求处理
+type AsyncSink struct {
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan []byte
+	done    chan struct{}
+	dropped uint64
+	next    Sink
+}
+
+func NewAsyncSink(next Sink, bufferSize int) *AsyncSink {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	s := &AsyncSink{
+		queue: make(chan []byte, bufferSize),
+		done:  make(chan struct{}),
+		next:  next,
+	}
+	go s.loop()
+	return s
+}
+
+func (s *AsyncSink) Write(line []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return 0, ErrSinkClosed
+	}
+	select {
+	case s.queue <- line:
+		return len(line), nil
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+		return 0, nil
+	}
+}
+
+// Dropped 返回因缓冲区已满而丢弃的日志数量
+func (s *AsyncSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close 停止接收日志，等待缓冲区内的日志全部输出后，关闭下一级Sink
+func (s *AsyncSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+	<-s.done
+	return s.next.Close()
+}
+
+func (s *AsyncSink) loop() {
+	defer close(s.done)
+	for line := range s.queue {
+		if _, err := s.next.Write(line); nil != err {
+			logger.Warnw("ACCESSLOG:SINK:WRITE", "error", err)
+		}
+	}
+}
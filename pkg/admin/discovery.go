@@ -0,0 +1,250 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/discovery"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/server"
+)
+
+// SubmitData 提交变更事件的返回数据
+type SubmitData struct {
+	Key       string `json:"key"`
+	Version   string `json:"version,omitempty"`
+	EventType string `json:"eventType"`
+}
+
+// DiscoveryHandlers 返回运行时提交Endpoint/Service变更事件的管理接口列表；
+// 变更事件通过 discovery.AdminMetadataDiscovery 投递到服务的事件处理队列。
+// 仅在配置 write_enable 为true，并且配置了访问令牌 write_token 时注册；请求需携带 Authorization: Bearer <token>。
+func DiscoveryHandlers(config *flux.Configuration) []listener.WebHandlerTuple {
+	if !config.GetBool(discovery.ConfigKeyWriteEnable) {
+		return nil
+	}
+	token := config.GetString(discovery.ConfigKeyWriteToken)
+	if token == "" {
+		logger.Warnw("ADMIN:DISCOVERY:DISABLED", "reason", "<write_token> is empty")
+		return nil
+	}
+	return DiscoveryHandlersWith(NewTokenAuthFilter(token))
+}
+
+// DiscoveryHandlersWith 返回以指定认证Filter保护的管理接口列表
+func DiscoveryHandlersWith(auth flux.WebFilter) []listener.WebHandlerTuple {
+	flux.AssertNotNil(auth, "<auth-filter> MUST NOT nil")
+	filters := []flux.WebFilter{auth}
+	return []listener.WebHandlerTuple{
+		{Method: http.MethodPost, Pattern: "/discovery/endpoints", Handler: AddEndpointHandler, Filters: filters},
+		{Method: http.MethodPut, Pattern: "/discovery/endpoints", Handler: UpdateEndpointHandler, Filters: filters},
+		{Method: http.MethodDelete, Pattern: "/discovery/endpoints", Handler: RemoveEndpointHandler, Filters: filters},
+		{Method: http.MethodPost, Pattern: "/discovery/services", Handler: AddServiceHandler, Filters: filters},
+		{Method: http.MethodPut, Pattern: "/discovery/services", Handler: UpdateServiceHandler, Filters: filters},
+		{Method: http.MethodDelete, Pattern: "/discovery/services", Handler: RemoveServiceHandler, Filters: filters},
+	}
+}
+
+// NewTokenAuthFilter 校验请求携带的Bearer访问令牌
+func NewTokenAuthFilter(token string) flux.WebFilter {
+	expected := []byte(token)
+	return func(next flux.WebHandlerFunc) flux.WebHandlerFunc {
+		return func(webex flux.WebContext) error {
+			value := webex.HeaderVar(flux.HeaderAuthorization)
+			if !strings.HasPrefix(value, "Bearer ") ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(value, "Bearer ")), expected) != 1 {
+				return &flux.ServeError{
+					StatusCode: http.StatusUnauthorized,
+					ErrorCode:  flux.ErrorCodeAuthenticationRequired,
+					Message:    flux.ErrorMessageAdminUnauthorized,
+				}
+			}
+			return next(webex)
+		}
+	}
+}
+
+// AddEndpointHandler 新增Endpoint；请求体为EndpointSpec的JSON数据
+func AddEndpointHandler(webex flux.WebContext) error {
+	ep, err := decodeEndpoint(webex)
+	if nil != err {
+		return err
+	}
+	return submitEndpoint(webex, flux.EndpointEvent{EventType: flux.EventTypeAdded, Endpoint: ep})
+}
+
+// UpdateEndpointHandler 更新已存在的Endpoint；请求体为EndpointSpec的JSON数据
+func UpdateEndpointHandler(webex flux.WebContext) error {
+	ep, err := decodeEndpoint(webex)
+	if nil != err {
+		return err
+	}
+	key := ext.MakeEndpointKey(ep.HttpMethod, ep.HttpPattern)
+	if mvce, ok := ext.EndpointByKey(key); !ok || mvce.IsEmpty() {
+		return NewNotFoundError("endpoint not found, key: " + key)
+	}
+	return submitEndpoint(webex, flux.EndpointEvent{EventType: flux.EventTypeUpdated, Endpoint: ep})
+}
+
+// RemoveEndpointHandler 按method+pattern+version删除Endpoint
+func RemoveEndpointHandler(webex flux.WebContext) error {
+	method, pattern := webex.QueryVar(QueryKeyMethod), webex.QueryVar(QueryKeyPattern)
+	if method == "" || pattern == "" {
+		return NewInvalidRequestError("query <method>, <pattern> is required")
+	}
+	key := ext.MakeEndpointKey(method, pattern)
+	version := webex.QueryVar(QueryKeyVersion)
+	if mvce, ok := ext.EndpointByKey(key); ok {
+		for _, ep := range mvce.Endpoints() {
+			if ep.Version == version {
+				return submitEndpoint(webex, flux.EndpointEvent{EventType: flux.EventTypeRemoved, Endpoint: *ep})
+			}
+		}
+	}
+	return NewNotFoundError("endpoint not found, key: " + key + ", version: " + version)
+}
+
+// AddServiceHandler 新增Service；请求体为ServiceSpec的JSON数据
+func AddServiceHandler(webex flux.WebContext) error {
+	service, err := decodeService(webex)
+	if nil != err {
+		return err
+	}
+	return submitService(webex, flux.ServiceEvent{EventType: flux.EventTypeAdded, Service: service})
+}
+
+// UpdateServiceHandler 更新已存在的Service；请求体为ServiceSpec的JSON数据
+func UpdateServiceHandler(webex flux.WebContext) error {
+	service, err := decodeService(webex)
+	if nil != err {
+		return err
+	}
+	if !ext.ExistsServiceByID(service.ServiceID()) {
+		return NewNotFoundError("service not found, id: " + service.ServiceID())
+	}
+	return submitService(webex, flux.ServiceEvent{EventType: flux.EventTypeUpdated, Service: service})
+}
+
+// RemoveServiceHandler 按ServiceId删除Service
+func RemoveServiceHandler(webex flux.WebContext) error {
+	id := webex.QueryVar(QueryKeyServiceId)
+	if id == "" {
+		return NewInvalidRequestError("query <id> is required")
+	}
+	service, ok := ext.ServiceByID(id)
+	if !ok {
+		return NewNotFoundError("service not found, id: " + id)
+	}
+	return submitService(webex, flux.ServiceEvent{EventType: flux.EventTypeRemoved, Service: service})
+}
+
+func submitEndpoint(webex flux.WebContext, event flux.EndpointEvent) error {
+	d, err := lookupAdminDiscovery()
+	if nil != err {
+		return err
+	}
+	if err := d.SubmitEndpoint(event); nil != err {
+		return toSubmitError(err)
+	}
+	return WriteJSON(webex, flux.StatusOK, SubmitData{
+		Key:       ext.MakeEndpointKey(event.Endpoint.HttpMethod, event.Endpoint.HttpPattern),
+		Version:   event.Endpoint.Version,
+		EventType: flux.EventTypeName(int(event.EventType)),
+	})
+}
+
+func submitService(webex flux.WebContext, event flux.ServiceEvent) error {
+	d, err := lookupAdminDiscovery()
+	if nil != err {
+		return err
+	}
+	if err := d.SubmitService(event); nil != err {
+		return toSubmitError(err)
+	}
+	return WriteJSON(webex, flux.StatusOK, SubmitData{
+		Key:       event.Service.ServiceID(),
+		EventType: flux.EventTypeName(int(event.EventType)),
+	})
+}
+
+func decodeEndpoint(webex flux.WebContext) (flux.EndpointSpec, error) {
+	ep := flux.EndpointSpec{}
+	if err := decodeBody(webex, &ep); nil != err {
+		return ep, err
+	}
+	if ep.HttpMethod = strings.ToUpper(ep.HttpMethod); ep.HttpMethod != "" && !server.SupportedHttpMethod(ep.HttpMethod) {
+		return ep, NewInvalidRequestError("unsupported endpoint http method: " + ep.HttpMethod)
+	}
+	if err := discovery.VerifyEndpoint(&ep); nil != err {
+		return ep, toSubmitError(err)
+	}
+	return ep, nil
+}
+
+func decodeService(webex flux.WebContext) (flux.ServiceSpec, error) {
+	service := flux.ServiceSpec{}
+	if err := decodeBody(webex, &service); nil != err {
+		return service, err
+	}
+	if err := discovery.VerifyService(&service); nil != err {
+		return service, toSubmitError(err)
+	}
+	return service, nil
+}
+
+func decodeBody(webex flux.WebContext, out interface{}) error {
+	reader, err := webex.BodyReader()
+	if nil != err {
+		return NewInvalidRequestError("read request body: " + err.Error())
+	}
+	defer reader.Close()
+	bytes, err := ioutil.ReadAll(reader)
+	if nil != err {
+		return NewInvalidRequestError("read request body: " + err.Error())
+	}
+	if err := discovery.VerifyJSON(bytes); nil != err {
+		return NewInvalidRequestError(err.Error())
+	}
+	if err := ext.JSONUnmarshal(bytes, out); nil != err {
+		return NewInvalidRequestError("decode request body: " + err.Error())
+	}
+	return nil
+}
+
+func lookupAdminDiscovery() (*discovery.AdminMetadataDiscovery, error) {
+	if d, ok := ext.MetadataDiscoveryById(discovery.AdminId); ok {
+		if ad, ok := d.(*discovery.AdminMetadataDiscovery); ok {
+			return ad, nil
+		}
+	}
+	return nil, &flux.ServeError{
+		StatusCode: http.StatusServiceUnavailable,
+		ErrorCode:  flux.ErrorCodeGatewayInternal,
+		Message:    flux.ErrorMessageAdminDiscoveryNotFound,
+	}
+}
+
+func toSubmitError(err error) error {
+	if errors.Is(err, discovery.ErrInvalidMetadata) {
+		return &flux.ServeError{
+			StatusCode: flux.StatusBadRequest,
+			ErrorCode:  flux.ErrorCodeRequestInvalid,
+			Message:    err.Error(),
+			CauseError: err,
+		}
+	}
+	return &flux.ServeError{
+		StatusCode: http.StatusServiceUnavailable,
+		ErrorCode:  flux.ErrorCodeGatewayInternal,
+		Message:    flux.ErrorMessageAdminDiscoverySubmitFailed,
+		CauseError: err,
+	}
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/discovery"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWriteToken = "test-token"
+
+func newTestDiscoveryListener(t *testing.T) (flux.WebListener, chan flux.EndpointEvent, chan flux.ServiceEvent) {
+	config := flux.NewVarsConfiguration(map[string]interface{}{
+		discovery.ConfigKeyWriteEnable: true,
+		discovery.ConfigKeyWriteToken:  testWriteToken,
+	})
+	webl := newTestAdminListener()
+	listener.WithHandlers(DiscoveryHandlers(config))(webl)
+	d, err := lookupAdminDiscovery()
+	if nil != err {
+		t.Fatal(err)
+	}
+	_ = d.OnInit(config)
+	endpoints := make(chan flux.EndpointEvent, 4)
+	services := make(chan flux.ServiceEvent, 4)
+	_ = d.SubscribeEndpoints(context.Background(), endpoints)
+	_ = d.SubscribeServices(context.Background(), services)
+	return webl, endpoints, services
+}
+
+func submitTest(webl flux.WebListener, method, uri, body string) int {
+	return submitTestWithToken(webl, method, uri, body, testWriteToken)
+}
+
+func submitTestWithToken(webl flux.WebListener, method, uri, body, token string) int {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, uri, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set(flux.HeaderAuthorization, "Bearer "+token)
+	}
+	webl.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestDiscoveryHandlersGated(t *testing.T) {
+	tester := assert.New(t)
+	// 默认关闭
+	tester.Empty(DiscoveryHandlers(flux.NewVarsConfiguration(nil)))
+	// 未配置访问令牌
+	tester.Empty(DiscoveryHandlers(flux.NewVarsConfiguration(map[string]interface{}{
+		discovery.ConfigKeyWriteEnable: true,
+	})))
+	webl, endpoints, _ := newTestDiscoveryListener(t)
+	body := `{"httpMethod":"get","httpPattern":"/orders","serviceId":"com.foo.OrderService:list","version":"v1"}`
+	tester.Equal(401, submitTestWithToken(webl, "POST", "/discovery/endpoints", body, ""))
+	tester.Equal(401, submitTestWithToken(webl, "POST", "/discovery/endpoints", body, "bad-token"))
+	tester.Equal(0, len(endpoints))
+}
+
+func TestDiscoveryEndpointEvents(t *testing.T) {
+	tester := assert.New(t)
+	registerTestEndpoints()
+	webl, endpoints, _ := newTestDiscoveryListener(t)
+	body := `{"httpMethod":"get","httpPattern":"/orders","serviceId":"com.foo.OrderService:list","version":"v1"}`
+	tester.Equal(200, submitTest(webl, "POST", "/discovery/endpoints", body))
+	evt := <-endpoints
+	tester.Equal(flux.EventType(flux.EventTypeAdded), evt.EventType)
+	tester.Equal("GET", evt.Endpoint.HttpMethod)
+	tester.NotNil(evt.Endpoint.Annotations)
+	// update on unknown endpoint
+	tester.Equal(404, submitTest(webl, "PUT", "/discovery/endpoints", body))
+	// remove registered endpoint
+	tester.Equal(200, submitTest(webl, "DELETE", "/discovery/endpoints?method=GET&pattern=/users/{id}&version=v2", ""))
+	evt = <-endpoints
+	tester.Equal(flux.EventType(flux.EventTypeRemoved), evt.EventType)
+	tester.Equal("v2", evt.Endpoint.Version)
+}
+
+func TestDiscoveryRejectInvalid(t *testing.T) {
+	tester := assert.New(t)
+	webl, endpoints, _ := newTestDiscoveryListener(t)
+	// missing serviceId
+	tester.Equal(400, submitTest(webl, "POST", "/discovery/endpoints", `{"httpMethod":"GET","httpPattern":"/orders"}`))
+	// invalid annotation key
+	tester.Equal(400, submitTest(webl, "POST", "/discovery/endpoints",
+		`{"httpMethod":"GET","httpPattern":"/orders","serviceId":"a:b","annotations":{"#bad":"v"}}`))
+	// unsupported method
+	tester.Equal(400, submitTest(webl, "POST", "/discovery/endpoints",
+		`{"httpMethod":"CONNECT","httpPattern":"/orders","serviceId":"a:b"}`))
+	// invalid service
+	tester.Equal(400, submitTest(webl, "POST", "/discovery/services", `{"interface":"com.foo.Bar"}`))
+	tester.Equal(0, len(endpoints))
+}
+
+func TestDiscoveryServiceEvents(t *testing.T) {
+	tester := assert.New(t)
+	registerTestEndpoints()
+	webl, _, services := newTestDiscoveryListener(t)
+	body := `{"interface":"com.foo.UserService","method":"get","protocol":"DUBBO"}`
+	tester.Equal(200, submitTest(webl, "PUT", "/discovery/services", body))
+	evt := <-services
+	tester.Equal(flux.EventType(flux.EventTypeUpdated), evt.EventType)
+	tester.Equal(flux.ProtoDubbo, evt.Service.Protocol)
+	tester.Equal(200, submitTest(webl, "DELETE", "/discovery/services?id=com.foo.UserService:get", ""))
+	evt = <-services
+	tester.Equal(flux.EventType(flux.EventTypeRemoved), evt.EventType)
+}
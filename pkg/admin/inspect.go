@@ -0,0 +1,279 @@
+package admin
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/server"
+)
+
+// 查询参数
+const (
+	QueryKeyApplication = "application"
+	QueryKeyProtocol    = "protocol"
+	QueryKeyListener    = "listener"
+	QueryKeyVersion     = "version"
+	QueryKeyMethod      = "method"
+	QueryKeyPattern     = "pattern"
+	QueryKeyServiceId   = "id"
+)
+
+type (
+	// EndpointData 查询返回的Endpoint数据，包含同一Method+Pattern绑定的多版本Endpoint
+	EndpointData struct {
+		Key         string               `json:"key"`
+		HttpMethod  string               `json:"httpMethod"`
+		HttpPattern string               `json:"httpPattern"`
+		Versions    []string             `json:"versions"`
+		Endpoints   []*flux.EndpointSpec `json:"endpoints"`
+	}
+	// ServiceData 查询返回的Service数据，包含引用此Service的Endpoint版本
+	ServiceData struct {
+		ServiceId string            `json:"serviceId"`
+		Service   flux.ServiceSpec  `json:"service"`
+		Bindings  []ServiceBindData `json:"bindings"`
+	}
+	// ServiceBindData 引用Service的Endpoint
+	ServiceBindData struct {
+		Key     string `json:"key"`
+		Version string `json:"version"`
+	}
+	// ComponentData 查询返回的组件数据：Transporter/Filter/Plugin/Discovery
+	ComponentData struct {
+		Id    string `json:"id"`
+		Type  string `json:"type"`
+		Kind  string `json:"kind,omitempty"`
+		Order int    `json:"order"`
+	}
+)
+
+// InspectHandlers 返回查询运行时Endpoint/Service/组件注册信息的管理接口列表
+func InspectHandlers() []listener.WebHandlerTuple {
+	return []listener.WebHandlerTuple{
+		{Method: http.MethodGet, Pattern: "/inspect/endpoints", Handler: InspectEndpointsHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/endpoint", Handler: InspectEndpointHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/services", Handler: InspectServicesHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/service", Handler: InspectServiceHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/transporters", Handler: InspectTransportersHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/filters", Handler: InspectFiltersHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/plugins", Handler: InspectPluginsHandler},
+		{Method: http.MethodGet, Pattern: "/inspect/discoveries", Handler: InspectDiscoveriesHandler},
+	}
+}
+
+// InspectEndpointsHandler 查询Endpoint列表；支持按application,protocol,listener,version过滤
+func InspectEndpointsHandler(webex flux.WebContext) error {
+	filter := newEndpointFilter(webex)
+	out := make([]EndpointData, 0, 16)
+	for key, mvce := range ext.Endpoints() {
+		if data, ok := toEndpointData(key, mvce, filter); ok {
+			out = append(out, data)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+	return WriteJSON(webex, flux.StatusOK, out)
+}
+
+// InspectEndpointHandler 按method+pattern查询单个Endpoint及其绑定的版本；可指定version过滤
+func InspectEndpointHandler(webex flux.WebContext) error {
+	method, pattern := webex.QueryVar(QueryKeyMethod), webex.QueryVar(QueryKeyPattern)
+	if method == "" || pattern == "" {
+		return NewInvalidRequestError("query <method>, <pattern> is required")
+	}
+	key := ext.MakeEndpointKey(method, pattern)
+	if mvce, ok := ext.EndpointByKey(key); ok {
+		if data, ok := toEndpointData(key, mvce, newEndpointFilter(webex)); ok {
+			return WriteJSON(webex, flux.StatusOK, data)
+		}
+	}
+	return NewNotFoundError("endpoint not found, key: " + key)
+}
+
+// InspectServicesHandler 查询Service列表；支持按application,protocol过滤
+func InspectServicesHandler(webex flux.WebContext) error {
+	application, protocol := webex.QueryVar(QueryKeyApplication), webex.QueryVar(QueryKeyProtocol)
+	bindings := serviceBindings()
+	out := make([]ServiceData, 0, 16)
+	for id, service := range ext.Services() {
+		if !matchValue(application, service.Application) || !matchValue(protocol, service.Protocol) {
+			continue
+		}
+		out = append(out, ServiceData{ServiceId: id, Service: service, Bindings: bindings[id]})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ServiceId < out[j].ServiceId
+	})
+	return WriteJSON(webex, flux.StatusOK, out)
+}
+
+// InspectServiceHandler 按ServiceId查询单个Service及其绑定的Endpoint
+func InspectServiceHandler(webex flux.WebContext) error {
+	id := webex.QueryVar(QueryKeyServiceId)
+	if id == "" {
+		return NewInvalidRequestError("query <id> is required")
+	}
+	service, ok := ext.ServiceByID(id)
+	if !ok {
+		return NewNotFoundError("service not found, id: " + id)
+	}
+	return WriteJSON(webex, flux.StatusOK, ServiceData{ServiceId: id, Service: service, Bindings: serviceBindings()[id]})
+}
+
+// InspectTransportersHandler 查询已注册的Transporter列表
+func InspectTransportersHandler(webex flux.WebContext) error {
+	out := make([]ComponentData, 0, 4)
+	for proto, t := range ext.Transporters() {
+		out = append(out, ComponentData{Id: proto, Type: typeOf(t), Order: orderOf(t)})
+	}
+	return WriteJSON(webex, flux.StatusOK, sortComponents(out))
+}
+
+// InspectFiltersHandler 查询已注册的Filter列表，按全局和可选分类并按顺序排列
+func InspectFiltersHandler(webex flux.WebContext) error {
+	out := make([]ComponentData, 0, 16)
+	for _, f := range ext.GlobalFilters() {
+		out = append(out, ComponentData{Id: f.FilterId(), Type: typeOf(f), Kind: "global", Order: orderOf(f)})
+	}
+	for _, f := range ext.SelectiveFilters() {
+		out = append(out, ComponentData{Id: f.FilterId(), Type: typeOf(f), Kind: "selective", Order: orderOf(f)})
+	}
+	return WriteJSON(webex, flux.StatusOK, sortComponents(out))
+}
+
+// InspectPluginsHandler 查询已注册的Plugin列表，按全局和可选分类并按顺序排列
+func InspectPluginsHandler(webex flux.WebContext) error {
+	out := make([]ComponentData, 0, 16)
+	for _, p := range ext.GlobalPlugins() {
+		out = append(out, ComponentData{Id: p.PluginId(), Type: typeOf(p), Kind: "global", Order: orderOf(p)})
+	}
+	for _, p := range ext.SelectivePlugins() {
+		out = append(out, ComponentData{Id: p.PluginId(), Type: typeOf(p), Kind: "selective", Order: orderOf(p)})
+	}
+	return WriteJSON(webex, flux.StatusOK, sortComponents(out))
+}
+
+// InspectDiscoveriesHandler 查询已注册的MetadataDiscovery列表
+func InspectDiscoveriesHandler(webex flux.WebContext) error {
+	out := make([]ComponentData, 0, 4)
+	for _, d := range ext.MetadataDiscoveries() {
+		out = append(out, ComponentData{Id: d.Id(), Type: typeOf(d), Order: orderOf(d)})
+	}
+	return WriteJSON(webex, flux.StatusOK, sortComponents(out))
+}
+
+// WriteJSON 以JSON格式输出响应数据
+func WriteJSON(webex flux.WebContext, status int, data interface{}) error {
+	bytes, err := ext.JSONMarshalObject(data)
+	if nil != err {
+		return &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageAdminSerializeFailed,
+			CauseError: err,
+		}
+	}
+	return webex.Write(status, flux.MIMEApplicationJSONCharsetUTF8, bytes)
+}
+
+// NewInvalidRequestError 返回请求参数无效的错误
+func NewInvalidRequestError(message string) *flux.ServeError {
+	return &flux.ServeError{
+		StatusCode: flux.StatusBadRequest,
+		ErrorCode:  flux.ErrorCodeRequestInvalid,
+		Message:    message,
+	}
+}
+
+// NewNotFoundError 返回查询数据不存在的错误
+func NewNotFoundError(message string) *flux.ServeError {
+	return &flux.ServeError{
+		StatusCode: flux.StatusNotFound,
+		ErrorCode:  flux.ErrorCodeRequestNotFound,
+		Message:    message,
+	}
+}
+
+type endpointFilter struct {
+	application, protocol, listener, version string
+}
+
+func newEndpointFilter(webex flux.WebContext) endpointFilter {
+	return endpointFilter{
+		application: webex.QueryVar(QueryKeyApplication),
+		protocol:    webex.QueryVar(QueryKeyProtocol),
+		listener:    webex.QueryVar(QueryKeyListener),
+		version:     webex.QueryVar(QueryKeyVersion),
+	}
+}
+
+func (f endpointFilter) match(ep *flux.EndpointSpec) bool {
+	return matchValue(f.application, ep.Application) &&
+		matchValue(f.protocol, ep.Service.Protocol) &&
+		matchValue(f.listener, server.ListenerIdOf(ep)) &&
+		matchValue(f.version, ep.Version)
+}
+
+func toEndpointData(key string, mvce *flux.MVCEndpoint, filter endpointFilter) (EndpointData, bool) {
+	data := EndpointData{Key: key, Versions: make([]string, 0, 2), Endpoints: make([]*flux.EndpointSpec, 0, 2)}
+	for _, ep := range mvce.Endpoints() {
+		if !filter.match(ep) {
+			continue
+		}
+		data.HttpMethod, data.HttpPattern = ep.HttpMethod, ep.HttpPattern
+		data.Versions = append(data.Versions, ep.Version)
+		data.Endpoints = append(data.Endpoints, ep)
+	}
+	sort.Strings(data.Versions)
+	sort.Slice(data.Endpoints, func(i, j int) bool {
+		return data.Endpoints[i].Version < data.Endpoints[j].Version
+	})
+	return data, len(data.Endpoints) > 0
+}
+
+func serviceBindings() map[string][]ServiceBindData {
+	out := make(map[string][]ServiceBindData, 16)
+	for key, mvce := range ext.Endpoints() {
+		for _, ep := range mvce.Endpoints() {
+			out[ep.ServiceId] = append(out[ep.ServiceId], ServiceBindData{Key: key, Version: ep.Version})
+		}
+	}
+	return out
+}
+
+func matchValue(expected, actual string) bool {
+	return expected == "" || strings.EqualFold(expected, actual)
+}
+
+// sortComponents 组件列表按分类、顺序和标识排列
+func sortComponents(in []ComponentData) []ComponentData {
+	sort.Slice(in, func(i, j int) bool {
+		if in[i].Kind != in[j].Kind {
+			return in[i].Kind < in[j].Kind
+		}
+		if in[i].Order == in[j].Order {
+			return in[i].Id < in[j].Id
+		}
+		return in[i].Order < in[j].Order
+	})
+	return in
+}
+
+func typeOf(v interface{}) string {
+	return reflect.TypeOf(v).String()
+}
+
+func orderOf(v interface{}) int {
+	if o, ok := v.(flux.Orderer); ok {
+		return o.Order()
+	}
+	return 0
+}
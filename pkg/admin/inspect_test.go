@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+type stdJSONSerializer struct{}
+
+func (stdJSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONSerializer) Unmarshal(d []byte, v interface{}) error {
+	return json.Unmarshal(d, v)
+}
+
+func newTestAdminListener() flux.WebListener {
+	ext.RegisterSerializer(ext.TypeNameSerializerJson, stdJSONSerializer{})
+	return listener.New("admin-test", flux.NewConfiguration("admin_test"), nil,
+		listener.WithHandlers(InspectHandlers()),
+	)
+}
+
+func serveTest(webl flux.WebListener, uri string, out interface{}) int {
+	recorder := httptest.NewRecorder()
+	webl.ServeHTTP(recorder, httptest.NewRequest("GET", uri, nil))
+	if out != nil {
+		_ = json.Unmarshal(recorder.Body.Bytes(), out)
+	}
+	return recorder.Code
+}
+
+func registerTestEndpoints() {
+	service := flux.ServiceSpec{Application: "app-a", Protocol: flux.ProtoHttp, Interface: "com.foo.UserService", Method: "get"}
+	ext.RegisterService(service)
+	newEndpoint := func(version, app string) *flux.EndpointSpec {
+		return &flux.EndpointSpec{
+			Application: app, Version: version, HttpMethod: "GET", HttpPattern: "/users/{id}",
+			ServiceId: service.ServiceID(), Service: service,
+			Attributes: flux.Attributes{}, Annotations: flux.Annotations{},
+		}
+	}
+	key := ext.MakeEndpointKey("GET", "/users/{id}")
+	mvce := ext.RegisterEndpoint(key, newEndpoint("v1", "app-a"))
+	mvce.Update("v2", newEndpoint("v2", "app-b"))
+}
+
+func TestInspectEndpoints(t *testing.T) {
+	tester := assert.New(t)
+	registerTestEndpoints()
+	webl := newTestAdminListener()
+	all := make([]EndpointData, 0)
+	tester.Equal(200, serveTest(webl, "/inspect/endpoints", &all))
+	tester.Equal(1, len(all))
+	tester.Equal([]string{"v1", "v2"}, all[0].Versions)
+	// filter by application
+	apps := make([]EndpointData, 0)
+	tester.Equal(200, serveTest(webl, "/inspect/endpoints?application=app-b", &apps))
+	tester.Equal(1, len(apps))
+	tester.Equal([]string{"v2"}, apps[0].Versions)
+	// filter by listener
+	lis := make([]EndpointData, 0)
+	tester.Equal(200, serveTest(webl, "/inspect/endpoints?listener=admin", &lis))
+	tester.Equal(0, len(lis))
+}
+
+func TestInspectEndpoint(t *testing.T) {
+	tester := assert.New(t)
+	registerTestEndpoints()
+	webl := newTestAdminListener()
+	data := EndpointData{}
+	tester.Equal(200, serveTest(webl, "/inspect/endpoint?method=get&pattern=/users/{id}&version=v1", &data))
+	tester.Equal("GET#/users/{id}", data.Key)
+	tester.Equal([]string{"v1"}, data.Versions)
+	tester.Equal(404, serveTest(webl, "/inspect/endpoint?method=get&pattern=/none", nil))
+	tester.Equal(400, serveTest(webl, "/inspect/endpoint", nil))
+}
+
+func TestInspectService(t *testing.T) {
+	tester := assert.New(t)
+	registerTestEndpoints()
+	webl := newTestAdminListener()
+	data := ServiceData{}
+	tester.Equal(200, serveTest(webl, "/inspect/service?id=com.foo.UserService:get", &data))
+	tester.Equal("app-a", data.Service.Application)
+	tester.Equal(2, len(data.Bindings))
+	list := make([]ServiceData, 0)
+	tester.Equal(200, serveTest(webl, "/inspect/services?protocol=DUBBO", &list))
+	tester.Equal(0, len(list))
+	tester.Equal(404, serveTest(webl, "/inspect/service?id=none:none", nil))
+}
+
+func TestSortComponents(t *testing.T) {
+	tester := assert.New(t)
+	out := sortComponents([]ComponentData{
+		{Id: "b", Kind: "selective", Order: 1},
+		{Id: "c", Kind: "global", Order: 2},
+		{Id: "a", Kind: "selective", Order: 1},
+		{Id: "d", Kind: "global", Order: 1},
+	})
+	ids := make([]string, 0, len(out))
+	for _, c := range out {
+		ids = append(ids, c.Id)
+	}
+	tester.Equal([]string{"d", "c", "a", "b"}, ids)
+}
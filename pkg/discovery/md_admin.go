@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+)
+
+const (
+	AdminId = "admin"
+)
+
+const (
+	ConfigKeySubmitTimeout = "submit_timeout"
+	// ConfigKeyWriteEnable 是否允许通过管理接口提交变更事件；默认关闭
+	ConfigKeyWriteEnable = "write_enable"
+	// ConfigKeyWriteToken 提交变更的管理接口的访问令牌
+	ConfigKeyWriteToken = "write_token"
+)
+
+var (
+	ErrInvalidMetadata   = errors.New("DISCOVERY:ADMIN:METADATA/invalid")
+	ErrDiscoveryNotReady = errors.New("DISCOVERY:ADMIN:NOT_READY")
+	ErrSubmitTimeout     = errors.New("DISCOVERY:ADMIN:SUBMIT/timeout")
+)
+
+var _ flux.MetadataDiscovery = new(AdminMetadataDiscovery)
+
+// AdminMetadataDiscovery 通过管理接口在运行时提交Endpoint/Service变更事件的元数据发现服务
+type AdminMetadataDiscovery struct {
+	id        string
+	enabled   bool
+	timeout   time.Duration
+	ctx       context.Context
+	endpoints chan<- flux.EndpointEvent
+	services  chan<- flux.ServiceEvent
+	mutex     sync.RWMutex
+}
+
+// NewAdminMetadataDiscovery returns new an admin api based discovery service
+func NewAdminMetadataDiscovery(id string) *AdminMetadataDiscovery {
+	return &AdminMetadataDiscovery{
+		id:      id,
+		timeout: time.Second * 3,
+	}
+}
+
+func (d *AdminMetadataDiscovery) Id() string {
+	return d.id
+}
+
+func (d *AdminMetadataDiscovery) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeySubmitTimeout: "3s",
+	})
+	d.timeout = config.GetDuration(ConfigKeySubmitTimeout)
+	d.enabled = config.GetBool(ConfigKeyWriteEnable)
+	return nil
+}
+
+// SubscribeEndpoints 未开启 write_enable 时不订阅事件队列，提交变更返回 ErrDiscoveryNotReady 错误
+func (d *AdminMetadataDiscovery) SubscribeEndpoints(ctx context.Context, queue chan<- flux.EndpointEvent) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.enabled {
+		return nil
+	}
+	d.ctx = ctx
+	d.endpoints = queue
+	return nil
+}
+
+func (d *AdminMetadataDiscovery) SubscribeServices(ctx context.Context, queue chan<- flux.ServiceEvent) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.enabled {
+		return nil
+	}
+	d.ctx = ctx
+	d.services = queue
+	return nil
+}
+
+// SubmitEndpoint 校验并提交Endpoint变更事件；校验失败返回 ErrInvalidMetadata 错误。
+func (d *AdminMetadataDiscovery) SubmitEndpoint(event flux.EndpointEvent) error {
+	if err := VerifyEndpoint(&event.Endpoint); nil != err {
+		return err
+	}
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.endpoints == nil || d.ctx.Err() != nil {
+		return ErrDiscoveryNotReady
+	}
+	logger.Infow("DISCOVERY:ADMIN:ENDPOINT/submit", "event-type", flux.EventTypeName(int(event.EventType)),
+		"ep-method", event.Endpoint.HttpMethod, "ep-pattern", event.Endpoint.HttpPattern, "ep-version", event.Endpoint.Version)
+	select {
+	case d.endpoints <- event:
+		return nil
+	case <-d.ctx.Done():
+		return ErrDiscoveryNotReady
+	case <-time.After(d.timeout):
+		return ErrSubmitTimeout
+	}
+}
+
+// SubmitService 校验并提交Service变更事件；校验失败返回 ErrInvalidMetadata 错误。
+func (d *AdminMetadataDiscovery) SubmitService(event flux.ServiceEvent) error {
+	if err := VerifyService(&event.Service); nil != err {
+		return err
+	}
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.services == nil || d.ctx.Err() != nil {
+		return ErrDiscoveryNotReady
+	}
+	logger.Infow("DISCOVERY:ADMIN:SERVICE/submit", "event-type", flux.EventTypeName(int(event.EventType)),
+		"service-id", event.Service.ServiceID())
+	select {
+	case d.services <- event:
+		return nil
+	case <-d.ctx.Done():
+		return ErrDiscoveryNotReady
+	case <-time.After(d.timeout):
+		return ErrSubmitTimeout
+	}
+}
+
+// VerifyEndpoint 校验Endpoint元数据是否有效
+func VerifyEndpoint(ep *flux.EndpointSpec) error {
+	if ep.Annotations == nil {
+		ep.Annotations = make(flux.Annotations, 0)
+	}
+	if ep.Attributes == nil {
+		ep.Attributes = make(flux.Attributes, 0)
+	}
+	if !ep.IsValid() {
+		return fmt.Errorf("%w: endpoint requires <httpMethod>, <httpPattern>, <serviceId>", ErrInvalidMetadata)
+	}
+	if err := internal.VerifyAnnotations(ep.Annotations); nil != err {
+		return fmt.Errorf("%w: endpoint %s", ErrInvalidMetadata, err)
+	}
+	if ep.Service.IsValid() {
+		return verifyServiceAnnotations(&ep.Service)
+	}
+	return nil
+}
+
+// VerifyService 校验Service元数据是否有效
+func VerifyService(service *flux.ServiceSpec) error {
+	if !service.IsValid() {
+		return fmt.Errorf("%w: service requires <interface>, <method>, <protocol>", ErrInvalidMetadata)
+	}
+	return verifyServiceAnnotations(service)
+}
+
+func verifyServiceAnnotations(service *flux.ServiceSpec) error {
+	if service.Annotations == nil {
+		service.Annotations = make(flux.Annotations, 0)
+	}
+	if err := internal.VerifyAnnotations(service.Annotations); nil != err {
+		return fmt.Errorf("%w: service %s", ErrInvalidMetadata, err)
+	}
+	return nil
+}
@@ -22,6 +22,11 @@ func RegisterEndpoint(key string, endpoint *flux.EndpointSpec) *flux.MVCEndpoint
 	return mvce
 }
 
+// RemoveEndpoint 删除指定Key的多版本Endpoint
+func RemoveEndpoint(key string) {
+	endpoints.Delete(key)
+}
+
 func EndpointByKey(key string) (*flux.MVCEndpoint, bool) {
 	ep, ok := endpoints.Load(key)
 	if ok {
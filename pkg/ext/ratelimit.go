@@ -0,0 +1,27 @@
+package ext
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+)
+
+var (
+	rateLimitStores = make(map[string]flux.RateLimitStore, 2)
+)
+
+func RegisterRateLimitStore(id string, store flux.RateLimitStore) {
+	id = flux.MustNotEmpty(id, "<store-id> must not empty")
+	rateLimitStores[id] = flux.MustNotNil(store, "<rate-limit-store> must not nil").(flux.RateLimitStore)
+}
+
+func RateLimitStoreById(id string) (flux.RateLimitStore, bool) {
+	store, ok := rateLimitStores[id]
+	return store, ok
+}
+
+func RateLimitStores() map[string]flux.RateLimitStore {
+	m := make(map[string]flux.RateLimitStore, len(rateLimitStores))
+	for id, s := range rateLimitStores {
+		m[id] = s
+	}
+	return m
+}
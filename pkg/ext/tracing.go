@@ -0,0 +1,28 @@
+package ext
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var (
+	traceExporters = make(map[string]sdktrace.SpanExporter, 2)
+)
+
+func RegisterTraceExporter(id string, exporter sdktrace.SpanExporter) {
+	id = flux.MustNotEmpty(id, "<exporter-id> must not empty")
+	traceExporters[id] = flux.MustNotNil(exporter, "<trace-exporter> must not nil").(sdktrace.SpanExporter)
+}
+
+func TraceExporterById(id string) (sdktrace.SpanExporter, bool) {
+	exporter, ok := traceExporters[id]
+	return exporter, ok
+}
+
+func TraceExporters() map[string]sdktrace.SpanExporter {
+	m := make(map[string]sdktrace.SpanExporter, len(traceExporters))
+	for id, e := range traceExporters {
+		m[id] = e
+	}
+	return m
+}
@@ -0,0 +1,194 @@
+package filter
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+const (
+	TypeIdApiKeyFilter = "apikey_filter"
+)
+
+const (
+	ConfigKeyApiKeyLookup      = "lookup"
+	ConfigKeyApiKeyStore       = "store"
+	ConfigKeyApiKeyCredentials = "credentials"
+	ConfigKeyApiKeyFile        = "file"
+	ConfigKeyApiKeyServiceId   = "service_id"
+)
+
+// ApiKey绑定到Attribute的键名，前缀由 attachment_key 配置
+const (
+	ApiKeyAttrKey          = "key"
+	ApiKeyAttrConsumer     = "consumer"
+	ApiKeyAttrApplications = "applications"
+	ApiKeyAttrEndpoints    = "endpoints"
+)
+
+var (
+	_ flux.Filter          = new(ApiKeyFilter)
+	_ flux.Initializer     = new(ApiKeyFilter)
+	_ flux.FilterSkippable = new(ApiKeyFilter)
+)
+
+// ApiKeyConfig ApiKey认证配置
+type ApiKeyConfig struct {
+	SkipFunc flux.FilterSkipper
+	// 凭证存储；未设置时，根据 store 配置创建
+	Store CredentialStore
+	// 查找ApiKey的表达式，例如：HEADER:X-Api-Key, QUERY:api_key
+	LookupExpr   string
+	AttKeyPrefix string
+}
+
+func NewApiKeyFilter(c ApiKeyConfig) *ApiKeyFilter {
+	return &ApiKeyFilter{
+		Config: c,
+	}
+}
+
+// ApiKeyFilter 基于静态ApiKey的认证；校验通过后，将调用方身份和访问范围绑定到Attribute
+type ApiKeyFilter struct {
+	Config ApiKeyConfig
+}
+
+func (f *ApiKeyFilter) FilterId() string {
+	return TypeIdApiKeyFilter
+}
+
+func (f *ApiKeyFilter) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyApiKeyLookup:    "HEADER:X-Api-Key",
+		ConfigKeyApiKeyStore:     CredentialStoreConfig,
+		ConfigKeyAttachmentKey:   "apikey",
+		ConfigKeyCacheExpiration: time.Minute,
+		ConfigKeyCacheSize:       10000,
+	})
+	if flux.IsNil(f.Config.SkipFunc) {
+		f.Config.SkipFunc = func(_ flux.Context) bool {
+			return false
+		}
+	}
+	if "" == f.Config.LookupExpr {
+		f.Config.LookupExpr = config.GetString(ConfigKeyApiKeyLookup)
+	}
+	if _, _, ok := toolkit.ParseScopeExpr(f.Config.LookupExpr); !ok {
+		return fmt.Errorf("apikey: invalid lookup expr: %s", f.Config.LookupExpr)
+	}
+	if "" == f.Config.AttKeyPrefix {
+		f.Config.AttKeyPrefix = config.GetString(ConfigKeyAttachmentKey)
+	}
+	if f.Config.Store != nil {
+		return nil
+	}
+	switch store := config.GetString(ConfigKeyApiKeyStore); store {
+	case CredentialStoreConfig:
+		s, err := NewConfigCredentialStore(cast.ToSlice(config.Get(ConfigKeyApiKeyCredentials)))
+		if nil != err {
+			return err
+		}
+		f.Config.Store = s
+	case CredentialStoreFile:
+		s, err := NewFileCredentialStore(config.GetString(ConfigKeyApiKeyFile))
+		if nil != err {
+			return err
+		}
+		f.Config.Store = s
+	case CredentialStoreService:
+		id := config.GetString(ConfigKeyApiKeyServiceId)
+		if "" == id {
+			return fmt.Errorf("apikey: <%s> is required for service store", ConfigKeyApiKeyServiceId)
+		}
+		f.Config.Store = NewServiceCredentialStore(id, f.Config.AttKeyPrefix+"."+ApiKeyAttrKey,
+			config.GetInt(ConfigKeyCacheSize), config.GetDuration(ConfigKeyCacheExpiration))
+	default:
+		return fmt.Errorf("apikey: unsupported credential store: %s", store)
+	}
+	logger.Infow("AUTHORIZATION:APIKEY:INIT", "store", config.GetString(ConfigKeyApiKeyStore), "lookup", f.Config.LookupExpr)
+	return nil
+}
+
+func (f *ApiKeyFilter) ShouldSkip(ctx flux.Context) bool {
+	return f.Config.SkipFunc(ctx)
+}
+
+func (f *ApiKeyFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		if f.Config.SkipFunc(ctx) {
+			return next(ctx)
+		}
+		defer func() {
+			ctx.AddMetric(f.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		key := common.LookupWebValueByExpr(ctx, f.Config.LookupExpr)
+		if "" == key {
+			return &flux.ServeError{
+				StatusCode: http.StatusUnauthorized,
+				ErrorCode:  flux.ErrorCodeApiKeyNotFound,
+				Message:    "APIKEY:VALIDATE: key not found",
+			}
+		}
+		credential, err := f.Config.Store.Lookup(ctx, key)
+		if nil != err {
+			return &flux.ServeError{
+				StatusCode: flux.StatusServerError,
+				ErrorCode:  flux.ErrorCodeGatewayInternal,
+				Message:    "APIKEY:VALIDATE: credential lookup failed",
+				CauseError: err,
+			}
+		}
+		if serr := f.verify(ctx, credential); serr != nil {
+			ctx.Logger().Infow("AUTHORIZATION:APIKEY:VALIDATE:REJECTED", "apikey", toolkit.Mask(key, 4), "error", serr.ErrorCode)
+			return serr
+		}
+		ctx.Logger().Infow("AUTHORIZATION:APIKEY:VALIDATE:PASSED", "consumer", credential.Consumer)
+		ctx.SetAttribute(f.Config.AttKeyPrefix+"."+ApiKeyAttrConsumer, credential.Consumer)
+		ctx.SetAttribute(f.Config.AttKeyPrefix+"."+ApiKeyAttrApplications, credential.Applications)
+		ctx.SetAttribute(f.Config.AttKeyPrefix+"."+ApiKeyAttrEndpoints, credential.Endpoints)
+		return next(ctx)
+	}
+}
+
+func (f *ApiKeyFilter) verify(ctx flux.Context, credential *ApiKeyCredential) *flux.ServeError {
+	if credential == nil {
+		return &flux.ServeError{
+			StatusCode: http.StatusUnauthorized,
+			ErrorCode:  flux.ErrorCodeApiKeyInvalid,
+			Message:    "APIKEY:VALIDATE: key is invalid",
+		}
+	}
+	if credential.Disabled {
+		return &flux.ServeError{
+			StatusCode: http.StatusForbidden,
+			ErrorCode:  flux.ErrorCodeApiKeyDisabled,
+			Message:    "APIKEY:VALIDATE: key is disabled",
+		}
+	}
+	if credential.IsExpired(time.Now()) {
+		return &flux.ServeError{
+			StatusCode: http.StatusUnauthorized,
+			ErrorCode:  flux.ErrorCodeApiKeyExpired,
+			Message:    "APIKEY:VALIDATE: key is expired",
+		}
+	}
+	pattern, method, _ := ctx.Exposed()
+	if !credential.Allows(ctx.Application(), method, pattern) {
+		return &flux.ServeError{
+			StatusCode: http.StatusForbidden,
+			ErrorCode:  flux.ErrorCodeApiKeyOutOfScope,
+			Message:    "APIKEY:VALIDATE: key is out of scope",
+			CauseError: fmt.Errorf("consumer: %s, application: %s, endpoint: %s %s",
+				credential.Consumer, ctx.Application(), method, pattern),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package filter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	CredentialStoreConfig  = "config"
+	CredentialStoreFile    = "file"
+	CredentialStoreService = "service"
+)
+
+// ApiKeyCredential ApiKey的凭证信息
+type ApiKeyCredential struct {
+	Key          string    // ApiKey
+	Consumer     string    // 调用方身份标识
+	Disabled     bool      // 是否已禁用
+	ExpiresAt    time.Time // 过期时间；零值表示永不过期
+	Applications []string  // 允许访问的应用；为空表示不限制；支持 * 通配
+	Endpoints    []string  // 允许访问的Endpoint，格式：[METHOD ]/pattern；为空表示不限制；支持 /prefix/* 通配
+}
+
+// IsExpired 判断凭证是否已过期
+func (c *ApiKeyCredential) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt)
+}
+
+// Allows 判断凭证是否允许访问指定应用的Endpoint
+func (c *ApiKeyCredential) Allows(application, method, pattern string) bool {
+	if len(c.Applications) > 0 && !matchAny(c.Applications, application) {
+		return false
+	}
+	if len(c.Endpoints) == 0 {
+		return true
+	}
+	for _, ep := range c.Endpoints {
+		epm, epp := "", ep
+		if i := strings.IndexByte(ep, ' '); i > 0 {
+			epm, epp = ep[:i], strings.TrimSpace(ep[i+1:])
+		}
+		if epm != "" && epm != "*" && !strings.EqualFold(epm, method) {
+			continue
+		}
+		if matchWildcard(epp, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialStore ApiKey凭证存储；凭证不存在时返回 (nil, nil)
+type CredentialStore interface {
+	Lookup(ctx flux.Context, key string) (*ApiKeyCredential, error)
+}
+
+// NewApiKeyCredential 从键值数据创建凭证；用于配置、文件和服务返回的数据
+func NewApiKeyCredential(data map[string]interface{}) (*ApiKeyCredential, error) {
+	values := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		values[strings.ToLower(strings.Replace(k, "_", "", -1))] = v
+	}
+	c := &ApiKeyCredential{
+		Key:          cast.ToString(values["key"]),
+		Consumer:     cast.ToString(values["consumer"]),
+		Disabled:     cast.ToBool(values["disabled"]),
+		Applications: cast.ToStringSlice(values["applications"]),
+		Endpoints:    cast.ToStringSlice(values["endpoints"]),
+	}
+	if exp, ok := values["expiresat"]; ok && exp != nil && exp != "" {
+		t, err := cast.ToTimeE(exp)
+		if nil != err {
+			return nil, fmt.Errorf("apikey: invalid expires_at, consumer: %s, error: %w", c.Consumer, err)
+		}
+		c.ExpiresAt = t
+	}
+	if c.Key == "" {
+		return nil, fmt.Errorf("apikey: credential key is empty, consumer: %s", c.Consumer)
+	}
+	return c, nil
+}
+
+//// Memory
+
+// MemoryCredentialStore 基于内存的凭证存储；用于静态配置和文件加载的凭证
+type MemoryCredentialStore struct {
+	mu          sync.RWMutex
+	credentials map[string]*ApiKeyCredential
+}
+
+func NewMemoryCredentialStore(credentials []*ApiKeyCredential) *MemoryCredentialStore {
+	s := new(MemoryCredentialStore)
+	s.Reset(credentials)
+	return s
+}
+
+// Reset 替换全部凭证
+func (s *MemoryCredentialStore) Reset(credentials []*ApiKeyCredential) {
+	m := make(map[string]*ApiKeyCredential, len(credentials))
+	for _, c := range credentials {
+		m[c.Key] = c
+	}
+	s.mu.Lock()
+	s.credentials = m
+	s.mu.Unlock()
+}
+
+func (s *MemoryCredentialStore) Lookup(_ flux.Context, key string) (*ApiKeyCredential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.credentials[key], nil
+}
+
+// NewConfigCredentialStore 从配置的凭证列表加载凭证
+func NewConfigCredentialStore(items []interface{}) (*MemoryCredentialStore, error) {
+	credentials := make([]*ApiKeyCredential, 0, len(items))
+	for _, item := range items {
+		c, err := NewApiKeyCredential(cast.ToStringMap(item))
+		if nil != err {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+	return NewMemoryCredentialStore(credentials), nil
+}
+
+// NewFileCredentialStore 从JSON或YAML文件加载凭证列表
+func NewFileCredentialStore(path string) (*MemoryCredentialStore, error) {
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, fmt.Errorf("apikey: read credential file, path: %s, error: %w", path, err)
+	}
+	var items []map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		var raw []map[interface{}]interface{}
+		if err = yaml.Unmarshal(data, &raw); nil == err {
+			for _, item := range raw {
+				items = append(items, cast.ToStringMap(item))
+			}
+		}
+	default:
+		err = json.Unmarshal(data, &items)
+	}
+	if nil != err {
+		return nil, fmt.Errorf("apikey: decode credential file, path: %s, error: %w", path, err)
+	}
+	credentials := make([]*ApiKeyCredential, 0, len(items))
+	for _, item := range items {
+		c, err := NewApiKeyCredential(item)
+		if nil != err {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+	return NewMemoryCredentialStore(credentials), nil
+}
+
+//// Service
+
+// ServiceCredentialStore 调用后端服务查询凭证；ApiKey通过Attribute传递给服务参数，调用完成后删除该Attribute。
+// 服务返回凭证的键值数据，不存在时返回空数据；返回的key与查询的ApiKey不一致时，按凭证不存在处理。查询结果按ApiKey缓存。
+type ServiceCredentialStore struct {
+	serviceId  string
+	attrKey    string
+	expiration time.Duration
+	cache      *toolkit.LRUCache
+}
+
+func NewServiceCredentialStore(serviceId, attrKey string, cacheSize int, expiration time.Duration) *ServiceCredentialStore {
+	return &ServiceCredentialStore{
+		serviceId:  serviceId,
+		attrKey:    attrKey,
+		expiration: expiration,
+		cache:      toolkit.NewLRUCache(cacheSize, 0),
+	}
+}
+
+func (s *ServiceCredentialStore) Lookup(ctx flux.Context, key string) (*ApiKeyCredential, error) {
+	sum := sha256.Sum256([]byte(key))
+	ckey := hex.EncodeToString(sum[:])
+	if cached, ok := s.cache.Get(ckey); ok {
+		c, _ := cached.(*ApiKeyCredential)
+		return c, nil
+	}
+	service, ok := ext.ServiceByID(s.serviceId)
+	if !ok {
+		return nil, errors.New("apikey: credential service not found, id: " + s.serviceId)
+	}
+	transporter, ok := ext.TransporterByProto(service.Protocol)
+	if !ok {
+		return nil, errors.New("apikey: unknown credential service protocol: " + service.Protocol)
+	}
+	ctx.SetAttribute(s.attrKey, key)
+	resp, serr := transporter.DoInvoke(ctx, service)
+	ctx.RemoveAttribute(s.attrKey)
+	if serr != nil {
+		return nil, fmt.Errorf("apikey: invoke credential service, error: %w", serr)
+	}
+	data, err := ext.JSONMarshalObject(resp.Body)
+	if nil != err {
+		return nil, fmt.Errorf("apikey: encode credential response, error: %w", err)
+	}
+	values := make(map[string]interface{})
+	if err := ext.JSONUnmarshal(data, &values); nil != err {
+		return nil, fmt.Errorf("apikey: decode credential response, error: %w", err)
+	}
+	var credential *ApiKeyCredential
+	if len(values) > 0 {
+		if _, ok := values["key"]; !ok {
+			values["key"] = key
+		}
+		if credential, err = NewApiKeyCredential(values); nil != err {
+			return nil, err
+		}
+		if credential.Key != key {
+			logger.TraceVerbose(ctx).Warnw("AUTHORIZATION:APIKEY:SERVICE:MISMATCH", "service-id", s.serviceId)
+			credential = nil
+		}
+	}
+	if s.expiration > 0 {
+		s.cache.Set(ckey, credential, 1, s.expiration)
+	}
+	return credential, nil
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if matchWildcard(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard 支持 * 匹配全部，及 prefix* 前缀匹配
+func matchWildcard(pattern, value string) bool {
+	if pattern == "*" || pattern == value {
+		return true
+	}
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package filter
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func invokeApiKeyFilter(f *ApiKeyFilter, key string) (flux.Context, *flux.ServeError) {
+	request := httptest.NewRequest("GET", "/orders/1", nil)
+	if key != "" {
+		request.Header.Set("X-Api-Key", key)
+	}
+	ctx := internal.NewContext()
+	ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+		&flux.EndpointSpec{Application: "orders", HttpMethod: "GET", HttpPattern: "/orders/{id}"})
+	return ctx, f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+		return nil
+	})(ctx)
+}
+
+func TestApiKeyFilterConfigStore(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	config := flux.NewConfiguration("apikey_test")
+	config.Set(ConfigKeyApiKeyCredentials, []interface{}{
+		map[string]interface{}{"key": "k-partner", "consumer": "partner", "applications": []string{"orders"},
+			"endpoints": []string{"GET /orders/*"}},
+		map[string]interface{}{"key": "k-disabled", "consumer": "disabled", "disabled": true},
+		map[string]interface{}{"key": "k-expired", "consumer": "expired", "expires_at": "2020-01-01T00:00:00Z"},
+		map[string]interface{}{"key": "k-users", "consumer": "users", "applications": []string{"users"}},
+	})
+	f := NewApiKeyFilter(ApiKeyConfig{})
+	tester.NoError(f.OnInit(config))
+	ctx, serr := invokeApiKeyFilter(f, "k-partner")
+	tester.Nil(serr)
+	tester.Equal("partner", ctx.Attribute("apikey.consumer", ""))
+	tester.Equal([]string{"orders"}, ctx.Attribute("apikey.applications", nil))
+	cases := map[string]string{
+		"":           flux.ErrorCodeApiKeyNotFound,
+		"k-unknown":  flux.ErrorCodeApiKeyInvalid,
+		"k-disabled": flux.ErrorCodeApiKeyDisabled,
+		"k-expired":  flux.ErrorCodeApiKeyExpired,
+		"k-users":    flux.ErrorCodeApiKeyOutOfScope,
+	}
+	for key, code := range cases {
+		_, serr := invokeApiKeyFilter(f, key)
+		tester.NotNil(serr, key)
+		tester.Equal(code, serr.ErrorCode, key)
+	}
+}
+
+func TestApiKeyFilterFileStore(t *testing.T) {
+	tester := assert.New(t)
+	dir, err := ioutil.TempDir("", "apikey")
+	tester.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials.yml")
+	tester.NoError(ioutil.WriteFile(path, []byte(`
+- key: "k-file"
+  consumer: "file-partner"
+  endpoints: [ "/orders/{id}" ]
+`), 0644))
+	config := flux.NewConfiguration("apikey_file_test")
+	config.Set(ConfigKeyApiKeyStore, CredentialStoreFile)
+	config.Set(ConfigKeyApiKeyFile, path)
+	f := NewApiKeyFilter(ApiKeyConfig{})
+	tester.NoError(f.OnInit(config))
+	ctx, serr := invokeApiKeyFilter(f, "k-file")
+	tester.Nil(serr)
+	tester.Equal("file-partner", ctx.Attribute("apikey.consumer", ""))
+}
+
+type credentialTransporter struct {
+	invokes int
+}
+
+func (t *credentialTransporter) DoInvoke(ctx flux.Context, _ flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	t.invokes++
+	switch ctx.Attribute("apikey.key", "") {
+	case "k-remote":
+	case "k-other":
+		// 返回与查询不一致的key
+		return flux.NewServeResponse(200, []byte(`{"key":"k-remote","consumer":"remote"}`)), nil
+	default:
+		return flux.NewServeResponse(200, []byte("null")), nil
+	}
+	return flux.NewServeResponse(200, []byte(`{"consumer":"remote","expiresAt":"`+
+		time.Now().Add(time.Hour).Format(time.RFC3339)+`"}`)), nil
+}
+
+func TestApiKeyFilterServiceStore(t *testing.T) {
+	tester := assert.New(t)
+	ext.RegisterSerializer(ext.TypeNameSerializerJson, flux.NewJsonSerializer())
+	transporter := &credentialTransporter{}
+	ext.RegisterTransporter("apikeytest", transporter)
+	ext.RegisterService(flux.ServiceSpec{Interface: "apikey.service", Method: "lookup", Protocol: "apikeytest"})
+	config := flux.NewConfiguration("apikey_service_test")
+	config.Set(ConfigKeyApiKeyStore, CredentialStoreService)
+	config.Set(ConfigKeyApiKeyServiceId, "apikey.service:lookup")
+	f := NewApiKeyFilter(ApiKeyConfig{})
+	tester.NoError(f.OnInit(config))
+	ctx, serr := invokeApiKeyFilter(f, "k-remote")
+	tester.Nil(serr)
+	tester.Equal("remote", ctx.Attribute("apikey.consumer", ""))
+	_, ok := ctx.AttributeEx("apikey.key")
+	tester.False(ok, "raw key removed")
+	_, serr = invokeApiKeyFilter(f, "k-remote")
+	tester.Nil(serr)
+	tester.Equal(1, transporter.invokes, "cached")
+	_, serr = invokeApiKeyFilter(f, "k-missing")
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeApiKeyInvalid, serr.ErrorCode)
+	_, serr = invokeApiKeyFilter(f, "k-other")
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeApiKeyInvalid, serr.ErrorCode)
+}
@@ -0,0 +1,164 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/spf13/cast"
+)
+
+const (
+	TypeIdAuthzFilter = "authz_filter"
+)
+
+const (
+	ConfigKeyAuthzScopeAttribute = "scope_attribute"
+	ConfigKeyAuthzRoleAttribute  = "role_attribute"
+)
+
+var (
+	_ flux.Filter      = new(AuthzFilter)
+	_ flux.Initializer = new(AuthzFilter)
+)
+
+// AuthzConfig 基于角色/Scope的授权配置
+type AuthzConfig struct {
+	SkipFunc flux.FilterSkipper
+	// 读取Scope声明的Attribute键名，例如由JWTFilter写入的 jwt.scope
+	ScopeAttribute string
+	// 读取角色声明的Attribute键名，例如由JWTFilter写入的 jwt.roles
+	RoleAttribute string
+}
+
+func NewAuthzFilter(c AuthzConfig) *AuthzFilter {
+	return &AuthzFilter{
+		Config: c,
+	}
+}
+
+// AuthzFilter 根据Endpoint注解 flux.go/authz.scopes, flux.go/authz.roles 声明的授权表达式，
+// 校验认证Filter写入Attribute的Scope和角色声明，无需调用后端权限服务。需配置在认证Filter之后。
+type AuthzFilter struct {
+	Config AuthzConfig
+	exprs  sync.Map // 已解析的授权表达式
+}
+
+func (f *AuthzFilter) FilterId() string {
+	return TypeIdAuthzFilter
+}
+
+func (f *AuthzFilter) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyAuthzScopeAttribute: "jwt.scope",
+		ConfigKeyAuthzRoleAttribute:  "jwt.roles",
+	})
+	if flux.IsNil(f.Config.SkipFunc) {
+		f.Config.SkipFunc = func(_ flux.Context) bool {
+			return false
+		}
+	}
+	if "" == f.Config.ScopeAttribute {
+		f.Config.ScopeAttribute = config.GetString(ConfigKeyAuthzScopeAttribute)
+	}
+	if "" == f.Config.RoleAttribute {
+		f.Config.RoleAttribute = config.GetString(ConfigKeyAuthzRoleAttribute)
+	}
+	logger.Infow("PERMISSION:AUTHZ:INIT", "scope-attribute", f.Config.ScopeAttribute, "role-attribute", f.Config.RoleAttribute)
+	return nil
+}
+
+func (f *AuthzFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		if f.Config.SkipFunc(ctx) {
+			return next(ctx)
+		}
+		defer func() {
+			ctx.AddMetric(f.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		if serr := f.check(ctx, flux.EndpointAnnotationAuthzScopes, f.Config.ScopeAttribute, matchScope); serr != nil {
+			return serr
+		}
+		if serr := f.check(ctx, flux.EndpointAnnotationAuthzRoles, f.Config.RoleAttribute, strings.EqualFold); serr != nil {
+			return serr
+		}
+		return next(ctx)
+	}
+}
+
+func (f *AuthzFilter) check(ctx flux.Context, annotation, attribute string, match func(granted, required string) bool) *flux.ServeError {
+	anno, ok := ctx.Endpoint().AnnotationEx(annotation)
+	if !ok || "" == strings.TrimSpace(anno.GetString()) {
+		return nil
+	}
+	expr, err := f.parse(anno.GetString())
+	if nil != err {
+		return &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayEndpoint,
+			Message:    flux.ErrorMessagePermissionVerifyError,
+			CauseError: err,
+		}
+	}
+	granted := splitClaimValues(ctx.Attribute(attribute, nil))
+	pass := expr.Eval(func(required string) bool {
+		for _, g := range granted {
+			if match(g, required) {
+				return true
+			}
+		}
+		return false
+	})
+	if pass {
+		return nil
+	}
+	ctx.Logger().Infow("PERMISSION:AUTHZ:DENIED", "annotation", annotation, "required", expr.String(), "granted", granted)
+	return &flux.ServeError{
+		StatusCode: flux.StatusAccessDenied,
+		ErrorCode:  flux.ErrorCodePermissionDenied,
+		Message:    flux.ErrorMessagePermissionAccessDenied,
+		CauseError: fmt.Errorf("%s not satisfied, required: %s", annotation, expr.String()),
+	}
+}
+
+func (f *AuthzFilter) parse(value string) (AuthzExpr, error) {
+	if expr, ok := f.exprs.Load(value); ok {
+		return expr.(AuthzExpr), nil
+	}
+	expr, err := ParseAuthzExpr(value)
+	if nil != err {
+		return nil, err
+	}
+	f.exprs.Store(value, expr)
+	return expr, nil
+}
+
+// matchScope 匹配Scope；已授权和要求的Scope均支持 * 及 prefix* 通配
+func matchScope(granted, required string) bool {
+	return matchWildcard(granted, required) || matchWildcard(required, granted)
+}
+
+// splitClaimValues 解析声明值；支持字符串数组，以及以空格或逗号分隔的字符串
+func splitClaimValues(value interface{}) []string {
+	if value == nil {
+		return nil
+	}
+	var values []string
+	if s, ok := value.(string); ok {
+		values = []string{s}
+	} else {
+		values = cast.ToStringSlice(value)
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, strings.FieldsFunc(v, func(r rune) bool {
+			return r == ' ' || r == ','
+		})...)
+	}
+	return out
+}
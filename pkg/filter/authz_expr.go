@@ -0,0 +1,148 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AuthzExpr 授权表达式；支持 AND/OR（及 &&, ||）组合和括号分组，AND优先于OR，逗号等同于OR。
+// 例如：orders:read AND (orders:write OR admin:*)
+type AuthzExpr interface {
+	// Eval 使用match函数判断每个授权项是否满足，返回表达式结果
+	Eval(match func(item string) bool) bool
+	String() string
+}
+
+type (
+	authzItem string
+	authzAnd  []AuthzExpr
+	authzOr   []AuthzExpr
+)
+
+func (i authzItem) Eval(match func(string) bool) bool {
+	return match(string(i))
+}
+
+func (i authzItem) String() string {
+	return string(i)
+}
+
+func (a authzAnd) Eval(match func(string) bool) bool {
+	for _, e := range a {
+		if !e.Eval(match) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a authzAnd) String() string {
+	return joinAuthzExpr(a, " AND ")
+}
+
+func (o authzOr) Eval(match func(string) bool) bool {
+	for _, e := range o {
+		if e.Eval(match) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o authzOr) String() string {
+	return joinAuthzExpr(o, " OR ")
+}
+
+func joinAuthzExpr(exprs []AuthzExpr, sep string) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// ParseAuthzExpr 解析授权表达式
+func ParseAuthzExpr(expr string) (AuthzExpr, error) {
+	p := &authzParser{tokens: tokenizeAuthzExpr(expr)}
+	if len(p.tokens) == 0 {
+		return nil, errors.New("authz: empty expression")
+	}
+	e, err := p.parseOr()
+	if nil != err {
+		return nil, fmt.Errorf("authz: invalid expression: %s, error: %w", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("authz: invalid expression: %s, unexpected token: %s", expr, p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func tokenizeAuthzExpr(expr string) []string {
+	replacer := strings.NewReplacer("(", " ( ", ")", " ) ", ",", " OR ", "&&", " AND ", "||", " OR ")
+	return strings.Fields(replacer.Replace(expr))
+}
+
+type authzParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *authzParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *authzParser) parseOr() (AuthzExpr, error) {
+	return p.parseBinary("OR", p.parseAnd, func(exprs []AuthzExpr) AuthzExpr { return authzOr(exprs) })
+}
+
+func (p *authzParser) parseAnd() (AuthzExpr, error) {
+	return p.parseBinary("AND", p.parseFactor, func(exprs []AuthzExpr) AuthzExpr { return authzAnd(exprs) })
+}
+
+func (p *authzParser) parseBinary(op string, next func() (AuthzExpr, error), combine func([]AuthzExpr) AuthzExpr) (AuthzExpr, error) {
+	first, err := next()
+	if nil != err {
+		return nil, err
+	}
+	exprs := []AuthzExpr{first}
+	for strings.EqualFold(p.peek(), op) {
+		p.pos++
+		e, err := next()
+		if nil != err {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+	}
+	if len(exprs) == 1 {
+		return first, nil
+	}
+	return combine(exprs), nil
+}
+
+func (p *authzParser) parseFactor() (AuthzExpr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end")
+	case token == "(":
+		p.pos++
+		e, err := p.parseOr()
+		if nil != err {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return e, nil
+	case token == ")" || strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR"):
+		return nil, fmt.Errorf("unexpected token: %s", token)
+	default:
+		p.pos++
+		return authzItem(token), nil
+	}
+}
@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAuthzExpr(t *testing.T) {
+	tester := assert.New(t)
+	cases := map[string]string{
+		"a":                       "a",
+		"a AND b":                 "(a AND b)",
+		"a, b":                    "(a OR b)",
+		"a && b || c":             "((a AND b) OR c)",
+		"a and (b or c)":          "(a AND (b OR c))",
+		"orders:read AND admin:*": "(orders:read AND admin:*)",
+		"(a OR b) AND (c OR d)":   "((a OR b) AND (c OR d))",
+	}
+	for expr, expected := range cases {
+		e, err := ParseAuthzExpr(expr)
+		tester.NoError(err, expr)
+		tester.Equal(expected, e.String(), expr)
+	}
+	for _, expr := range []string{"", "a AND", "(a OR b", "a b", "OR a", "a)"} {
+		_, err := ParseAuthzExpr(expr)
+		tester.Error(err, expr)
+	}
+}
+
+func TestAuthzFilter(t *testing.T) {
+	tester := assert.New(t)
+	f := NewAuthzFilter(AuthzConfig{})
+	tester.NoError(f.OnInit(flux.NewConfiguration("authz_test")))
+	invoke := func(attrs map[string]interface{}, annotations flux.Annotations) *flux.ServeError {
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(httptest.NewRequest("GET", "/orders", nil), httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/orders", Annotations: annotations})
+		for k, v := range attrs {
+			ctx.SetAttribute(k, v)
+		}
+		return f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			return nil
+		})(ctx)
+	}
+	user := map[string]interface{}{"jwt.scope": "orders:read profile", "jwt.roles": []interface{}{"user", "Auditor"}}
+	admin := map[string]interface{}{"jwt.scope": "orders:*", "jwt.roles": []interface{}{"admin"}}
+	tester.Nil(invoke(nil, nil))
+	tester.Nil(invoke(user, flux.Annotations{flux.EndpointAnnotationAuthzScopes: "orders:read AND profile"}))
+	tester.Nil(invoke(admin, flux.Annotations{flux.EndpointAnnotationAuthzScopes: "orders:read && orders:write"}))
+	tester.Nil(invoke(user, flux.Annotations{flux.EndpointAnnotationAuthzScopes: "orders:*"}))
+	tester.Nil(invoke(user, flux.Annotations{flux.EndpointAnnotationAuthzRoles: "admin, auditor"}))
+	tester.Nil(invoke(admin, flux.Annotations{
+		flux.EndpointAnnotationAuthzScopes: "orders:write",
+		flux.EndpointAnnotationAuthzRoles:  "admin OR (user AND auditor)",
+	}))
+	denied := []struct {
+		attrs       map[string]interface{}
+		annotations flux.Annotations
+	}{
+		{attrs: user, annotations: flux.Annotations{flux.EndpointAnnotationAuthzScopes: "orders:write"}},
+		{attrs: user, annotations: flux.Annotations{flux.EndpointAnnotationAuthzScopes: "orders:read AND admin:*"}},
+		{attrs: user, annotations: flux.Annotations{flux.EndpointAnnotationAuthzRoles: "admin"}},
+		{attrs: nil, annotations: flux.Annotations{flux.EndpointAnnotationAuthzRoles: "user"}},
+	}
+	for _, c := range denied {
+		serr := invoke(c.attrs, c.annotations)
+		tester.NotNil(serr)
+		tester.Equal(flux.StatusAccessDenied, serr.StatusCode)
+		tester.Equal(flux.ErrorCodePermissionDenied, serr.ErrorCode)
+	}
+	serr := invoke(user, flux.Annotations{flux.EndpointAnnotationAuthzRoles: "admin AND"})
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeGatewayEndpoint, serr.ErrorCode)
+}
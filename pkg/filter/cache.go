@@ -0,0 +1,376 @@
+package filter
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/cast"
+)
+
+const (
+	TypeIdCacheFilter = "cache_filter"
+)
+
+const (
+	ConfigKeyCacheMaxBytes     = "cache_max_bytes"
+	ConfigKeyCacheMaxEntrySize = "cache_max_entry_size"
+)
+
+const (
+	HeaderXCache = "X-Cache"
+	CacheHit     = "HIT"
+	CacheMiss    = "MISS"
+)
+
+// 与单次请求相关的响应Header，不写入缓存
+var (
+	cacheExcludedHeaders = []string{
+		HeaderXCache, flux.HeaderXRequestId, HeaderRetryAfter, "Date", "Age", "Traceparent", "Tracestate",
+	}
+	cacheExcludedHeaderPrefixes = []string{"X-Ratelimit-"}
+)
+
+var _ flux.Filter = new(CacheFilter)
+
+type (
+	// CacheKeyFunc 用于构建响应缓存Key的函数；返回空字符串表示不缓存当前请求
+	CacheKeyFunc func(ctx flux.Context) (key string, err error)
+)
+
+// CacheConfig 响应缓存配置
+type CacheConfig struct {
+	SkipFunc flux.FilterSkipper
+	KeyFunc  CacheKeyFunc
+}
+
+type CacheMetrics struct {
+	// 缓存命中次数统计
+	HitAccess *prometheus.CounterVec
+	// 缓存未命中次数统计
+	MissAccess *prometheus.CounterVec
+	// 缓存命中率
+	HitRatio prometheus.Gauge
+}
+
+var (
+	cacheMetrics     *CacheMetrics
+	cacheMetricsOnce sync.Once
+)
+
+// CachedResponse 缓存的响应数据
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	CreatedAt  time.Time
+}
+
+func NewCacheFilter(c CacheConfig) *CacheFilter {
+	return &CacheFilter{
+		CacheConfig: c,
+	}
+}
+
+// CacheFilter 基于Endpoint注解 flux.go/cache.ttl 启用的响应缓存Filter
+type CacheFilter struct {
+	CacheConfig
+	cache        *toolkit.LRUCache
+	maxEntrySize int
+	metrics      *CacheMetrics
+	hits, total  uint64
+}
+
+func (f *CacheFilter) FilterId() string {
+	return TypeIdCacheFilter
+}
+
+func (f *CacheFilter) OnInit(c *flux.Configuration) error {
+	logger.Info("Cache filter initializing")
+	c.SetDefaults(map[string]interface{}{
+		ConfigKeyCacheSize:         1024,
+		ConfigKeyCacheMaxBytes:     64 * 1024 * 1024,
+		ConfigKeyCacheMaxEntrySize: 1024 * 1024,
+	})
+	f.cache = toolkit.NewLRUCache(c.GetInt(ConfigKeyCacheSize), c.GetInt64(ConfigKeyCacheMaxBytes))
+	f.maxEntrySize = c.GetInt(ConfigKeyCacheMaxEntrySize)
+	f.metrics = newCacheMetrics()
+	if f.CacheConfig.SkipFunc == nil {
+		f.CacheConfig.SkipFunc = func(flux.Context) bool {
+			return false
+		}
+	}
+	if f.CacheConfig.KeyFunc == nil {
+		f.CacheConfig.KeyFunc = DefaultCacheKeyFunc
+	}
+	logger.Infow("Cache filter config", "cache-size", c.GetInt(ConfigKeyCacheSize),
+		"cache-max-bytes", c.GetInt64(ConfigKeyCacheMaxBytes), "cache-max-entry-size", f.maxEntrySize)
+	return nil
+}
+
+func (f *CacheFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		ttl := CacheTTLOf(ctx.Endpoint())
+		if ttl <= 0 || f.CacheConfig.SkipFunc(ctx) {
+			return next(ctx)
+		}
+		defer func() {
+			ctx.AddMetric(f.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		nocache, nostore := parseCacheControl(ctx.HeaderVar("Cache-Control"))
+		if nostore {
+			return next(ctx)
+		}
+		key, err := f.CacheConfig.KeyFunc(ctx)
+		if nil != err {
+			logger.TraceVerbose(ctx).Warnw("CACHE:KEY:BUILD/error", "error", err)
+			return next(ctx)
+		}
+		if key == "" {
+			return next(ctx)
+		}
+		labels := cacheLabels(ctx)
+		if !nocache {
+			if cached, ok := f.cache.Get(key); ok {
+				f.metrics.HitAccess.WithLabelValues(labels...).Inc()
+				f.observeRatio(true)
+				return f.writeCached(ctx, cached.(*CachedResponse))
+			}
+		}
+		f.metrics.MissAccess.WithLabelValues(labels...).Inc()
+		f.observeRatio(false)
+		ctx.ResponseWriter().Header().Set(HeaderXCache, CacheMiss)
+		// 记录响应数据
+		origin := ctx.ResponseWriter()
+		recorder := &cacheRecordWriter{ResponseWriter: origin, limit: f.maxEntrySize}
+		ctx.SetResponseWriter(recorder)
+		serr := next(ctx)
+		ctx.SetResponseWriter(origin)
+		if serr == nil && recorder.cacheable() && isResponseStorable(origin.Header()) {
+			f.cache.Set(key, &CachedResponse{
+				StatusCode: recorder.status,
+				Header:     cachedHeaderOf(origin.Header()),
+				Body:       recorder.body,
+				CreatedAt:  time.Now(),
+			}, int64(len(recorder.body)), ttl)
+		}
+		return serr
+	}
+}
+
+func (f *CacheFilter) writeCached(ctx flux.Context, cached *CachedResponse) *flux.ServeError {
+	header := ctx.ResponseWriter().Header()
+	for k, hv := range cached.Header {
+		header[k] = append([]string(nil), hv...)
+	}
+	header.Set(HeaderXCache, CacheHit)
+	header.Set("Age", strconv.Itoa(int(time.Since(cached.CreatedAt).Seconds())))
+	if err := ctx.Write(cached.StatusCode, header.Get("Content-Type"), cached.Body); nil != err {
+		logger.TraceVerbose(ctx).Errorw("CACHE:WRITE/error", "error", err)
+	}
+	return nil
+}
+
+func (f *CacheFilter) observeRatio(hit bool) {
+	total := atomic.AddUint64(&f.total, 1)
+	hits := atomic.LoadUint64(&f.hits)
+	if hit {
+		hits = atomic.AddUint64(&f.hits, 1)
+	}
+	f.metrics.HitRatio.Set(float64(hits) / float64(total))
+}
+
+// CacheTTLOf 读取Endpoint的响应缓存时长注解；支持Duration字符串或秒数
+func CacheTTLOf(ep *flux.EndpointSpec) time.Duration {
+	anno, ok := ep.AnnotationEx(flux.EndpointAnnotationCacheTTL)
+	if !ok {
+		return 0
+	}
+	value := strings.TrimSpace(anno.GetString())
+	if secs, err := strconv.ParseFloat(value, 64); nil == err {
+		return time.Duration(secs * float64(time.Second))
+	}
+	return cast.ToDuration(value)
+}
+
+// DefaultCacheKeyFunc 默认缓存Key构建函数：
+// 1. Endpoint注解 flux.go/cache.keys 指定查找表达式时，使用表达式查找的值；
+// 2. 否则，使用后端服务参数的解析值，以及路径参数、Query参数和Authorization请求头；
+// 未声明参数的服务（如Http服务）透传全部请求参数，不同参数或不同身份的请求不能共享缓存。
+func DefaultCacheKeyFunc(ctx flux.Context) (string, error) {
+	pattern, method, version := ctx.Exposed()
+	values := make([]string, 0, 8)
+	if exprs := ctx.Endpoint().Annotation(flux.EndpointAnnotationCacheKeys).GetStrings(); len(exprs) > 0 {
+		for _, expr := range exprs {
+			value, err := common.LookupValueByExpr(ctx, expr)
+			if nil != err {
+				return "", fmt.Errorf("lookup cache key, expr: %s, error: %w", expr, err)
+			}
+			values = append(values, expr+"="+cast.ToString(value))
+		}
+	} else {
+		service := ctx.Service()
+		for i := range service.Arguments {
+			value, err := transporter.Resolve(ctx, &service.Arguments[i])
+			if nil != err {
+				return "", fmt.Errorf("resolve cache key, argument: %s, error: %w", service.Arguments[i].Name, err)
+			}
+			values = append(values, fmt.Sprintf("%s=%v", service.Arguments[i].Name, value))
+		}
+		values = append(values, "path:"+ctx.PathVars().Encode(), "query:"+ctx.URL().Query().Encode(),
+			"authorization:"+ctx.HeaderVar(flux.HeaderAuthorization))
+	}
+	digest := sha1.Sum([]byte(strings.Join(values, "&")))
+	return method + "#" + pattern + "#" + version + "#" + hex.EncodeToString(digest[:]), nil
+}
+
+// isResponseStorable 携带Set-Cookie，或者后端服务声明 Cache-Control: private/no-store 的响应不被缓存
+func isResponseStorable(header http.Header) bool {
+	if len(header.Values(flux.HeaderSetCookie)) > 0 {
+		return false
+	}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(strings.ToLower(value), ",") {
+			switch strings.TrimSpace(directive) {
+			case "private", "no-store":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cachedHeaderOf 返回写入缓存的响应Header，删除与单次请求相关的Header
+func cachedHeaderOf(header http.Header) http.Header {
+	cached := header.Clone()
+	for _, name := range cacheExcludedHeaders {
+		cached.Del(name)
+	}
+	for name := range cached {
+		for _, prefix := range cacheExcludedHeaderPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				delete(cached, name)
+			}
+		}
+	}
+	return cached
+}
+
+func parseCacheControl(value string) (nocache, nostore bool) {
+	for _, directive := range strings.Split(strings.ToLower(value), ",") {
+		switch strings.TrimSpace(directive) {
+		case "no-cache":
+			nocache = true
+		case "no-store":
+			nostore = true
+		}
+	}
+	return nocache, nostore
+}
+
+func cacheLabels(ctx flux.Context) []string {
+	listener := ""
+	if webl := ctx.WebListener(); webl != nil {
+		listener = webl.ListenerId()
+	}
+	pattern, method, version := ctx.Exposed()
+	if version == "" {
+		version = "default"
+	}
+	return []string{listener, method, pattern, version}
+}
+
+// 缓存统计
+func newCacheMetrics() *CacheMetrics {
+	cacheMetricsOnce.Do(func() {
+		const namespace, subsystem = "fluxgo", "cache"
+		var labels = []string{"Listener", "Method", "Pattern", "Version"}
+		cacheMetrics = &CacheMetrics{
+			HitAccess: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "hit_count",
+				Help:      "Number of endpoint access, served from response cache",
+			}, labels),
+			MissAccess: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "miss_count",
+				Help:      "Number of endpoint access, missed response cache",
+			}, labels),
+			HitRatio: promauto.NewGauge(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "hit_ratio",
+				Help:      "Ratio of endpoint access served from response cache",
+			}),
+		}
+	})
+	return cacheMetrics
+}
+
+// cacheRecordWriter 写入响应数据的同时，记录响应状态码和响应体
+type cacheRecordWriter struct {
+	http.ResponseWriter
+	status   int
+	body     []byte
+	limit    int
+	overflow bool
+}
+
+func (w *cacheRecordWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *cacheRecordWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflow {
+		if len(w.body)+len(data) > w.limit {
+			w.overflow, w.body = true, nil
+		} else {
+			w.body = append(w.body, data...)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheRecordWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 接管连接的响应不被缓存
+func (w *cacheRecordWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.overflow = true
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response writer not support hijack")
+}
+
+// cacheable 仅缓存成功写入、且未超出大小限制的2xx响应
+func (w *cacheRecordWriter) cacheable() bool {
+	return !w.overflow && w.status >= 200 && w.status < 300
+}
@@ -0,0 +1,152 @@
+package filter
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheFilterHitAndMiss(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	f := NewCacheFilter(CacheConfig{})
+	tester.NoError(f.OnInit(flux.NewConfiguration("cache_test")))
+	invokes := 0
+	endpoint := &flux.EndpointSpec{
+		Application: "app", HttpMethod: "GET", HttpPattern: "/users",
+		Annotations: flux.Annotations{
+			flux.EndpointAnnotationCacheTTL:  "30s",
+			flux.EndpointAnnotationCacheKeys: []string{"QUERY:id"},
+		},
+	}
+	invoke := func(uri, cacheControl string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", uri, nil)
+		if cacheControl != "" {
+			request.Header.Set("Cache-Control", cacheControl)
+		}
+		recorder := httptest.NewRecorder()
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, recorder), "test-id", nil), endpoint)
+		serr := f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			invokes++
+			_ = ctx.Write(200, flux.MIMEApplicationJSON, []byte(`{"id":"`+ctx.QueryVar("id")+`"}`))
+			return nil
+		})(ctx)
+		tester.Nil(serr)
+		return recorder
+	}
+	resp := invoke("/users?id=1", "")
+	tester.Equal(CacheMiss, resp.Header().Get(HeaderXCache))
+	resp = invoke("/users?id=1", "")
+	tester.Equal(CacheHit, resp.Header().Get(HeaderXCache))
+	tester.Equal(`{"id":"1"}`, resp.Body.String())
+	tester.Equal(flux.MIMEApplicationJSON, resp.Header().Get("Content-Type"))
+	tester.Equal(1, invokes)
+	// other key
+	resp = invoke("/users?id=2", "")
+	tester.Equal(CacheMiss, resp.Header().Get(HeaderXCache))
+	tester.Equal(`{"id":"2"}`, resp.Body.String())
+	// no-cache
+	resp = invoke("/users?id=1", "no-cache")
+	tester.Equal(CacheMiss, resp.Header().Get(HeaderXCache))
+	tester.Equal(3, invokes)
+	// not annotated
+	delete(endpoint.Annotations, flux.EndpointAnnotationCacheTTL)
+	resp = invoke("/users?id=1", "")
+	tester.Equal("", resp.Header().Get(HeaderXCache))
+	tester.Equal(4, invokes)
+}
+
+func TestDefaultCacheKeyFunc(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	// 未声明参数的Http服务
+	endpoint := &flux.EndpointSpec{
+		HttpMethod: "GET", HttpPattern: "/users/{id}",
+		Service: flux.ServiceSpec{Protocol: flux.ProtoHttp, Url: "http://users.svc"},
+	}
+	keyOf := func(uri, id, authorization string) string {
+		request := httptest.NewRequest("GET", uri, nil)
+		if authorization != "" {
+			request.Header.Set(flux.HeaderAuthorization, authorization)
+		}
+		echoc := echo.New().NewContext(request, httptest.NewRecorder())
+		echoc.SetParamNames("id")
+		echoc.SetParamValues(id)
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echoc, "test-id", nil), endpoint)
+		key, err := DefaultCacheKeyFunc(ctx)
+		tester.NoError(err)
+		return key
+	}
+	key := keyOf("/users/1", "1", "")
+	tester.Equal(key, keyOf("/users/1", "1", ""))
+	tester.NotEqual(key, keyOf("/users/2", "2", ""))
+	tester.NotEqual(key, keyOf("/users/1?x=y", "1", ""))
+	tester.NotEqual(key, keyOf("/users/1", "1", "Bearer token"))
+	// Query参数的顺序不影响缓存Key
+	tester.Equal(keyOf("/users/1?a=1&b=2", "1", ""), keyOf("/users/1?b=2&a=1", "1", ""))
+}
+
+func TestCacheFilterResponseHeaders(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	f := NewCacheFilter(CacheConfig{})
+	tester.NoError(f.OnInit(flux.NewConfiguration("cache_test")))
+	invokes := 0
+	invoke := func(uri string, headers map[string]string) *httptest.ResponseRecorder {
+		endpoint := &flux.EndpointSpec{
+			HttpMethod: "GET", HttpPattern: "/profile",
+			Annotations: flux.Annotations{flux.EndpointAnnotationCacheTTL: "30s"},
+		}
+		recorder := httptest.NewRecorder()
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(httptest.NewRequest("GET", uri, nil), recorder), "test-id", nil), endpoint)
+		tester.Nil(f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			invokes++
+			for k, v := range headers {
+				ctx.ResponseWriter().Header().Set(k, v)
+			}
+			_ = ctx.Write(200, flux.MIMEApplicationJSON, []byte(`{}`))
+			return nil
+		})(ctx))
+		return recorder
+	}
+	// 携带Set-Cookie或声明private/no-store的响应不缓存
+	for i, headers := range []map[string]string{
+		{flux.HeaderSetCookie: "sid=1"},
+		{"Cache-Control": "private, max-age=60"},
+		{"Cache-Control": "no-store"},
+	} {
+		uri := fmt.Sprintf("/profile?case=%d", i)
+		invoke(uri, headers)
+		tester.Equal(CacheMiss, invoke(uri, headers).Header().Get(HeaderXCache))
+	}
+	tester.Equal(6, invokes)
+	// 与单次请求相关的Header不写入缓存
+	invoke("/profile", map[string]string{
+		flux.HeaderXRequestId:    "req-1",
+		HeaderRateLimitRemaining: "9",
+		"X-Custom":               "v",
+	})
+	resp := invoke("/profile", nil)
+	tester.Equal(CacheHit, resp.Header().Get(HeaderXCache))
+	tester.Equal("v", resp.Header().Get("X-Custom"))
+	tester.Empty(resp.Header().Get(flux.HeaderXRequestId))
+	tester.Empty(resp.Header().Get(HeaderRateLimitRemaining))
+	tester.Equal(7, invokes)
+}
@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+// TokenIntrospector 基于 RFC 7662 OAuth2 Token Introspection 校验不透明Token；
+// 校验结果按Token缓存，缓存时长不超过Token的过期时间。
+type TokenIntrospector struct {
+	endpoint     string
+	clientId     string
+	clientSecret string
+	client       *http.Client
+	expiration   time.Duration
+	cache        *toolkit.LRUCache
+}
+
+func NewTokenIntrospector(endpoint, clientId, clientSecret string, client *http.Client, cacheSize int, expiration time.Duration) *TokenIntrospector {
+	if client == nil {
+		client = &http.Client{Timeout: time.Second * 10}
+	}
+	return &TokenIntrospector{
+		endpoint:     endpoint,
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		client:       client,
+		expiration:   expiration,
+		cache:        toolkit.NewLRUCache(cacheSize, 0),
+	}
+}
+
+// Introspect 校验Token，返回Token是否有效，以及Token的声明数据
+func (i *TokenIntrospector) Introspect(ctx context.Context, token string) (active bool, claims map[string]interface{}, err error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	if i.expiration > 0 {
+		if cached, ok := i.cache.Get(key); ok {
+			claims = cached.(map[string]interface{})
+			return cast.ToBool(claims["active"]), claims, nil
+		}
+	}
+	claims, err = i.doIntrospect(ctx, token)
+	if nil != err {
+		return false, nil, err
+	}
+	active = cast.ToBool(claims["active"])
+	if ttl := i.ttlOf(active, claims); ttl > 0 {
+		i.cache.Set(key, claims, 1, ttl)
+	}
+	return active, claims, nil
+}
+
+func (i *TokenIntrospector) ttlOf(active bool, claims map[string]interface{}) time.Duration {
+	ttl := i.expiration
+	if !active {
+		return ttl
+	}
+	if exp, ok := claims["exp"]; ok {
+		if remain := time.Until(time.Unix(cast.ToInt64(exp), 0)); remain < ttl {
+			return remain
+		}
+	}
+	return ttl
+}
+
+func (i *TokenIntrospector) doIntrospect(ctx context.Context, token string) (map[string]interface{}, error) {
+	form := url.Values{"token": []string{token}, "token_type_hint": []string{"access_token"}}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint, strings.NewReader(form.Encode()))
+	if nil != err {
+		return nil, fmt.Errorf("introspection: new request, error: %w", err)
+	}
+	request.Header.Set(flux.HeaderContentType, flux.MIMEApplicationForm)
+	request.Header.Set(flux.HeaderAccept, flux.MIMEApplicationJSON)
+	if i.clientId != "" {
+		request.SetBasicAuth(url.QueryEscape(i.clientId), url.QueryEscape(i.clientSecret))
+	}
+	resp, err := i.client.Do(request)
+	if nil != err {
+		return nil, fmt.Errorf("introspection: request, error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil, fmt.Errorf("introspection: unexpected status: %d", resp.StatusCode)
+	}
+	claims := make(map[string]interface{})
+	if err := json.NewDecoder(resp.Body).Decode(&claims); nil != err {
+		return nil, fmt.Errorf("introspection: decode response, error: %w", err)
+	}
+	return claims, nil
+}
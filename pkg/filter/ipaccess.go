@@ -0,0 +1,214 @@
+package filter
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+const (
+	TypeIdIPAccessFilter = "ipaccess_filter"
+)
+
+const (
+	ConfigKeyIPAccessAllow        = "allow"
+	ConfigKeyIPAccessDeny         = "deny"
+	ConfigKeyIPAccessListeners    = "listeners"
+	ConfigKeyIPAccessApplications = "applications"
+)
+
+var (
+	_ flux.Filter      = new(IPAccessFilter)
+	_ flux.Initializer = new(IPAccessFilter)
+)
+
+// IPAccessRule 客户端IP访问规则；命中Deny时拒绝，Allow非空时必须命中Allow
+type IPAccessRule struct {
+	Allow []*net.IPNet
+	Deny  []*net.IPNet
+}
+
+func NewIPAccessRule(allow, deny []string) (*IPAccessRule, error) {
+	allows, err := toolkit.ParseCIDRs(allow)
+	if nil != err {
+		return nil, err
+	}
+	denies, err := toolkit.ParseCIDRs(deny)
+	if nil != err {
+		return nil, err
+	}
+	return &IPAccessRule{Allow: allows, Deny: denies}, nil
+}
+
+// Permit 判断IP是否允许访问
+func (r *IPAccessRule) Permit(ip net.IP) bool {
+	if r == nil {
+		return true
+	}
+	if toolkit.ContainsIP(r.Deny, ip) {
+		return false
+	}
+	return len(r.Allow) == 0 || toolkit.ContainsIP(r.Allow, ip)
+}
+
+// IPAccessConfig 客户端IP访问控制配置
+type IPAccessConfig struct {
+	SkipFunc flux.FilterSkipper
+	// 全局规则
+	Global *IPAccessRule
+	// 按ListenerId配置的规则
+	Listeners map[string]*IPAccessRule
+	// 按应用名配置的规则
+	Applications map[string]*IPAccessRule
+}
+
+func NewIPAccessFilter(c IPAccessConfig) *IPAccessFilter {
+	return &IPAccessFilter{
+		Config: c,
+	}
+}
+
+// IPAccessFilter 基于CIDR的客户端IP访问控制；依次检查全局、Listener、应用和Endpoint注解的规则，
+// 任一级别拒绝即返回403。客户端IP通过 WebContext.RemoteAddr 获取，由Listener按可信代理配置解析。
+type IPAccessFilter struct {
+	Config    IPAccessConfig
+	endpoints sync.Map // 已解析的Endpoint注解规则
+}
+
+func (f *IPAccessFilter) FilterId() string {
+	return TypeIdIPAccessFilter
+}
+
+func (f *IPAccessFilter) OnInit(config *flux.Configuration) error {
+	if flux.IsNil(f.Config.SkipFunc) {
+		f.Config.SkipFunc = func(_ flux.Context) bool {
+			return false
+		}
+	}
+	if f.Config.Global == nil {
+		rule, err := NewIPAccessRule(config.GetStringSlice(ConfigKeyIPAccessAllow), config.GetStringSlice(ConfigKeyIPAccessDeny))
+		if nil != err {
+			return fmt.Errorf("ipaccess: global rule, error: %w", err)
+		}
+		f.Config.Global = rule
+	}
+	if f.Config.Listeners == nil {
+		rules, err := loadIPAccessRules(config.Get(ConfigKeyIPAccessListeners))
+		if nil != err {
+			return fmt.Errorf("ipaccess: listener rules, error: %w", err)
+		}
+		f.Config.Listeners = rules
+	}
+	if f.Config.Applications == nil {
+		rules, err := loadIPAccessRules(config.Get(ConfigKeyIPAccessApplications))
+		if nil != err {
+			return fmt.Errorf("ipaccess: application rules, error: %w", err)
+		}
+		f.Config.Applications = rules
+	}
+	f.Config.Listeners = lowerKeyRules(f.Config.Listeners)
+	f.Config.Applications = lowerKeyRules(f.Config.Applications)
+	logger.Infow("PERMISSION:IPACCESS:INIT", "listeners", len(f.Config.Listeners), "applications", len(f.Config.Applications))
+	return nil
+}
+
+func (f *IPAccessFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		if f.Config.SkipFunc(ctx) {
+			return next(ctx)
+		}
+		defer func() {
+			ctx.AddMetric(f.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		addr := ctx.RemoteAddr()
+		ip := toolkit.ParseHostIP(addr)
+		if ip == nil {
+			return f.denied(ctx, addr, "invalid client ip")
+		}
+		if !f.Config.Global.Permit(ip) {
+			return f.denied(ctx, addr, "global")
+		}
+		if listener := ctx.WebListener(); listener != nil {
+			if !f.lookup(f.Config.Listeners, listener.ListenerId()).Permit(ip) {
+				return f.denied(ctx, addr, "listener")
+			}
+		}
+		if !f.lookup(f.Config.Applications, ctx.Application()).Permit(ip) {
+			return f.denied(ctx, addr, "application")
+		}
+		rule, err := f.endpointRule(ctx.Endpoint())
+		if nil != err {
+			ctx.Logger().Errorw("PERMISSION:IPACCESS:ANNOTATION:INVALID", "error", err)
+			return f.denied(ctx, addr, "endpoint annotation invalid")
+		}
+		if !rule.Permit(ip) {
+			return f.denied(ctx, addr, "endpoint")
+		}
+		return next(ctx)
+	}
+}
+
+func (f *IPAccessFilter) lookup(rules map[string]*IPAccessRule, name string) *IPAccessRule {
+	return rules[toolkit.LowerKey(name)]
+}
+
+func (f *IPAccessFilter) endpointRule(endpoint *flux.EndpointSpec) (*IPAccessRule, error) {
+	if endpoint == nil {
+		return nil, nil
+	}
+	allow, hasAllow := endpoint.AnnotationEx(flux.EndpointAnnotationIPAllow)
+	deny, hasDeny := endpoint.AnnotationEx(flux.EndpointAnnotationIPDeny)
+	if !hasAllow && !hasDeny {
+		return nil, nil
+	}
+	key := allow.GetString() + "|" + deny.GetString()
+	if rule, ok := f.endpoints.Load(key); ok {
+		return rule.(*IPAccessRule), nil
+	}
+	rule, err := NewIPAccessRule(splitAnnotationList(allow.GetString()), splitAnnotationList(deny.GetString()))
+	if nil != err {
+		return nil, err
+	}
+	f.endpoints.Store(key, rule)
+	return rule, nil
+}
+
+func (f *IPAccessFilter) denied(ctx flux.Context, addr, level string) *flux.ServeError {
+	ctx.Logger().Infow("PERMISSION:IPACCESS:DENIED", "client-ip", addr, "level", level)
+	return &flux.ServeError{
+		StatusCode: flux.StatusAccessDenied,
+		ErrorCode:  flux.ErrorCodeIPAccessDenied,
+		Message:    flux.ErrorMessagePermissionAccessDenied,
+		CauseError: fmt.Errorf("client ip denied, ip: %s, level: %s", addr, level),
+	}
+}
+
+func loadIPAccessRules(value interface{}) (map[string]*IPAccessRule, error) {
+	items := cast.ToStringMap(value)
+	rules := make(map[string]*IPAccessRule, len(items))
+	for name, item := range items {
+		m := cast.ToStringMap(item)
+		rule, err := NewIPAccessRule(cast.ToStringSlice(m[ConfigKeyIPAccessAllow]), cast.ToStringSlice(m[ConfigKeyIPAccessDeny]))
+		if nil != err {
+			return nil, fmt.Errorf("name: %s, %w", name, err)
+		}
+		rules[name] = rule
+	}
+	return rules, nil
+}
+
+func lowerKeyRules(rules map[string]*IPAccessRule) map[string]*IPAccessRule {
+	out := make(map[string]*IPAccessRule, len(rules))
+	for name, rule := range rules {
+		out[toolkit.LowerKey(name)] = rule
+	}
+	return out
+}
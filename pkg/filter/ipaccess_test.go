@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPAccessFilter(t *testing.T) {
+	tester := assert.New(t)
+	config := flux.NewConfiguration("ipaccess_test")
+	config.Set(ConfigKeyIPAccessDeny, []string{"6.6.6.6"})
+	config.Set(ConfigKeyIPAccessApplications, map[string]interface{}{
+		"partner": map[string]interface{}{"allow": []string{"10.0.0.0/8", "192.168.0.0/16"}},
+	})
+	f := NewIPAccessFilter(IPAccessConfig{})
+	tester.NoError(f.OnInit(config))
+	invoke := func(remote, app string, annotations flux.Annotations) *flux.ServeError {
+		request := httptest.NewRequest("GET", "/admin", nil)
+		request.RemoteAddr = remote
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{Application: app, HttpMethod: "GET", HttpPattern: "/admin", Annotations: annotations})
+		return f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			return nil
+		})(ctx)
+	}
+	tester.Nil(invoke("1.1.1.1:1234", "users", nil))
+	tester.Nil(invoke("10.1.1.1:1234", "partner", nil))
+	cases := []struct {
+		remote      string
+		app         string
+		annotations flux.Annotations
+	}{
+		{remote: "6.6.6.6:1234", app: "users"},
+		{remote: "1.1.1.1:1234", app: "partner"},
+		{remote: "192.168.1.8:1234", app: "partner", annotations: flux.Annotations{flux.EndpointAnnotationIPDeny: "192.168.1.0/24"}},
+		{remote: "10.1.1.1:1234", app: "partner", annotations: flux.Annotations{flux.EndpointAnnotationIPAllow: "10.2.0.0/16, 10.3.0.1"}},
+		{remote: "10.1.1.1:1234", app: "users", annotations: flux.Annotations{flux.EndpointAnnotationIPAllow: "bad-cidr"}},
+	}
+	for _, c := range cases {
+		serr := invoke(c.remote, c.app, c.annotations)
+		tester.NotNil(serr, c.remote)
+		tester.Equal(flux.StatusAccessDenied, serr.StatusCode)
+		tester.Equal(flux.ErrorCodeIPAccessDenied, serr.ErrorCode)
+	}
+	tester.Nil(invoke("10.3.0.1:1234", "partner", flux.Annotations{flux.EndpointAnnotationIPAllow: "10.2.0.0/16, 10.3.0.1"}))
+	// 应用名称大小写不敏感
+	tester.NotNil(invoke("1.1.1.1:1234", "Partner", nil))
+	tester.Nil(invoke("10.1.1.1:1234", "Partner", nil))
+	// 代码配置的规则键名同样规范为小写
+	rule, err := NewIPAccessRule([]string{"10.0.0.0/8"}, nil)
+	tester.NoError(err)
+	f = NewIPAccessFilter(IPAccessConfig{Applications: map[string]*IPAccessRule{"Partner": rule}})
+	tester.NoError(f.OnInit(flux.NewConfiguration("ipaccess_test")))
+	tester.NotNil(invoke("1.1.1.1:1234", "partner", nil))
+	tester.Nil(invoke("10.1.1.1:1234", "PARTNER", nil))
+}
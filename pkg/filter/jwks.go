@@ -0,0 +1,274 @@
+package filter
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// OIDCDiscoveryPath OIDC Discovery文档的路径
+	OIDCDiscoveryPath = "/.well-known/openid-configuration"
+)
+
+var (
+	ErrJWKSKeyNotFound = errors.New("jwks: signing key not found")
+)
+
+// JSONWebKey JWK密钥（RFC 7517），支持RSA、EC、oct类型
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+// OIDCProviderMetadata OIDC Discovery文档的字段
+type OIDCProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	JwksURI               string `json:"jwks_uri"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+}
+
+// DiscoverOIDC 加载OIDC Discovery文档，并校验文档声明的Issuer与请求的Issuer一致
+func DiscoverOIDC(ctx context.Context, client *http.Client, issuer string) (*OIDCProviderMetadata, error) {
+	url := strings.TrimSuffix(issuer, "/") + OIDCDiscoveryPath
+	meta := new(OIDCProviderMetadata)
+	if err := getJSON(ctx, client, url, meta); nil != err {
+		return nil, fmt.Errorf("oidc: discovery, issuer: %s, error: %w", issuer, err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("oidc: discovery issuer mismatch, expected: %s, was: %s", issuer, meta.Issuer)
+	}
+	if meta.JwksURI == "" {
+		return nil, fmt.Errorf("oidc: discovery jwks_uri is empty, issuer: %s", issuer)
+	}
+	return meta, nil
+}
+
+// JWKSKeySet 从JWKS地址加载签名验证密钥；按kid查找密钥，支持定时刷新，
+// 以及在kid未命中时按最小间隔立即刷新（签名密钥轮换）。
+type JWKSKeySet struct {
+	url         string
+	client      *http.Client
+	interval    time.Duration
+	minInterval time.Duration
+	mu          sync.RWMutex
+	keys        map[string]jwksEntry
+	refreshAt   time.Time
+	refreshMu   sync.Mutex
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+type jwksEntry struct {
+	alg string
+	key interface{}
+}
+
+func NewJWKSKeySet(url string, client *http.Client, interval time.Duration) *JWKSKeySet {
+	if client == nil {
+		client = &http.Client{Timeout: time.Second * 10}
+	}
+	return &JWKSKeySet{
+		url:         url,
+		client:      client,
+		interval:    interval,
+		minInterval: time.Second * 10,
+		keys:        make(map[string]jwksEntry, 0),
+		stop:        make(chan struct{}),
+	}
+}
+
+// KeyFunc 按Token的kid查找签名验证密钥；kid未命中时，以请求的Context立即刷新密钥
+func (s *JWKSKeySet) KeyFunc(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	entry, ok := s.lookup(kid)
+	if !ok {
+		// 密钥可能已轮换，立即刷新
+		if err := s.refreshIfStale(ctx); nil != err {
+			return nil, err
+		}
+		if entry, ok = s.lookup(kid); !ok {
+			return nil, fmt.Errorf("%w, kid: %s", ErrJWKSKeyNotFound, kid)
+		}
+	}
+	if entry.alg != "" && entry.alg != token.Method.Alg() {
+		return nil, fmt.Errorf("jwks: signing method mismatch, kid: %s, expected: %s, was: %s", kid, entry.alg, token.Method.Alg())
+	}
+	return entry.key, nil
+}
+
+// Refresh 立即从JWKS地址加载密钥
+func (s *JWKSKeySet) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.doRefresh(ctx)
+}
+
+// Start 启动定时刷新；interval小于等于0时不启动
+func (s *JWKSKeySet) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := s.Refresh(context.Background()); nil != err {
+					logger.Warnw("JWKS:REFRESH:ERROR", "url", s.url, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close 停止定时刷新
+func (s *JWKSKeySet) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *JWKSKeySet) lookup(kid string) (jwksEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if kid == "" && len(s.keys) == 1 {
+		// Token未声明kid，并且JWKS仅有一个密钥
+		for _, entry := range s.keys {
+			return entry, true
+		}
+	}
+	entry, ok := s.keys[kid]
+	return entry, ok
+}
+
+func (s *JWKSKeySet) refreshIfStale(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	if time.Since(s.refreshAt) < s.minInterval {
+		return nil
+	}
+	return s.doRefresh(ctx)
+}
+
+func (s *JWKSKeySet) doRefresh(ctx context.Context) error {
+	s.refreshAt = time.Now()
+	var doc struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, s.client, s.url, &doc); nil != err {
+		return fmt.Errorf("jwks: load keys, url: %s, error: %w", s.url, err)
+	}
+	keys := make(map[string]jwksEntry, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if nil != err {
+			logger.Warnw("JWKS:KEY:INVALID", "url", s.url, "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = jwksEntry{alg: jwk.Alg, key: key}
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	logger.Infow("JWKS:REFRESH:OK", "url", s.url, "keys", len(keys))
+	return nil
+}
+
+// PublicKey 返回JWK的签名验证密钥：*rsa.PublicKey, *ecdsa.PublicKey 或 []byte
+func (k JSONWebKey) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if nil != err {
+			return nil, fmt.Errorf("decode rsa.n: %w", err)
+		}
+		e, err := decodeBase64URL(k.E)
+		if nil != err {
+			return nil, fmt.Errorf("decode rsa.e: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported ec.crv: %s", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if nil != err {
+			return nil, fmt.Errorf("decode ec.x: %w", err)
+		}
+		y, err := decodeBase64URL(k.Y)
+		if nil != err {
+			return nil, fmt.Errorf("decode ec.y: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "oct":
+		return decodeBase64URL(k.K)
+	default:
+		return nil, fmt.Errorf("unsupported kty: %s", k.Kty)
+	}
+}
+
+func decodeBase64URL(v string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "="))
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if nil != err {
+		return err
+	}
+	request.Header.Set(flux.HeaderAccept, flux.MIMEApplicationJSON)
+	resp, err := client.Do(request)
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
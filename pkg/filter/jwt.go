@@ -1,6 +1,8 @@
 package filter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,6 +12,8 @@ import (
 import (
 	"github.com/bytepowered/fluxgo/pkg/common"
 	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/dgrijalva/jwt-go/request"
 	"github.com/spf13/cast"
@@ -24,14 +28,39 @@ const (
 	ConfigKeyAttachmentKey = "attachment_key"
 )
 
-var _ flux.Filter = new(JWTFilter)
+const (
+	ConfigKeyIssuer              = "issuer"
+	ConfigKeyAudience            = "audience"
+	ConfigKeyOIDCIssuer          = "oidc_issuer"
+	ConfigKeyJWKSURL             = "jwks_url"
+	ConfigKeyJWKSRefreshInterval = "jwks_refresh_interval"
+	ConfigKeyIntrospection       = "introspection"
+	ConfigKeyEndpoint            = "endpoint"
+	ConfigKeyClientId            = "client_id"
+	ConfigKeyClientSecret        = "client_secret"
+	ConfigKeyHttpTimeout         = "http_timeout"
+)
+
+var (
+	_ flux.Filter      = new(JWTFilter)
+	_ flux.Initializer = new(JWTFilter)
+	_ flux.Startuper   = new(JWTFilter)
+	_ flux.Shutdowner  = new(JWTFilter)
+)
 
 type JWTConfig struct {
 	AttKeyPrefix string
 	// 默认查找Token的函数
 	TokenExtractor func(ctx flux.Context) (string, error)
-	// 加载签名验证密钥的函数
+	// 加载签名验证密钥的函数；未设置时，使用JWKS/OIDC配置的密钥
 	SecretKeyLoader func(ctx flux.Context, token *jwt.Token) (interface{}, error)
+	// JWKS签名验证密钥；未设置时，根据 jwks_url 或 oidc_issuer 配置创建
+	KeySet *JWKSKeySet
+	// 不透明Token的校验；未设置时，根据 introspection 配置创建
+	Introspector *TokenIntrospector
+	// 默认校验的签发者和受众；可通过Endpoint注解覆盖
+	Issuer   string
+	Audience []string
 }
 
 func NewJWTFilter(config JWTConfig) *JWTFilter {
@@ -49,6 +78,10 @@ func (f *JWTFilter) FilterId() string {
 }
 
 func (f *JWTFilter) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyJWKSRefreshInterval: time.Minute * 10,
+		ConfigKeyHttpTimeout:         time.Second * 10,
+	})
 	if f.Config.TokenExtractor == nil {
 		f.Config.TokenExtractor = func(ctx flux.Context) (string, error) {
 			return ExtractTokenOAuth2(ctx)
@@ -58,7 +91,77 @@ func (f *JWTFilter) OnInit(config *flux.Configuration) error {
 	if "" == f.Config.AttKeyPrefix {
 		f.Config.AttKeyPrefix = cast.ToString(config.GetOrDefault(ConfigKeyAttachmentKey, "jwt"))
 	}
-	flux.AssertNotNil(f.Config.SecretKeyLoader, "<secret-loader> must not nil")
+	if "" == f.Config.Issuer {
+		f.Config.Issuer = config.GetString(ConfigKeyIssuer)
+	}
+	if len(f.Config.Audience) == 0 {
+		f.Config.Audience = config.GetStringSlice(ConfigKeyAudience)
+	}
+	client := &http.Client{Timeout: config.GetDuration(ConfigKeyHttpTimeout)}
+	jwksURL := config.GetString(ConfigKeyJWKSURL)
+	introspection := config.Sub(ConfigKeyIntrospection)
+	introspection.SetDefaults(map[string]interface{}{
+		ConfigKeyCacheExpiration: time.Minute,
+		ConfigKeyCacheSize:       10000,
+	})
+	introspectionURL := introspection.GetString(ConfigKeyEndpoint)
+	introspectionEnabled := "" != introspectionURL
+	// OIDC Discovery：加载JWKS和Introspection地址
+	if issuer := config.GetString(ConfigKeyOIDCIssuer); "" != issuer {
+		ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
+		meta, err := DiscoverOIDC(ctx, client, issuer)
+		cancel()
+		if nil != err {
+			return err
+		}
+		if "" == jwksURL {
+			jwksURL = meta.JwksURI
+		}
+		if "" == introspectionURL {
+			introspectionURL = meta.IntrospectionEndpoint
+		}
+		if "" == f.Config.Issuer {
+			f.Config.Issuer = meta.Issuer
+		}
+		logger.Infow("AUTHORIZATION:JWT:OIDC:DISCOVERY", "issuer", meta.Issuer, "jwks-uri", meta.JwksURI)
+	}
+	if f.Config.KeySet == nil && "" != jwksURL {
+		f.Config.KeySet = NewJWKSKeySet(jwksURL, client, config.GetDuration(ConfigKeyJWKSRefreshInterval))
+		// 启动时加载失败，在校验Token时重新加载
+		if err := f.Config.KeySet.Refresh(context.Background()); nil != err {
+			logger.Warnw("AUTHORIZATION:JWT:JWKS:LOAD", "error", err)
+		}
+	}
+	if f.Config.SecretKeyLoader == nil && f.Config.KeySet != nil {
+		keyset := f.Config.KeySet
+		f.Config.SecretKeyLoader = func(ctx flux.Context, token *jwt.Token) (interface{}, error) {
+			return keyset.KeyFunc(ctx.Context(), token)
+		}
+	}
+	// OIDC发现的Introspection地址，仅在配置client_id时启用
+	introspectionEnabled = introspectionEnabled || introspection.GetString(ConfigKeyClientId) != ""
+	if f.Config.Introspector == nil && "" != introspectionURL && introspectionEnabled {
+		f.Config.Introspector = NewTokenIntrospector(introspectionURL,
+			introspection.GetString(ConfigKeyClientId), introspection.GetString(ConfigKeyClientSecret), client,
+			introspection.GetInt(ConfigKeyCacheSize), introspection.GetDuration(ConfigKeyCacheExpiration))
+	}
+	if f.Config.SecretKeyLoader == nil && f.Config.Introspector == nil {
+		return errors.New("jwt: <secret-loader>, <jwks_url>, <oidc_issuer> or <introspection> is required")
+	}
+	return nil
+}
+
+func (f *JWTFilter) OnStartup() error {
+	if f.Config.KeySet != nil {
+		f.Config.KeySet.Start()
+	}
+	return nil
+}
+
+func (f *JWTFilter) OnShutdown(_ context.Context) error {
+	if f.Config.KeySet != nil {
+		f.Config.KeySet.Close()
+	}
 	return nil
 }
 
@@ -76,53 +179,158 @@ func (f *JWTFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
 				Message:    "JWT:VALIDATE: token not found",
 			}
 		}
-		ctx.Logger().Infow("AUTHORIZATION:JWT:TOKEN_VERIFY", "token", tokenStr)
-		// 解析和校验
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return f.Config.SecretKeyLoader(ctx, token)
-		})
-		if token != nil && token.Valid {
-			// set claims to attributes
-			ctx.Logger().Infow("AUTHORIZATION:JWT:VALIDATE:PASSED", "jwt.claims", claims)
-			for k, v := range claims {
-				ctx.SetAttribute(f.Config.AttKeyPrefix+"."+k, v)
-			}
-			return next(ctx)
+		ctx.Logger().Infow("AUTHORIZATION:JWT:TOKEN_VERIFY", "token", toolkit.Mask(tokenStr, 8))
+		var claims map[string]interface{}
+		var serr *flux.ServeError
+		// 非JWT格式的不透明Token，使用Introspection校验
+		if f.Config.Introspector != nil && (f.Config.SecretKeyLoader == nil || strings.Count(tokenStr, ".") != 2) {
+			claims, serr = f.introspect(ctx, tokenStr)
 		} else {
-			ctx.Logger().Infow("AUTHORIZATION:JWT:VALIDATE:REJECTED", "error", err)
-		}
-		if ve, ok := err.(*jwt.ValidationError); ok {
-			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-				return &flux.ServeError{
-					StatusCode: http.StatusBadRequest,
-					ErrorCode:  flux.ErrorCodeJwtMalformed,
-					Message:    "JWT:VALIDATE: token malformed",
-				}
-			} else if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
-				// Token is either expired or not active yet
-				return &flux.ServeError{
-					StatusCode: http.StatusUnauthorized,
-					ErrorCode:  flux.ErrorCodeJwtExpired,
-					Message:    "JWT:VALIDATE:token is expired/not active",
-				}
-			} else {
-				return &flux.ServeError{
-					StatusCode: http.StatusBadRequest,
-					ErrorCode:  flux.ErrorCodeJwtMalformed,
-					Message:    "JWT:VALIDATE: Couldn't handle(002)",
-					CauseError: err,
-				}
+			claims, serr = f.parse(ctx, tokenStr)
+		}
+		if serr != nil {
+			return serr
+		}
+		if serr := f.verifyClaims(ctx, claims); serr != nil {
+			ctx.Logger().Infow("AUTHORIZATION:JWT:VALIDATE:REJECTED", "error", serr.Message)
+			return serr
+		}
+		// set claims to attributes
+		ctx.Logger().Infow("AUTHORIZATION:JWT:VALIDATE:PASSED", "jwt.claims", claims)
+		for k, v := range claims {
+			ctx.SetAttribute(f.Config.AttKeyPrefix+"."+k, v)
+		}
+		return next(ctx)
+	}
+}
+
+func (f *JWTFilter) parse(ctx flux.Context, tokenStr string) (map[string]interface{}, *flux.ServeError) {
+	// 解析和校验
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return f.Config.SecretKeyLoader(ctx, token)
+	})
+	if token != nil && token.Valid {
+		return claims, nil
+	}
+	ctx.Logger().Infow("AUTHORIZATION:JWT:VALIDATE:REJECTED", "error", err)
+	if ve, ok := err.(*jwt.ValidationError); ok {
+		if ve.Errors&jwt.ValidationErrorMalformed != 0 {
+			return nil, &flux.ServeError{
+				StatusCode: http.StatusBadRequest,
+				ErrorCode:  flux.ErrorCodeJwtMalformed,
+				Message:    "JWT:VALIDATE: token malformed",
+			}
+		} else if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
+			// Token is either expired or not active yet
+			return nil, &flux.ServeError{
+				StatusCode: http.StatusUnauthorized,
+				ErrorCode:  flux.ErrorCodeJwtExpired,
+				Message:    "JWT:VALIDATE:token is expired/not active",
+			}
+		} else if ve.Errors&(jwt.ValidationErrorUnverifiable|jwt.ValidationErrorSignatureInvalid) != 0 {
+			// Signing key not found or signature invalid
+			return nil, &flux.ServeError{
+				StatusCode: http.StatusUnauthorized,
+				ErrorCode:  flux.ErrorCodeJwtInvalid,
+				Message:    "JWT:VALIDATE: signature invalid",
+				CauseError: err,
 			}
 		} else {
-			return &flux.ServeError{
+			return nil, &flux.ServeError{
 				StatusCode: http.StatusBadRequest,
 				ErrorCode:  flux.ErrorCodeJwtMalformed,
-				Message:    "JWT:VALIDATE: Couldn't handle(001)",
+				Message:    "JWT:VALIDATE: Couldn't handle(002)",
 				CauseError: err,
 			}
 		}
+	} else {
+		return nil, &flux.ServeError{
+			StatusCode: http.StatusBadRequest,
+			ErrorCode:  flux.ErrorCodeJwtMalformed,
+			Message:    "JWT:VALIDATE: Couldn't handle(001)",
+			CauseError: err,
+		}
+	}
+}
+
+func (f *JWTFilter) introspect(ctx flux.Context, tokenStr string) (map[string]interface{}, *flux.ServeError) {
+	active, claims, err := f.Config.Introspector.Introspect(ctx.Context(), tokenStr)
+	if nil != err {
+		return nil, &flux.ServeError{
+			StatusCode: http.StatusBadGateway,
+			ErrorCode:  flux.ErrorCodeTokenIntrospection,
+			Message:    "JWT:INTROSPECT: introspection failed",
+			CauseError: err,
+		}
+	}
+	if !active {
+		ctx.Logger().Infow("AUTHORIZATION:JWT:INTROSPECT:INACTIVE")
+		return nil, &flux.ServeError{
+			StatusCode: http.StatusUnauthorized,
+			ErrorCode:  flux.ErrorCodeTokenInactive,
+			Message:    "JWT:INTROSPECT: token is inactive",
+		}
+	}
+	return claims, nil
+}
+
+// verifyClaims 校验Token的签发者和受众；Endpoint注解优先于默认配置
+func (f *JWTFilter) verifyClaims(ctx flux.Context, claims map[string]interface{}) *flux.ServeError {
+	issuer := f.Config.Issuer
+	if anno, ok := ctx.Endpoint().AnnotationEx(flux.EndpointAnnotationJWTIssuer); ok {
+		issuer = anno.GetString()
+	}
+	if "" != issuer && cast.ToString(claims["iss"]) != issuer {
+		return &flux.ServeError{
+			StatusCode: http.StatusUnauthorized,
+			ErrorCode:  flux.ErrorCodeJwtInvalid,
+			Message:    "JWT:VALIDATE: issuer mismatch",
+		}
+	}
+	audience := f.Config.Audience
+	if anno, ok := ctx.Endpoint().AnnotationEx(flux.EndpointAnnotationJWTAudience); ok {
+		audience = splitAnnotationList(anno.GetString())
+	}
+	if len(audience) > 0 && !matchAudience(claims["aud"], audience) {
+		return &flux.ServeError{
+			StatusCode: http.StatusUnauthorized,
+			ErrorCode:  flux.ErrorCodeJwtInvalid,
+			Message:    "JWT:VALIDATE: audience mismatch",
+		}
+	}
+	return nil
+}
+
+// matchAudience 判断Token的aud声明（字符串或字符串数组）是否包含任一期望受众
+func matchAudience(aud interface{}, expected []string) bool {
+	var values []string
+	switch v := aud.(type) {
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	case []interface{}:
+		values = cast.ToStringSlice(v)
+	}
+	for _, value := range values {
+		for _, exp := range expected {
+			if value == exp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitAnnotationList(value string) []string {
+	out := make([]string, 0, 2)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
 	}
+	return out
 }
 
 // ExtractTokenOAuth2 按OAuth2请求，从Header:Authorization和form:access_token中抓取Token
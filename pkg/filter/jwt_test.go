@@ -0,0 +1,188 @@
+package filter
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type oidcTestServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	keys        map[string]*rsa.PrivateKey
+	introspects int32
+}
+
+func newOIDCTestServer() *oidcTestServer {
+	s := &oidcTestServer{keys: make(map[string]*rsa.PrivateKey)}
+	mux := http.NewServeMux()
+	mux.HandleFunc(OIDCDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.URL,
+			"jwks_uri":               s.URL + "/jwks",
+			"introspection_endpoint": s.URL + "/introspect",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		keys := make([]JSONWebKey, 0, len(s.keys))
+		for kid, key := range s.keys {
+			keys = append(keys, JSONWebKey{Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256",
+				N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.introspects, 1)
+		if id, secret, _ := r.BasicAuth(); id != "gateway" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp := map[string]interface{}{"active": false}
+		if r.PostFormValue("token") == "opaque-active" {
+			resp = map[string]interface{}{"active": true, "sub": "u-100", "iss": s.URL, "aud": "api",
+				"exp": time.Now().Add(time.Hour).Unix()}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *oidcTestServer) addKey(kid string) *rsa.PrivateKey {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+	return key
+}
+
+func (s *oidcTestServer) sign(kid string, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, _ := token.SignedString(key)
+	return signed
+}
+
+func TestJWTFilterOIDC(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	server := newOIDCTestServer()
+	defer server.Close()
+	key1 := server.addKey("k1")
+	config := flux.NewConfiguration("jwt_test")
+	config.Set(ConfigKeyOIDCIssuer, server.URL)
+	config.Set(ConfigKeyAudience, []string{"api"})
+	config.Set(ConfigKeyIntrospection+"."+ConfigKeyClientId, "gateway")
+	config.Set(ConfigKeyIntrospection+"."+ConfigKeyClientSecret, "secret")
+	f := NewJWTFilter(JWTConfig{})
+	tester.NoError(f.OnInit(config))
+	tester.NoError(f.OnStartup())
+	defer f.OnShutdown(nil)
+	f.Config.KeySet.minInterval = 0
+	invoke := func(token string, annotations flux.Annotations) (flux.Context, *flux.ServeError) {
+		request := httptest.NewRequest("GET", "/users", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/users", Annotations: annotations})
+		return ctx, f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			return nil
+		})(ctx)
+	}
+	claims := jwt.MapClaims{"sub": "u-1", "iss": server.URL, "aud": []string{"web", "api"},
+		"exp": time.Now().Add(time.Hour).Unix()}
+	// 有效Token，声明映射到属性
+	ctx, serr := invoke(server.sign("k1", key1, claims), nil)
+	tester.Nil(serr)
+	tester.Equal("u-1", ctx.Attribute("jwt.sub", ""))
+	// 密钥轮换：未知kid触发刷新
+	key2 := server.addKey("k2")
+	_, serr = invoke(server.sign("k2", key2, claims), nil)
+	tester.Nil(serr)
+	// 未注册的密钥
+	forged, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, serr = invoke(server.sign("k3", forged, claims), nil)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeJwtInvalid, serr.ErrorCode)
+	_, serr = invoke(server.sign("k1", forged, claims), nil)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeJwtInvalid, serr.ErrorCode)
+	// Endpoint注解覆盖签发者和受众
+	_, serr = invoke(server.sign("k1", key1, claims), flux.Annotations{flux.EndpointAnnotationJWTAudience: "admin, ops"})
+	tester.NotNil(serr)
+	tester.Equal(flux.StatusUnauthorized, serr.StatusCode)
+	tester.Equal("JWT:VALIDATE: audience mismatch", serr.Message)
+	_, serr = invoke(server.sign("k1", key1, claims), flux.Annotations{flux.EndpointAnnotationJWTIssuer: "https://other"})
+	tester.NotNil(serr)
+	tester.Equal("JWT:VALIDATE: issuer mismatch", serr.Message)
+	// 过期Token
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, serr = invoke(server.sign("k1", key1, claims), nil)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeJwtExpired, serr.ErrorCode)
+	// 不透明Token：Introspection，结果缓存
+	ctx, serr = invoke("opaque-active", nil)
+	tester.Nil(serr)
+	tester.Equal("u-100", ctx.Attribute("jwt.sub", ""))
+	_, serr = invoke("opaque-active", nil)
+	tester.Nil(serr)
+	tester.Equal(int32(1), atomic.LoadInt32(&server.introspects))
+	_, serr = invoke("opaque-inactive", nil)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeTokenInactive, serr.ErrorCode)
+}
+
+func TestJWTFilterRequiresKeySource(t *testing.T) {
+	tester := assert.New(t)
+	f := NewJWTFilter(JWTConfig{})
+	tester.Error(f.OnInit(flux.NewConfiguration("jwt_test_empty")))
+}
+
+func TestJWKSKeyFuncRequestContext(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	blocked := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-blocked
+	}))
+	defer server.Close()
+	defer close(blocked)
+	keyset := NewJWKSKeySet(server.URL, nil, 0)
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = "k1"
+	// kid未命中时的刷新随请求取消而结束
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	start := time.Now()
+	_, err := keyset.KeyFunc(ctx, token)
+	tester.Error(err)
+	tester.True(errors.Is(err, context.DeadlineExceeded))
+	tester.True(time.Since(start) < time.Second)
+}
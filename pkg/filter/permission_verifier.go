@@ -0,0 +1,228 @@
+package filter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
+	"github.com/spf13/cast"
+)
+
+const (
+	ConfigKeyPermissionMode          = "mode"
+	ConfigKeyPermissionInvokeTimeout = "invoke_timeout"
+	ConfigKeyPermissionSubjectLookup = "subject_lookup"
+)
+
+// 多个权限服务的验证结果组合方式
+const (
+	PermissionModeAnd = "and" // 全部通过
+	PermissionModeOr  = "or"  // 任一通过
+)
+
+type (
+	// PermissionInvokeFunc 调用权限服务的函数
+	PermissionInvokeFunc func(ctx flux.Context, service flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError)
+)
+
+// PermissionVerifier 默认的权限验证实现：通过Transporter并行调用Endpoint声明的权限服务，
+// 按标准响应协议解析为 PermissionReport，并按 AND/OR 组合验证结果。
+// 权限服务响应协议：{"success": bool, "statusCode": int, "errorCode": string, "message": string}，或者直接返回 bool。
+// 验证结果按 (Subject, 权限服务, 参数) 缓存；Subject为空时不缓存。
+type PermissionVerifier struct {
+	Invoker     PermissionInvokeFunc
+	Mode        string
+	Timeout     time.Duration
+	SubjectExpr string
+	Expiration  time.Duration
+	cache       *toolkit.LRUCache
+}
+
+func NewPermissionVerifier(invoker PermissionInvokeFunc, mode string, timeout time.Duration,
+	subjectExpr string, cacheSize int, expiration time.Duration) *PermissionVerifier {
+	v := &PermissionVerifier{
+		Invoker:     invoker,
+		Mode:        strings.ToLower(mode),
+		Timeout:     timeout,
+		SubjectExpr: subjectExpr,
+		Expiration:  expiration,
+	}
+	if expiration > 0 && cacheSize > 0 {
+		v.cache = toolkit.NewLRUCache(cacheSize, 0)
+	}
+	return v
+}
+
+type permissionResult struct {
+	report PermissionReport
+	err    error
+}
+
+// Verify 实现 PermissionVerifyFunc
+func (v *PermissionVerifier) Verify(services []flux.ServiceSpec, ctx flux.Context) (PermissionReport, error) {
+	if len(services) == 0 {
+		return NewPermissionVerifyReport(true, "", ""), nil
+	}
+	subject := ""
+	if v.cache != nil && "" != v.SubjectExpr {
+		value, _ := common.LookupValueByExpr(ctx, v.SubjectExpr)
+		subject = cast.ToString(value)
+	}
+	// 权限服务共享同一请求Context，按顺序调用；
+	// AND模式遇到调用出错或未通过、OR模式遇到通过时，不再调用后续服务。
+	results := make([]permissionResult, 0, len(services))
+	for _, service := range services {
+		result := v.verify(ctx, subject, service)
+		results = append(results, result)
+		passed := result.err == nil && result.report.Success
+		if passed == (PermissionModeOr == v.Mode) {
+			break
+		}
+	}
+	if PermissionModeOr == v.Mode {
+		return combinePermissionOr(results)
+	}
+	return combinePermissionAnd(results)
+}
+
+func (v *PermissionVerifier) verify(ctx flux.Context, subject string, service flux.ServiceSpec) permissionResult {
+	key := ""
+	if "" != subject {
+		if k, err := v.cacheKey(ctx, subject, service); nil == err {
+			key = k
+			if cached, ok := v.cache.Get(key); ok {
+				return permissionResult{report: cached.(PermissionReport)}
+			}
+		}
+	}
+	invctx := ctx
+	if v.Timeout > 0 {
+		goctx, cancel := context.WithTimeout(ctx.Context(), v.Timeout)
+		defer cancel()
+		invctx = &permissionContext{fluxContext: ctx, goctx: goctx}
+	}
+	resp, serr := v.Invoker(invctx, service)
+	if serr != nil {
+		return permissionResult{err: serr}
+	}
+	report, err := DecodePermissionReport(resp)
+	if nil != err {
+		return permissionResult{err: fmt.Errorf("permission service: %s, %w", service.ServiceID(), err)}
+	}
+	if "" != key {
+		v.cache.Set(key, report, 1, v.Expiration)
+	}
+	return permissionResult{report: report}
+}
+
+func (v *PermissionVerifier) cacheKey(ctx flux.Context, subject string, service flux.ServiceSpec) (string, error) {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\x00%s", subject, service.ServiceID())
+	for i := range service.Arguments {
+		value, err := transporter.Resolve(ctx, &service.Arguments[i])
+		if nil != err {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(hash, "\x00%s=%v", service.Arguments[i].Name, value)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DecodePermissionReport 按标准响应协议解析权限服务的响应
+func DecodePermissionReport(resp *flux.ServeResponse) (PermissionReport, error) {
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return PermissionReport{StatusCode: resp.StatusCode, Success: false}, nil
+	}
+	if resp.StatusCode != 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return PermissionReport{}, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	var body interface{}
+	switch b := resp.Body.(type) {
+	case bool, map[string]interface{}:
+		body = b
+	default:
+		data, err := ext.JSONMarshalObject(resp.Body)
+		if nil != err {
+			return PermissionReport{}, fmt.Errorf("encode response, error: %w", err)
+		}
+		if err := ext.JSONUnmarshal(data, &body); nil != err {
+			return PermissionReport{}, fmt.Errorf("decode response, error: %w", err)
+		}
+	}
+	switch b := body.(type) {
+	case bool:
+		return PermissionReport{Success: b}, nil
+	case map[string]interface{}:
+		return PermissionReport{
+			StatusCode: cast.ToInt(b["statusCode"]),
+			Success:    cast.ToBool(b["success"]),
+			ErrorCode:  cast.ToString(b["errorCode"]),
+			Message:    cast.ToString(b["message"]),
+		}, nil
+	default:
+		return PermissionReport{}, fmt.Errorf("unsupported response: %T", body)
+	}
+}
+
+// combinePermissionAnd 全部通过时成功；任一调用出错时返回错误，否则返回首个未通过的报告
+func combinePermissionAnd(results []permissionResult) (PermissionReport, error) {
+	for _, r := range results {
+		if r.err != nil {
+			return PermissionReport{}, r.err
+		}
+	}
+	for _, r := range results {
+		if !r.report.Success {
+			return r.report, nil
+		}
+	}
+	return results[0].report, nil
+}
+
+// combinePermissionOr 任一通过时成功；全部未通过时，如有调用出错返回错误，否则返回首个未通过的报告
+func combinePermissionOr(results []permissionResult) (PermissionReport, error) {
+	var failed *PermissionReport
+	var err error
+	for i, r := range results {
+		if r.err != nil {
+			if err == nil {
+				err = r.err
+			}
+			continue
+		}
+		if r.report.Success {
+			return r.report, nil
+		}
+		if failed == nil {
+			failed = &results[i].report
+		}
+	}
+	if err != nil {
+		return PermissionReport{}, err
+	}
+	return *failed, nil
+}
+
+// permissionContext 绑定单次权限服务调用的超时Context
+type permissionContext struct {
+	fluxContext
+	goctx context.Context
+}
+
+// fluxContext 嵌入 flux.Context 时避免字段名与 Context() 方法同名
+type fluxContext = flux.Context
+
+func (c *permissionContext) Context() context.Context {
+	return c.goctx
+}
@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type permissionTransporter struct {
+	invokes int32
+}
+
+func (t *permissionTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	atomic.AddInt32(&t.invokes, 1)
+	switch service.Method {
+	case "allow":
+		return flux.NewServeResponse(200, []byte(`{"success":true}`)), nil
+	case "deny":
+		return flux.NewServeResponse(200, []byte(`{"success":false,"statusCode":403,"errorCode":"PERMISSION:DENY","message":"denied"}`)), nil
+	case "tenant":
+		return flux.NewServeResponse(200, ctx.HeaderVar("X-Tenant") == "t-1"), nil
+	default:
+		select {
+		case <-ctx.Context().Done():
+			return nil, &flux.ServeError{StatusCode: flux.StatusTimeout, ErrorCode: flux.ErrorCodeRequestTimeout,
+				Message: flux.ErrorMessageRequestTimeout, CauseError: ctx.Context().Err()}
+		case <-time.After(time.Second):
+			return flux.NewServeResponse(200, true), nil
+		}
+	}
+}
+
+func TestPermissionFilterDefaultVerifier(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	ext.RegisterSerializer(ext.TypeNameSerializerJson, flux.NewJsonSerializer())
+	transporter := &permissionTransporter{}
+	ext.RegisterTransporter("permtest", transporter)
+	for _, method := range []string{"allow", "deny", "slow"} {
+		ext.RegisterService(flux.ServiceSpec{Interface: "perm", Method: method, Protocol: "permtest"})
+	}
+	ext.RegisterService(flux.ServiceSpec{Interface: "perm", Method: "tenant", Protocol: "permtest",
+		Arguments: []flux.ServiceArgumentSpec{{Name: "tenant", StructType: flux.ServiceArgumentTypePrimitive,
+			ClassType: internal.JavaLangStringClassName, HttpScope: flux.ScopeHeader, HttpName: "X-Tenant"}}})
+	newFilter := func(mode string) *PermissionFilter {
+		config := flux.NewConfiguration("permission_test_" + mode)
+		config.Set(ConfigKeyPermissionMode, mode)
+		config.Set(ConfigKeyPermissionInvokeTimeout, "50ms")
+		f := NewPermissionFilter(PermissionConfig{})
+		tester.NoError(f.OnInit(config))
+		return f
+	}
+	invoke := func(f *PermissionFilter, tenant string, permissions ...string) *flux.ServeError {
+		request := httptest.NewRequest("GET", "/orders", nil)
+		request.Header.Set("Authorization", "Bearer u-1")
+		request.Header.Set("X-Tenant", tenant)
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/orders",
+				Annotations: flux.Annotations{flux.EndpointAnnotationPermissions: permissions}})
+		return f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			return nil
+		})(ctx)
+	}
+	and := newFilter(PermissionModeAnd)
+	tester.Nil(invoke(and, "", "perm:allow"))
+	serr := invoke(and, "", "perm:allow", "perm:deny")
+	tester.NotNil(serr)
+	tester.Equal(403, serr.StatusCode)
+	tester.Equal("PERMISSION:DENY", serr.ErrorCode)
+	// 单次调用超时
+	start := time.Now()
+	serr = invoke(and, "", "perm:allow", "perm:slow")
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeRequestTimeout, serr.ErrorCode)
+	tester.True(time.Since(start) < time.Millisecond*500)
+	// 按顺序调用，未通过时不再调用后续服务
+	start = time.Now()
+	serr = invoke(and, "", "perm:deny", "perm:slow")
+	tester.NotNil(serr)
+	tester.Equal("PERMISSION:DENY", serr.ErrorCode)
+	tester.True(time.Since(start) < time.Millisecond*50)
+	or := newFilter(PermissionModeOr)
+	tester.Nil(invoke(or, "", "perm:deny", "perm:allow"))
+	tester.Nil(invoke(or, "", "perm:slow", "perm:allow"))
+	serr = invoke(or, "", "perm:deny", "perm:slow")
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeRequestTimeout, serr.ErrorCode)
+	// 按 Subject、服务和参数缓存
+	before := atomic.LoadInt32(&transporter.invokes)
+	tester.Nil(invoke(and, "t-1", "perm:tenant"))
+	tester.Nil(invoke(and, "t-1", "perm:tenant"))
+	tester.Equal(before+1, atomic.LoadInt32(&transporter.invokes))
+	tester.NotNil(invoke(and, "t-2", "perm:tenant"))
+	tester.Equal(before+2, atomic.LoadInt32(&transporter.invokes))
+}
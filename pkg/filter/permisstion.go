@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -60,7 +61,13 @@ type PermissionFilter struct {
 
 func (p *PermissionFilter) OnInit(config *flux.Configuration) error {
 	config.SetDefaults(map[string]interface{}{
-		ConfigKeyDisabled: false,
+		ConfigKeyDisabled:                false,
+		ConfigKeyPermissionMode:          PermissionModeAnd,
+		ConfigKeyPermissionInvokeTimeout: time.Second * 3,
+		ConfigKeyPermissionSubjectLookup: "HEADER:Authorization",
+		ConfigKeyCacheDisabled:           false,
+		ConfigKeyCacheExpiration:         time.Second * 30,
+		ConfigKeyCacheSize:               10000,
 	})
 	p.Disabled = config.GetBool(ConfigKeyDisabled)
 	if p.Disabled {
@@ -72,8 +79,20 @@ func (p *PermissionFilter) OnInit(config *flux.Configuration) error {
 			return false
 		}
 	}
+	// 未指定验证函数时，使用默认的权限服务验证实现
 	if flux.IsNil(p.Configs.VerifyFunc) {
-		return fmt.Errorf("PermissionFilter.PermissionVerifyFunc is nil")
+		mode := strings.ToLower(config.GetString(ConfigKeyPermissionMode))
+		if mode != PermissionModeAnd && mode != PermissionModeOr {
+			return fmt.Errorf("PermissionFilter: unsupported mode: %s", mode)
+		}
+		expiration := config.GetDuration(ConfigKeyCacheExpiration)
+		if config.GetBool(ConfigKeyCacheDisabled) {
+			expiration = 0
+		}
+		verifier := NewPermissionVerifier(p.InvokeCodec, mode, config.GetDuration(ConfigKeyPermissionInvokeTimeout),
+			config.GetString(ConfigKeyPermissionSubjectLookup), config.GetInt(ConfigKeyCacheSize), expiration)
+		p.Configs.VerifyFunc = verifier.Verify
+		logger.Infow("PERMISSION:VERIFIER:INIT", "mode", mode, "timeout", verifier.Timeout, "cache-expiration", expiration)
 	}
 	return nil
 }
@@ -0,0 +1,329 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/cast"
+)
+
+const (
+	TypeIdRateLimitFilter = "ratelimit_filter"
+)
+
+const (
+	ConfigKeyRateLimitRules     = "rules"
+	ConfigKeyRateLimitScope     = "scope"
+	ConfigKeyRateLimitMatch     = "match"
+	ConfigKeyRateLimitAlgorithm = "algorithm"
+	ConfigKeyRateLimitLimit     = "limit"
+	ConfigKeyRateLimitWindow    = "window"
+	ConfigKeyClientKeyLookup    = "client_key_lookup"
+	ConfigKeyRateLimitStore     = "store"
+	ConfigKeyStoreTimeout       = "store_timeout"
+)
+
+// 限流维度
+const (
+	RateLimitScopeApplication = "application"
+	RateLimitScopeEndpoint    = "endpoint"
+	RateLimitScopeService     = "service"
+	RateLimitScopeClient      = "client"
+)
+
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+var _ flux.Filter = new(RateLimitFilter)
+
+type (
+	// RateLimitClientKeyFunc 用于获取客户端标识的函数
+	RateLimitClientKeyFunc func(ctx flux.Context) string
+)
+
+// RateLimitRule 限流规则
+type RateLimitRule struct {
+	Scope     string        // 限流维度：application/endpoint/service/client
+	Match     string        // 匹配维度值；*表示匹配全部，支持以*结尾的前缀匹配；每个维度值独立计数
+	Algorithm string        // 限流算法：token_bucket/sliding_window
+	Limit     int           // 窗口内最大请求数
+	Window    time.Duration // 窗口时长
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	SkipFunc      flux.FilterSkipper
+	ClientKeyFunc RateLimitClientKeyFunc
+	// 限流规则；如未指定，从配置 rules 中加载。
+	// 请求按规则顺序依次申请许可，被后续规则拒绝时，已通过的规则不退还许可。
+	Rules []RateLimitRule
+	// 限流计数存储；如未指定，按配置 store 查找已注册的存储；未配置时使用本地计数
+	Store flux.RateLimitStore
+}
+
+type RateLimitMetrics struct {
+	// 通过限流检查的请求次数统计
+	AllowedAccess *prometheus.CounterVec
+	// 被限流拒绝的请求次数统计
+	RejectedAccess *prometheus.CounterVec
+	// 计数存储访问失败，降级为本地限流的次数统计
+	StoreFallback *prometheus.CounterVec
+}
+
+var (
+	rateLimitMetrics     *RateLimitMetrics
+	rateLimitMetricsOnce sync.Once
+)
+
+func NewRateLimitFilter(c RateLimitConfig) *RateLimitFilter {
+	return &RateLimitFilter{
+		RateLimitConfig: c,
+		limiter:         ratelimit.NewLocalRateLimiter(),
+	}
+}
+
+// RateLimitFilter 按应用、Endpoint、Service和客户端维度限流的Filter
+type RateLimitFilter struct {
+	RateLimitConfig
+	metrics *RateLimitMetrics
+	limiter *ratelimit.LocalRateLimiter
+	timeout time.Duration
+}
+
+func (f *RateLimitFilter) FilterId() string {
+	return TypeIdRateLimitFilter
+}
+
+func (f *RateLimitFilter) OnInit(c *flux.Configuration) error {
+	logger.Info("RateLimit filter initializing")
+	c.SetDefaults(map[string]interface{}{
+		ConfigKeyRateLimitAlgorithm: flux.RateLimitAlgorithmTokenBucket,
+		ConfigKeyClientKeyLookup:    "HEADER:X-App-Key",
+		ConfigKeyStoreTimeout:       "100ms",
+	})
+	f.metrics = newRateLimitMetrics()
+	f.timeout = c.GetDuration(ConfigKeyStoreTimeout)
+	if f.RateLimitConfig.Store == nil {
+		if id := c.GetString(ConfigKeyRateLimitStore); id != "" {
+			store, ok := ext.RateLimitStoreById(id)
+			if !ok {
+				return fmt.Errorf("ratelimit: store not found, id: %s", id)
+			}
+			f.RateLimitConfig.Store = store
+		}
+	}
+	if f.RateLimitConfig.SkipFunc == nil {
+		f.RateLimitConfig.SkipFunc = func(flux.Context) bool {
+			return false
+		}
+	}
+	if f.RateLimitConfig.ClientKeyFunc == nil {
+		expr := c.GetString(ConfigKeyClientKeyLookup)
+		f.RateLimitConfig.ClientKeyFunc = func(ctx flux.Context) string {
+			value, err := common.LookupValueByExpr(ctx, expr)
+			if nil != err {
+				return ""
+			}
+			return cast.ToString(value)
+		}
+	}
+	if len(f.RateLimitConfig.Rules) == 0 {
+		algorithm := c.GetString(ConfigKeyRateLimitAlgorithm)
+		for _, rc := range c.GetConfigurations(ConfigKeyRateLimitRules) {
+			rc.SetDefaults(map[string]interface{}{
+				ConfigKeyRateLimitMatch:     "*",
+				ConfigKeyRateLimitAlgorithm: algorithm,
+				ConfigKeyRateLimitWindow:    "1s",
+			})
+			f.RateLimitConfig.Rules = append(f.RateLimitConfig.Rules, RateLimitRule{
+				Scope:     strings.ToLower(rc.GetString(ConfigKeyRateLimitScope)),
+				Match:     rc.GetString(ConfigKeyRateLimitMatch),
+				Algorithm: strings.ToLower(rc.GetString(ConfigKeyRateLimitAlgorithm)),
+				Limit:     rc.GetInt(ConfigKeyRateLimitLimit),
+				Window:    rc.GetDuration(ConfigKeyRateLimitWindow),
+			})
+		}
+	}
+	for _, rule := range f.RateLimitConfig.Rules {
+		if err := verifyRateLimitRule(rule); nil != err {
+			return err
+		}
+		logger.Infow("RateLimit rule", "scope", rule.Scope, "match", rule.Match,
+			"algorithm", rule.Algorithm, "limit", rule.Limit, "window", rule.Window)
+	}
+	return nil
+}
+
+func (f *RateLimitFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		if f.RateLimitConfig.SkipFunc(ctx) {
+			return next(ctx)
+		}
+		defer func() {
+			ctx.AddMetric(f.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		now := time.Now()
+		var clientKey *string
+		var tightest *flux.RateLimitResult
+		for _, rule := range f.RateLimitConfig.Rules {
+			var value string
+			switch rule.Scope {
+			case RateLimitScopeApplication:
+				value = ctx.Application()
+			case RateLimitScopeEndpoint:
+				value, _, _ = ctx.Exposed()
+			case RateLimitScopeService:
+				value = ctx.ServiceID()
+			case RateLimitScopeClient:
+				if clientKey == nil {
+					key := f.RateLimitConfig.ClientKeyFunc(ctx)
+					clientKey = &key
+				}
+				value = *clientKey
+			}
+			// 无法识别维度值的请求，不受此规则限制
+			if value == "" || !matchRateLimitValue(rule.Match, value) {
+				continue
+			}
+			result := f.acquire(ctx, rule, rule.Scope+":"+rule.Match+":"+value, now)
+			if !result.Allowed {
+				f.metrics.RejectedAccess.WithLabelValues(rateLimitLabels(ctx, rule.Scope)...).Inc()
+				logger.TraceVerbose(ctx).Infow("RATELIMIT:REJECTED", "scope", rule.Scope, "match", rule.Match,
+					"value", value, "limit", rule.Limit, "window", rule.Window)
+				return &flux.ServeError{
+					StatusCode: http.StatusTooManyRequests,
+					ErrorCode:  flux.ErrorCodeRequestRateLimited,
+					Message:    flux.ErrorMessageRequestRateLimited,
+					Header:     rateLimitHeader(make(http.Header, 4), result),
+				}
+			}
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = &result
+			}
+		}
+		if tightest != nil {
+			f.metrics.AllowedAccess.WithLabelValues(rateLimitLabels(ctx, "")...).Inc()
+			rateLimitHeader(ctx.ResponseWriter().Header(), *tightest)
+		}
+		return next(ctx)
+	}
+}
+
+// acquire 从计数存储申请许可；存储不可用时，降级为本地限流
+func (f *RateLimitFilter) acquire(ctx flux.Context, rule RateLimitRule, key string, now time.Time) flux.RateLimitResult {
+	if f.RateLimitConfig.Store != nil {
+		c, cancel := context.WithTimeout(ctx.Context(), f.timeout)
+		defer cancel()
+		result, err := f.RateLimitConfig.Store.Acquire(c, key, rule.Algorithm, rule.Limit, rule.Window)
+		if nil == err {
+			return result
+		}
+		f.metrics.StoreFallback.WithLabelValues(rateLimitLabels(ctx, rule.Scope)...).Inc()
+		logger.TraceVerbose(ctx).Warnw("RATELIMIT:STORE:FALLBACK", "key", key, "error", err)
+	}
+	return f.limiter.Acquire(key, rule.Algorithm, rule.Limit, rule.Window, now)
+}
+
+func verifyRateLimitRule(rule RateLimitRule) error {
+	switch rule.Scope {
+	case RateLimitScopeApplication, RateLimitScopeEndpoint, RateLimitScopeService, RateLimitScopeClient:
+	default:
+		return fmt.Errorf("ratelimit: unsupported rule scope: %s", rule.Scope)
+	}
+	switch rule.Algorithm {
+	case flux.RateLimitAlgorithmTokenBucket, flux.RateLimitAlgorithmSlidingWindow:
+	default:
+		return fmt.Errorf("ratelimit: unsupported rule algorithm: %s", rule.Algorithm)
+	}
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		return fmt.Errorf("ratelimit: rule <limit>, <window> must be positive, scope: %s, match: %s", rule.Scope, rule.Match)
+	}
+	return nil
+}
+
+func matchRateLimitValue(match, value string) bool {
+	if match == "" || match == "*" {
+		return true
+	}
+	if strings.HasSuffix(match, "*") {
+		return strings.HasPrefix(value, match[:len(match)-1])
+	}
+	return match == value
+}
+
+func rateLimitHeader(header http.Header, result flux.RateLimitResult) http.Header {
+	header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	header.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	if !result.Allowed {
+		header.Set(HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	}
+	return header
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func rateLimitLabels(ctx flux.Context, scope string) []string {
+	listener := ""
+	if webl := ctx.WebListener(); webl != nil {
+		listener = webl.ListenerId()
+	}
+	pattern, method, version := ctx.Exposed()
+	if version == "" {
+		version = "default"
+	}
+	if scope == "" {
+		scope = "all"
+	}
+	return []string{listener, method, pattern, version, scope}
+}
+
+// 限流统计
+func newRateLimitMetrics() *RateLimitMetrics {
+	rateLimitMetricsOnce.Do(func() {
+		const namespace, subsystem = "fluxgo", "ratelimit"
+		var labels = []string{"Listener", "Method", "Pattern", "Version", "Scope"}
+		rateLimitMetrics = &RateLimitMetrics{
+			AllowedAccess: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "allowed_count",
+				Help:      "Number of endpoint access, allowed by rate limiter",
+			}, labels),
+			RejectedAccess: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rejected_count",
+				Help:      "Number of endpoint access, rejected by rate limiter",
+			}, labels),
+			StoreFallback: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "fallback_count",
+				Help:      "Number of endpoint access, fallback to local limiter on store errors",
+			}, labels),
+		}
+	})
+	return rateLimitMetrics
+}
@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitFilterRejected(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	f := NewRateLimitFilter(RateLimitConfig{
+		Rules: []RateLimitRule{
+			{Scope: RateLimitScopeClient, Match: "*", Algorithm: flux.RateLimitAlgorithmTokenBucket, Limit: 2, Window: time.Minute},
+		},
+	})
+	tester.NoError(f.OnInit(flux.NewConfiguration("ratelimit_test")))
+	invoke := func(appKey string) (*flux.ServeError, http.Header) {
+		request := httptest.NewRequest("GET", "/users", nil)
+		if appKey != "" {
+			request.Header.Set("X-App-Key", appKey)
+		}
+		recorder := httptest.NewRecorder()
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, recorder), "test-id", nil), &flux.EndpointSpec{
+			Application: "app", HttpMethod: "GET", HttpPattern: "/users",
+		})
+		return f.DoFilter(func(flux.Context) *flux.ServeError {
+			return nil
+		})(ctx), recorder.Header()
+	}
+	serr, header := invoke("a")
+	tester.Nil(serr)
+	tester.Equal("2", header.Get(HeaderRateLimitLimit))
+	tester.Equal("1", header.Get(HeaderRateLimitRemaining))
+	serr, _ = invoke("a")
+	tester.Nil(serr)
+	serr, _ = invoke("a")
+	tester.NotNil(serr)
+	tester.Equal(http.StatusTooManyRequests, serr.StatusCode)
+	tester.Equal(flux.ErrorCodeRequestRateLimited, serr.ErrorCode)
+	tester.Equal("30", serr.Header.Get(HeaderRetryAfter))
+	// other client
+	serr, _ = invoke("b")
+	tester.Nil(serr)
+	// client key not found, not limited
+	for i := 0; i < 3; i++ {
+		serr, _ = invoke("")
+		tester.Nil(serr)
+	}
+}
+
+type errorRateLimitStore struct{}
+
+func (errorRateLimitStore) Acquire(context.Context, string, string, int, time.Duration) (flux.RateLimitResult, error) {
+	return flux.RateLimitResult{}, errors.New("store unreachable")
+}
+
+func TestRateLimitFilterStoreFallback(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	f := NewRateLimitFilter(RateLimitConfig{
+		Store: errorRateLimitStore{},
+		Rules: []RateLimitRule{
+			{Scope: RateLimitScopeApplication, Match: "app", Algorithm: flux.RateLimitAlgorithmSlidingWindow, Limit: 1, Window: time.Minute},
+		},
+	})
+	tester.NoError(f.OnInit(flux.NewConfiguration("ratelimit_fallback_test")))
+	invoke := func() *flux.ServeError {
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(httptest.NewRequest("GET", "/users", nil), httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{Application: "app", HttpMethod: "GET", HttpPattern: "/users"})
+		return f.DoFilter(func(flux.Context) *flux.ServeError {
+			return nil
+		})(ctx)
+	}
+	tester.Nil(invoke())
+	serr := invoke()
+	tester.NotNil(serr)
+	tester.Equal(http.StatusTooManyRequests, serr.StatusCode)
+}
+
+func TestRateLimitFilterMultiRules(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	f := NewRateLimitFilter(RateLimitConfig{
+		Rules: []RateLimitRule{
+			{Scope: RateLimitScopeApplication, Match: "app", Algorithm: flux.RateLimitAlgorithmSlidingWindow, Limit: 3, Window: time.Hour},
+			{Scope: RateLimitScopeClient, Match: "*", Algorithm: flux.RateLimitAlgorithmSlidingWindow, Limit: 1, Window: time.Hour},
+		},
+	})
+	tester.NoError(f.OnInit(flux.NewConfiguration("ratelimit_multi_test")))
+	invoke := func(appKey string) *flux.ServeError {
+		request := httptest.NewRequest("GET", "/users", nil)
+		request.Header.Set("X-App-Key", appKey)
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{Application: "app", HttpMethod: "GET", HttpPattern: "/users"})
+		return f.DoFilter(func(flux.Context) *flux.ServeError {
+			return nil
+		})(ctx)
+	}
+	tester.Nil(invoke("a"))
+	// 被客户端规则拒绝，已通过的应用规则不退还许可
+	tester.NotNil(invoke("a"))
+	tester.Nil(invoke("b"))
+	serr := invoke("c")
+	tester.NotNil(serr)
+	tester.Equal(http.StatusTooManyRequests, serr.StatusCode)
+}
@@ -0,0 +1,293 @@
+package filter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/spf13/cast"
+)
+
+const (
+	TypeIdSignatureFilter = "signature_filter"
+)
+
+const (
+	ConfigKeySignatureSecrets       = "secrets"
+	ConfigKeySignatureClockSkew     = "clock_skew"
+	ConfigKeySignatureSignedHeaders = "signed_headers"
+)
+
+// 签名请求的Header
+const (
+	HeaderSignatureAccessKey = "X-Signature-Key"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	HeaderSignatureNonce     = "X-Signature-Nonce"
+	HeaderSignatureHeaders   = "X-Signature-Headers"
+	HeaderSignature          = "X-Signature"
+)
+
+// SignatureAttrAccessKey 验签通过后，AccessKey绑定到Attribute的键名
+const SignatureAttrAccessKey = "signature.access_key"
+
+var (
+	_ flux.Filter          = new(SignatureFilter)
+	_ flux.Initializer     = new(SignatureFilter)
+	_ flux.FilterSkippable = new(SignatureFilter)
+)
+
+type (
+	// SignatureSecretFunc 查找AccessKey对应的签名密钥；不存在时返回空字符串
+	SignatureSecretFunc func(ctx flux.Context, accessKey string) (secret string, err error)
+)
+
+// SignatureConfig 请求签名验证配置
+type SignatureConfig struct {
+	SkipFunc flux.FilterSkipper
+	// 签名密钥查找函数；未设置时，从配置 secrets 中加载
+	SecretFunc SignatureSecretFunc
+	// 防重放缓存；未设置时使用本地内存缓存
+	NonceCache NonceCache
+	// 允许的客户端时钟偏差
+	ClockSkew time.Duration
+	// 必须参与签名的Header
+	SignedHeaders []string
+}
+
+func NewSignatureFilter(c SignatureConfig) *SignatureFilter {
+	return &SignatureFilter{
+		Config: c,
+	}
+}
+
+// SignatureFilter 基于HMAC-SHA256的请求签名验证。签名原文由请求方法、路径、排序后的Query参数、
+// 参与签名的Header、Body摘要、时间戳和随机数组成；时间戳须在时钟偏差范围内，随机数在有效期内不可重复使用。
+type SignatureFilter struct {
+	Config SignatureConfig
+}
+
+func (f *SignatureFilter) FilterId() string {
+	return TypeIdSignatureFilter
+}
+
+func (f *SignatureFilter) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeySignatureClockSkew: time.Minute * 5,
+	})
+	if flux.IsNil(f.Config.SkipFunc) {
+		f.Config.SkipFunc = func(_ flux.Context) bool {
+			return false
+		}
+	}
+	if f.Config.ClockSkew <= 0 {
+		f.Config.ClockSkew = config.GetDuration(ConfigKeySignatureClockSkew)
+	}
+	if len(f.Config.SignedHeaders) == 0 {
+		f.Config.SignedHeaders = config.GetStringSlice(ConfigKeySignatureSignedHeaders)
+	}
+	if flux.IsNil(f.Config.NonceCache) {
+		f.Config.NonceCache = NewMemoryNonceCache()
+	}
+	if flux.IsNil(f.Config.SecretFunc) {
+		secrets := cast.ToStringMapString(config.Get(ConfigKeySignatureSecrets))
+		if len(secrets) == 0 {
+			return fmt.Errorf("signature: <%s> is required when secret func not set", ConfigKeySignatureSecrets)
+		}
+		f.Config.SecretFunc = func(_ flux.Context, accessKey string) (string, error) {
+			return secrets[accessKey], nil
+		}
+	}
+	logger.Infow("AUTHORIZATION:SIGNATURE:INIT", "clock-skew", f.Config.ClockSkew, "signed-headers", f.Config.SignedHeaders)
+	return nil
+}
+
+func (f *SignatureFilter) ShouldSkip(ctx flux.Context) bool {
+	return f.Config.SkipFunc(ctx)
+}
+
+func (f *SignatureFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		if f.Config.SkipFunc(ctx) {
+			return next(ctx)
+		}
+		defer func() {
+			ctx.AddMetric(f.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		if serr := f.verify(ctx); serr != nil {
+			ctx.Logger().Infow("AUTHORIZATION:SIGNATURE:VALIDATE:REJECTED",
+				"access-key", ctx.HeaderVar(HeaderSignatureAccessKey), "error", serr.ErrorCode, "message", serr.Message)
+			return serr
+		}
+		ctx.SetAttribute(SignatureAttrAccessKey, ctx.HeaderVar(HeaderSignatureAccessKey))
+		return next(ctx)
+	}
+}
+
+func (f *SignatureFilter) verify(ctx flux.Context) *flux.ServeError {
+	accessKey := ctx.HeaderVar(HeaderSignatureAccessKey)
+	timestamp := ctx.HeaderVar(HeaderSignatureTimestamp)
+	nonce := ctx.HeaderVar(HeaderSignatureNonce)
+	signature := ctx.HeaderVar(HeaderSignature)
+	if "" == accessKey || "" == timestamp || "" == nonce || "" == signature {
+		return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureNotFound, "SIGNATURE:VALIDATE: signature headers not found", nil)
+	}
+	// 时间戳：Unix秒
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if nil != err {
+		return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureInvalid, "SIGNATURE:VALIDATE: invalid timestamp", err)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > f.Config.ClockSkew || skew < -f.Config.ClockSkew {
+		return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureExpired, "SIGNATURE:VALIDATE: timestamp out of clock skew", nil)
+	}
+	signedHeaders := splitSignedHeaders(ctx.HeaderVar(HeaderSignatureHeaders))
+	for _, required := range f.Config.SignedHeaders {
+		if !containsHeader(signedHeaders, required) {
+			return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureInvalid,
+				"SIGNATURE:VALIDATE: required header not signed", fmt.Errorf("header: %s", required))
+		}
+	}
+	secret, err := f.Config.SecretFunc(ctx, accessKey)
+	if nil != err {
+		return newSignatureError(flux.StatusServerError, flux.ErrorCodeGatewayInternal, "SIGNATURE:VALIDATE: secret lookup failed", err)
+	}
+	if "" == secret {
+		return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureInvalid, "SIGNATURE:VALIDATE: unknown access key", nil)
+	}
+	digest, err := bodyDigestOf(ctx)
+	if nil != err {
+		return newSignatureError(flux.StatusBadRequest, flux.ErrorCodeRequestInvalid, "SIGNATURE:VALIDATE: read body failed", err)
+	}
+	canonical := CanonicalRequest(ctx.Method(), ctx.URL().EscapedPath(), ctx.QueryVars(), ctx.HeaderVars(),
+		signedHeaders, digest, timestamp, nonce)
+	expected := ComputeSignature(secret, canonical)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureInvalid, "SIGNATURE:VALIDATE: signature mismatch", nil)
+	}
+	// 签名通过后再记录随机数，避免伪造请求占用随机数；有效期覆盖时钟偏差的前后窗口
+	fresh, err := f.Config.NonceCache.Remember(accessKey+":"+nonce, f.Config.ClockSkew*2)
+	if nil != err {
+		return newSignatureError(flux.StatusServerError, flux.ErrorCodeGatewayInternal, "SIGNATURE:VALIDATE: nonce cache failed", err)
+	}
+	if !fresh {
+		return newSignatureError(http.StatusUnauthorized, flux.ErrorCodeSignatureReplayed, "SIGNATURE:VALIDATE: nonce replayed", nil)
+	}
+	return nil
+}
+
+// CanonicalRequest 生成签名原文，各部分以换行符连接：
+// METHOD, PATH, 排序后的Query, 参与签名的Header(name:value), 参与签名的Header名称列表, Body的SHA256摘要(Hex), 时间戳, 随机数
+func CanonicalRequest(method, path string, query url.Values, header http.Header, signedHeaders []string,
+	bodyDigest, timestamp, nonce string) string {
+	names := make([]string, 0, len(signedHeaders))
+	for _, name := range signedHeaders {
+		names = append(names, strings.ToLower(strings.TrimSpace(name)))
+	}
+	sort.Strings(names)
+	headers := make([]string, 0, len(names))
+	for _, name := range names {
+		values := header.Values(name)
+		trimmed := make([]string, 0, len(values))
+		for _, v := range values {
+			trimmed = append(trimmed, strings.TrimSpace(v))
+		}
+		headers = append(headers, name+":"+strings.Join(trimmed, ","))
+	}
+	if "" == path {
+		path = "/"
+	}
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		canonicalQuery(query),
+		strings.Join(headers, "\n"),
+		strings.Join(names, ";"),
+		bodyDigest,
+		timestamp,
+		nonce,
+	}, "\n")
+}
+
+// ComputeSignature 计算签名原文的HMAC-SHA256签名，返回小写Hex编码
+func ComputeSignature(secret, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BodyDigest 返回Body的SHA256摘要，小写Hex编码
+func BodyDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func bodyDigestOf(ctx flux.Context) (string, error) {
+	hash := sha256.New()
+	if ctx.Request().GetBody != nil {
+		reader, err := ctx.BodyReader()
+		if nil != err {
+			return "", err
+		}
+		defer reader.Close()
+		if _, err := io.Copy(hash, reader); nil != err {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func splitSignedHeaders(value string) []string {
+	out := make([]string, 0, 4)
+	for _, name := range strings.Split(value, ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func newSignatureError(status int, code, message string, cause error) *flux.ServeError {
+	return &flux.ServeError{
+		StatusCode: status,
+		ErrorCode:  code,
+		Message:    message,
+		CauseError: cause,
+	}
+}
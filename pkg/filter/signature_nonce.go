@@ -0,0 +1,58 @@
+package filter
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceCache 签名随机数的防重放缓存；可替换为Redis等共享存储，以支持多实例部署
+type NonceCache interface {
+	// Remember 记录随机数，有效期为ttl；有效期内已记录过时返回false
+	Remember(nonce string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceCache 基于本地内存的随机数缓存；过期记录在写入时定期清理
+type MemoryNonceCache struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+	interval  time.Duration
+}
+
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{
+		nonces:    make(map[string]time.Time),
+		lastSweep: time.Now(),
+		interval:  time.Minute,
+	}
+}
+
+func (c *MemoryNonceCache) Remember(nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) >= c.interval {
+		c.sweep(now)
+	}
+	if expireAt, ok := c.nonces[nonce]; ok && now.Before(expireAt) {
+		return false, nil
+	}
+	c.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// Len 返回当前记录的随机数数量，包含未清理的过期记录
+func (c *MemoryNonceCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.nonces)
+}
+
+func (c *MemoryNonceCache) sweep(now time.Time) {
+	for nonce, expireAt := range c.nonces {
+		if !now.Before(expireAt) {
+			delete(c.nonces, nonce)
+		}
+	}
+	c.lastSweep = now
+}
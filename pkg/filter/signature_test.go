@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type signedRequest struct {
+	accessKey string
+	secret    string
+	timestamp time.Time
+	nonce     string
+	body      string
+	tamper    bool
+}
+
+func (s signedRequest) invoke(f *SignatureFilter) (flux.Context, *flux.ServeError) {
+	request := httptest.NewRequest("POST", "/orders?b=2&a=1&a=0", strings.NewReader(s.body))
+	body := []byte(s.body)
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	request.Header.Set("Content-Type", "application/json")
+	timestamp := strconv.FormatInt(s.timestamp.Unix(), 10)
+	canonical := CanonicalRequest("POST", "/orders", request.URL.Query(), request.Header,
+		[]string{"Content-Type"}, BodyDigest(body), timestamp, s.nonce)
+	request.Header.Set(HeaderSignatureAccessKey, s.accessKey)
+	request.Header.Set(HeaderSignatureTimestamp, timestamp)
+	request.Header.Set(HeaderSignatureNonce, s.nonce)
+	request.Header.Set(HeaderSignatureHeaders, "content-type")
+	request.Header.Set(HeaderSignature, ComputeSignature(s.secret, canonical))
+	if s.tamper {
+		body = []byte(`{"amount":1000}`)
+	}
+	ctx := internal.NewContext()
+	ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+		&flux.EndpointSpec{HttpMethod: "POST", HttpPattern: "/orders"})
+	return ctx, f.DoFilter(func(ctx flux.Context) *flux.ServeError {
+		return nil
+	})(ctx)
+}
+
+func TestSignatureFilter(t *testing.T) {
+	tester := assert.New(t)
+	config := flux.NewConfiguration("signature_test")
+	config.Set(ConfigKeySignatureSecrets, map[string]interface{}{"ak-1": "sk-1"})
+	config.Set(ConfigKeySignatureSignedHeaders, []string{"Content-Type"})
+	f := NewSignatureFilter(SignatureConfig{})
+	tester.NoError(f.OnInit(config))
+	req := signedRequest{accessKey: "ak-1", secret: "sk-1", timestamp: time.Now(), nonce: "n-1", body: `{"amount":1}`}
+	ctx, serr := req.invoke(f)
+	tester.Nil(serr)
+	tester.Equal("ak-1", ctx.Attribute(SignatureAttrAccessKey, ""))
+	// 重放
+	_, serr = req.invoke(f)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeSignatureReplayed, serr.ErrorCode)
+	cases := map[string]signedRequest{
+		flux.ErrorCodeSignatureInvalid:  {accessKey: "ak-1", secret: "sk-x", timestamp: time.Now(), nonce: "n-2"},
+		flux.ErrorCodeSignatureExpired:  {accessKey: "ak-1", secret: "sk-1", timestamp: time.Now().Add(-time.Hour), nonce: "n-3"},
+		flux.ErrorCodeSignatureNotFound: {secret: "sk-1", timestamp: time.Now(), nonce: "n-4"},
+	}
+	for code, req := range cases {
+		_, serr := req.invoke(f)
+		tester.NotNil(serr, code)
+		tester.Equal(code, serr.ErrorCode, code)
+	}
+	// 篡改Body
+	tampered := signedRequest{accessKey: "ak-1", secret: "sk-1", timestamp: time.Now(), nonce: "n-5",
+		body: `{"amount":1}`, tamper: true}
+	_, serr = tampered.invoke(f)
+	tester.NotNil(serr)
+	tester.Equal("SIGNATURE:VALIDATE: signature mismatch", serr.Message)
+}
+
+func TestMemoryNonceCache(t *testing.T) {
+	tester := assert.New(t)
+	cache := NewMemoryNonceCache()
+	cache.interval = 0
+	ok, _ := cache.Remember("n", time.Millisecond*10)
+	tester.True(ok)
+	ok, _ = cache.Remember("n", time.Millisecond*10)
+	tester.False(ok)
+	time.Sleep(time.Millisecond * 20)
+	ok, _ = cache.Remember("n", time.Millisecond*10)
+	tester.True(ok)
+	tester.Equal(1, cache.Len())
+}
@@ -12,9 +12,11 @@ import (
 )
 
 const (
-	NamespaceWebListeners = "listeners"
-	NamespaceTransporters = "transporters"
-	NamespaceDiscoveries  = "discoveries"
+	NamespaceWebListeners    = "listeners"
+	NamespaceTransporters    = "transporters"
+	NamespaceDiscoveries     = "discoveries"
+	NamespaceRateLimitStores = "ratelimit_stores"
+	NamespaceTracing         = "tracing"
 )
 
 // MakeConfigurationKey 根据Key列表，构建Configuration的查询Key。
@@ -47,6 +47,9 @@ type Context interface {
 	// SetAttribute 向Context添加Attribute键值对
 	SetAttribute(key string, value interface{})
 
+	// RemoveAttribute 删除Context自身的Attribute键值对
+	RemoveAttribute(key string)
+
 	// StartAt 返回Http请求起始的服务器时间
 	StartAt() time.Time
 
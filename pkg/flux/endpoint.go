@@ -12,6 +12,24 @@ const (
 	EndpointAnnotationAuthorize   = "flux.go/authorize"         // 标识Endpoint访问是否需要授权的注解
 	EndpointAnnotationListenerSel = "flux.go/listener.selector" // 标识Endpoint绑定到哪个ListenServer服务
 	EndpointAnnotationStaticModel = "flux.go/static.model"      // 标识此Endpoint为固定数据模型，不支持动态更新
+	EndpointAnnotationCacheTTL    = "flux.go/cache.ttl"         // 标识Endpoint启用响应缓存及缓存时长
+	EndpointAnnotationCacheKeys   = "flux.go/cache.keys"        // 响应缓存Key的查找表达式列表；未指定时使用参数解析值
+	EndpointAnnotationTimeout     = "flux.go/timeout"           // Endpoint请求的网关级超时时长；未声明时使用Listener的默认配置
+	EndpointAnnotationJWTIssuer   = "flux.go/jwt.issuer"        // JWT/Token校验的签发者；未声明时使用JWTFilter的默认配置
+	EndpointAnnotationJWTAudience = "flux.go/jwt.audience"      // JWT/Token校验的受众，多个值以逗号分隔，匹配任一即可
+	EndpointAnnotationAuthzScopes = "flux.go/authz.scopes"      // 访问Endpoint需要的Scope授权表达式，支持 AND/OR 和 * 通配
+	EndpointAnnotationAuthzRoles  = "flux.go/authz.roles"       // 访问Endpoint需要的角色授权表达式，支持 AND/OR
+	EndpointAnnotationIPAllow     = "flux.go/ip.allow"          // 允许访问Endpoint的客户端IP/CIDR，多个值以逗号分隔
+	EndpointAnnotationIPDeny      = "flux.go/ip.deny"           // 禁止访问Endpoint的客户端IP/CIDR，多个值以逗号分隔
+)
+
+// Endpoint内置属性：响应数据转换；多个转换属性按声明顺序执行
+const (
+	EndpointAttrTransformSelect = "flux.go/transform.select" // 选择响应数据的字段作为响应体，例如：$.data
+	EndpointAttrTransformRename = "flux.go/transform.rename" // 重命名响应数据字段，例如：$.msg=$.message
+	EndpointAttrTransformRemove = "flux.go/transform.remove" // 删除响应数据字段，例如：$.data.password
+	EndpointAttrTransformSet    = "flux.go/transform.set"    // 设置响应数据字段为常量值，例如：$.source="gateway"
+	EndpointAttrTransformStatus = "flux.go/transform.status" // 根据响应数据字段映射响应状态码，例如：$.code != 0 -> 502
 )
 
 // EndpointSpec 定义前端Http请求与后端RPC服务的端点元数据
@@ -7,20 +7,48 @@ const (
 )
 
 const (
-	ErrorCodeRequestInvalid   = "GATEWAY:REQUEST:INVALID"
-	ErrorCodeRequestNotFound  = "GATEWAY:REQUEST:NOT_FOUND"
-	ErrorCodeRequestCircuited = "GATEWAY:REQUEST:CIRCUITED"
-	ErrorCodeRequestCanceled  = "GATEWAY:REQUEST:CANCELED"
+	ErrorCodeRequestInvalid     = "GATEWAY:REQUEST:INVALID"
+	ErrorCodeRequestNotFound    = "GATEWAY:REQUEST:NOT_FOUND"
+	ErrorCodeRequestCircuited   = "GATEWAY:REQUEST:CIRCUITED"
+	ErrorCodeRequestCanceled    = "GATEWAY:REQUEST:CANCELED"
+	ErrorCodeRequestRateLimited = "GATEWAY:REQUEST:RATE_LIMITED"
+	ErrorCodeRequestTimeout     = "GATEWAY:REQUEST:TIMEOUT"
 )
 
 const (
 	ErrorCodePermissionDenied = "GATEWAY:PERMISSION:ACCESS_DENIED"
+	ErrorCodeIPAccessDenied   = "GATEWAY:PERMISSION:IP_DENIED"
+)
+
+const (
+	ErrorCodeAuthenticationRequired = "GATEWAY:AUTHORIZATION:REQUIRED"
 )
 
 const (
 	ErrorCodeJwtMalformed = "GATEWAY:AUTHORIZATION:JWT:MALFORMED"
 	ErrorCodeJwtExpired   = "GATEWAY:AUTHORIZATION:JWT:EXPIRED"
 	ErrorCodeJwtNotFound  = "GATEWAY:AUTHORIZATION:JWT:NOTFOUND"
+	ErrorCodeJwtInvalid   = "GATEWAY:AUTHORIZATION:JWT:INVALID"
+)
+
+const (
+	ErrorCodeApiKeyNotFound   = "GATEWAY:AUTHORIZATION:APIKEY:NOTFOUND"
+	ErrorCodeApiKeyInvalid    = "GATEWAY:AUTHORIZATION:APIKEY:INVALID"
+	ErrorCodeApiKeyDisabled   = "GATEWAY:AUTHORIZATION:APIKEY:DISABLED"
+	ErrorCodeApiKeyExpired    = "GATEWAY:AUTHORIZATION:APIKEY:EXPIRED"
+	ErrorCodeApiKeyOutOfScope = "GATEWAY:AUTHORIZATION:APIKEY:OUT_OF_SCOPE"
+)
+
+const (
+	ErrorCodeSignatureNotFound = "GATEWAY:AUTHORIZATION:SIGNATURE:NOTFOUND"
+	ErrorCodeSignatureInvalid  = "GATEWAY:AUTHORIZATION:SIGNATURE:INVALID"
+	ErrorCodeSignatureExpired  = "GATEWAY:AUTHORIZATION:SIGNATURE:EXPIRED"
+	ErrorCodeSignatureReplayed = "GATEWAY:AUTHORIZATION:SIGNATURE:REPLAYED"
+)
+
+const (
+	ErrorCodeTokenInactive      = "GATEWAY:AUTHORIZATION:TOKEN:INACTIVE"
+	ErrorCodeTokenIntrospection = "GATEWAY:AUTHORIZATION:TOKEN:INTROSPECTION"
 )
 
 //// Messages
@@ -29,12 +57,23 @@ const (
 	ErrorMessageProtocolUnknown          = "GATEWAY:PROTOCOL:UNKNOWN"
 	ErrorMessageWebServerRequestNotFound = "SERVER:REQUEST:NOT_FOUND"
 	ErrorMessageRequestPrepare           = "REQUEST:BODY:PREPARE"
+	ErrorMessageRequestRateLimited       = "REQUEST:RATE_LIMITED"
+	ErrorMessageRequestTimeout           = "REQUEST:TIMEOUT"
 )
 
 const (
 	ErrorMessagePermissionAccessDenied    = "PERMISSION:ACCESS_DENIED"
 	ErrorMessagePermissionServiceNotFound = "PERMISSION:SERVICE:NOT_FOUND"
 	ErrorMessagePermissionVerifyError     = "PERMISSION:VERIFY:ERROR"
+	ErrorMessageAuthenticationRequired    = "AUTHORIZATION:AUTHENTICATION:REQUIRED"
+	ErrorMessageAuthenticatorNotFound     = "AUTHORIZATION:AUTHENTICATOR:NOT_FOUND"
+)
+
+const (
+	ErrorMessageAdminSerializeFailed       = "ADMIN:SERIALIZE/error"
+	ErrorMessageAdminDiscoveryNotFound     = "ADMIN:DISCOVERY:NOT_FOUND"
+	ErrorMessageAdminDiscoverySubmitFailed = "ADMIN:DISCOVERY:SUBMIT/error"
+	ErrorMessageAdminUnauthorized          = "ADMIN:UNAUTHORIZED"
 )
 
 const (
@@ -43,7 +82,14 @@ const (
 	ErrorMessageTransportDubboClientCanceled      = "TRANSPORT:DU:CANCELED/client"
 	ErrorMessageTransportDubboDecodeInvalidHeader = "TRANSPORT:DU:DECODE:INVALID/headers"
 	ErrorMessageTransportDubboDecodeInvalidStatus = "TRANSPORT:DU:DECODE:INVALID/status"
+	ErrorMessageTransportGrpcInvokeFailed         = "TRANSPORT:GR:INVOKE/error"
+	ErrorMessageTransportGrpcAssembleFailed       = "TRANSPORT:GR:ASSEMBLE/error"
+	ErrorMessageTransportGrpcDescriptorFailed     = "TRANSPORT:GR:DESCRIPTOR/error"
+	ErrorMessageTransportGrpcClientCanceled       = "TRANSPORT:GR:CANCELED/client"
 	ErrorMessageTransportHttpInvokeFailed         = "TRANSPORT:HT:INVOKE/error"
 	ErrorMessageTransportHttpAssembleFailed       = "TRANSPORT:HT:ASSEMBLE/error"
+	ErrorMessageTransportHttpWebSocketHandshake   = "TRANSPORT:HT:WEBSOCKET/handshake"
+	ErrorMessageTransportHttpWebSocketUpgrade     = "TRANSPORT:HT:WEBSOCKET/upgrade"
 	ErrorMessageTransportCodecError               = "TRANSPORT:CODEC/error"
+	ErrorMessageTransformResponseFailed           = "TRANSFORM:RESPONSE/error"
 )
@@ -17,6 +17,12 @@ type (
 		DoFilter(next FilterInvoker) FilterInvoker
 	}
 
+	// FilterSkippable 支持跳过处理的Filter；用于在执行Filter前判断是否跳过当前请求
+	FilterSkippable interface {
+		// ShouldSkip 返回当前请求是否跳过此Filter的处理
+		ShouldSkip(Context) bool
+	}
+
 	// FilterSelector 用于请求处理前的动态选择Filter
 	FilterSelector interface {
 		// Activate 返回当前请求是否激活Selector
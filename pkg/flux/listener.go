@@ -13,6 +13,8 @@ const (
 	MIMEApplicationJSON            = "application/json"
 	MIMEApplicationJSONCharsetUTF8 = MIMEApplicationJSON + "; " + charsetUTF8
 	MIMEApplicationForm            = "application/x-www-form-urlencoded"
+	MIMEApplicationOctetStream     = "application/octet-stream"
+	MIMETextEventStream            = "text/event-stream"
 )
 
 // Headers
@@ -34,6 +36,7 @@ const (
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
 	HeaderXForwardedFor       = "X-Forwarded-For"
+	HeaderXForwardedHost      = "X-Forwarded-Host"
 	HeaderXForwardedProto     = "X-Forwarded-Protocol"
 	HeaderXForwardedProtocol  = "X-Forwarded-Protocol"
 	HeaderXForwardedSsl       = "X-Forwarded-Ssl"
@@ -66,7 +69,8 @@ const (
 	HeaderReferrerPolicy                  = "Referrer-Policy"
 
 	// Ext
-	HeaderXRequestId = "X-Request-Id"
+	HeaderXRequestId      = "X-Request-Id"
+	HeaderXRequestTimeout = "X-Request-Timeout" // 请求剩余的超时时长，单位：毫秒
 )
 
 // Common used status code
@@ -78,6 +82,7 @@ const (
 	StatusAccessDenied = http.StatusForbidden
 	StatusServerError  = http.StatusInternalServerError
 	StatusBadGateway   = http.StatusBadGateway
+	StatusTimeout      = http.StatusGatewayTimeout
 	StatusNoContent    = http.StatusNoContent
 )
 
@@ -131,9 +136,12 @@ type WebContext interface {
 	// Host 返回当前请求的Host
 	Host() string
 
-	// RemoteAddr 返回当前请求的客户端地址
+	// RemoteAddr 返回当前请求的客户端地址；经可信代理转发时，返回解析的真实客户端IP
 	RemoteAddr() string
 
+	// TrustedProxy 返回当前请求的直连地址是否属于可信代理
+	TrustedProxy() bool
+
 	// HeaderVars 返回请求对象的Header；只读；
 	HeaderVars() http.Header
 
@@ -241,6 +249,9 @@ type WebListener interface {
 	// AddHttpHandler 添加http标准请求路由处理函数及其中间件
 	AddHttpHandler(method, pattern string, h http.Handler, m ...func(http.Handler) http.Handler)
 
+	// RemoveHandler 删除请求路由处理函数；删除后请求按路由不存在处理；
+	RemoveHandler(method, pattern string)
+
 	// ServeHTTP Http调用
 	ServeHTTP(w http.ResponseWriter, r *http.Request)
 
@@ -0,0 +1,28 @@
+package flux
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	RateLimitAlgorithmTokenBucket   = "token_bucket"
+	RateLimitAlgorithmSlidingWindow = "sliding_window"
+)
+
+type (
+	// RateLimitResult 限流许可申请结果
+	RateLimitResult struct {
+		Allowed    bool          // 是否允许通过
+		Limit      int           // 窗口内最大请求数
+		Remaining  int           // 窗口内剩余请求数
+		ResetAfter time.Duration // 窗口重置（配额恢复）剩余时间
+		RetryAfter time.Duration // 被拒绝时，建议的重试等待时间
+	}
+
+	// RateLimitStore 限流计数存储；通过共享存储，在多个网关实例之间实现全局限流。
+	RateLimitStore interface {
+		// Acquire 按指定算法，对Key申请一个请求许可
+		Acquire(ctx context.Context, key, algorithm string, limit int, window time.Duration) (RateLimitResult, error)
+	}
+)
@@ -75,6 +75,20 @@ type (
 	}
 )
 
+// HijackedBody 表示客户端连接已被Transporter接管（例如WebSocket代理）的响应数据体
+type HijackedBody struct{}
+
+// NewHijackedServeResponse 返回客户端连接已被接管的响应；Dispatcher不再向客户端写入响应数据
+func NewHijackedServeResponse() *ServeResponse {
+	return NewServeResponse(http.StatusSwitchingProtocols, HijackedBody{})
+}
+
+// IsHijacked 判断响应是否表示客户端连接已被接管
+func (r *ServeResponse) IsHijacked() bool {
+	_, ok := r.Body.(HijackedBody)
+	return ok
+}
+
 func NewServeResponse(status int, body interface{}) *ServeResponse {
 	return &ServeResponse{
 		StatusCode:  status,
@@ -8,6 +8,44 @@ const (
 	ServiceAnnotationRpcRetries = "flux.go/rpc.retries"
 )
 
+// Service重试策略注解；由Dispatcher在Transporter调用层执行，与具体协议无关
+const (
+	ServiceAnnotationRetryAttempts   = "flux.go/retry.attempts"    // 最大调用次数（包含首次调用）
+	ServiceAnnotationRetryBackoff    = "flux.go/retry.backoff"     // 首次重试的退避时长，按指数递增
+	ServiceAnnotationRetryMaxBackoff = "flux.go/retry.max_backoff" // 最大退避时长
+	ServiceAnnotationRetryStatus     = "flux.go/retry.status"      // 可重试的响应状态码列表
+	ServiceAnnotationRetryErrorCodes = "flux.go/retry.error_codes" // 可重试的ServeError错误码列表
+	ServiceAnnotationRetryIdempotent = "flux.go/retry.idempotent"  // 是否仅对幂等请求方法重试；默认为true
+)
+
+// Http Service的上游负载均衡注解；声明后，Service.Url的协议和主机由选中的上游目标替换
+const (
+	ServiceAnnotationHttpUpstream = "flux.go/http.upstream" // 命名Upstream，定义在 transporters.http.upstreams 配置中
+	ServiceAnnotationHttpTargets  = "flux.go/http.targets"  // 上游目标列表，格式：URL[;weight=N]，多个值以逗号分隔
+	ServiceAnnotationHttpBalancer = "flux.go/http.balancer" // 负载均衡策略：round_robin, weighted_random, least_conn, consistent_hash
+	ServiceAnnotationHttpHashKey  = "flux.go/http.hash_key" // 一致性Hash的查找表达式，例如：HEADER:X-User-Id
+)
+
+const (
+	ServiceAnnotationHttpTransport = "flux.go/http.transport" // 命名传输配置，定义在 transporters.http.transports 配置中
+	ServiceAnnotationHttpWebSocket = "flux.go/http.websocket" // 是否代理WebSocket连接；Service.Url为ws/wss协议时默认启用
+)
+
+// Http Service的请求重写注解；重写后的路径拼接在Service.Url的路径之后
+const (
+	ServiceAnnotationHttpRewritePath          = "flux.go/http.rewrite.path"           // 路径模板，以动态路径参数替换，例如：/v2/users/{id}
+	ServiceAnnotationHttpRewriteRegex         = "flux.go/http.rewrite.regex"          // 请求路径的正则重写，格式：<pattern> <replacement>
+	ServiceAnnotationHttpStripPrefix          = "flux.go/http.strip_prefix"           // 删除请求路径的前缀
+	ServiceAnnotationHttpMethod               = "flux.go/http.method"                 // 重写请求方法
+	ServiceAnnotationHttpForwardCookie        = "flux.go/http.forward_cookie"         // 是否转发Cookie；默认为false
+	ServiceAnnotationHttpRequestHeaderAdd     = "flux.go/http.request.header.add"     // 添加请求Header，格式：Name: Value，多个值以逗号分隔
+	ServiceAnnotationHttpRequestHeaderSet     = "flux.go/http.request.header.set"     // 设置请求Header，格式：Name: Value，多个值以逗号分隔
+	ServiceAnnotationHttpRequestHeaderRemove  = "flux.go/http.request.header.remove"  // 删除请求Header，多个值以逗号分隔
+	ServiceAnnotationHttpResponseHeaderAdd    = "flux.go/http.response.header.add"    // 添加响应Header，格式：Name: Value，多个值以逗号分隔
+	ServiceAnnotationHttpResponseHeaderSet    = "flux.go/http.response.header.set"    // 设置响应Header，格式：Name: Value，多个值以逗号分隔
+	ServiceAnnotationHttpResponseHeaderRemove = "flux.go/http.response.header.remove" // 删除响应Header，多个值以逗号分隔
+)
+
 const (
 	// ServiceArgumentTypePrimitive 原始参数类型：int,long...
 	ServiceArgumentTypePrimitive = "PRIMITIVE"
@@ -37,6 +75,11 @@ func (s ServiceSpec) Annotation(name string) NamedValueSpec {
 	return s.Annotations.Get(name)
 }
 
+// AnnotationEx 获取指定名称的注解，如果注解不存在，返回空注解。并返回注解是否有效的标识；
+func (s ServiceSpec) AnnotationEx(name string) (NamedValueSpec, bool) {
+	return s.Annotations.GetEx(name)
+}
+
 // IsValid 判断服务配置是否有效；
 // 要求： Interface, Method, Protocol 不能为空；
 func (s ServiceSpec) IsValid() bool {
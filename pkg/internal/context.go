@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"time"
 )
@@ -23,6 +24,7 @@ var _ flux.Context = new(Context)
 // Context是一个与请求生命周期相同的临时上下文容器，它在Http请求被接受处理时创建，并在请求被处理完成时销毁回收。
 type Context struct {
 	flux.WebContext
+	context    context.Context
 	endpoint   *flux.EndpointSpec
 	attributes map[string]interface{}
 	metrics    []flux.TraceMetric
@@ -42,6 +44,7 @@ func NewContext() *Context {
 // Note：此函数由内部框架调用，不作为外部使用。
 func (c *Context) Reset(webex flux.WebContext, endpoint *flux.EndpointSpec) {
 	c.WebContext = webex
+	c.context = nil
 	c.endpoint = endpoint
 	c.ctxLogger = zap.S()
 	c.startTime = time.Now()
@@ -51,6 +54,20 @@ func (c *Context) Reset(webex flux.WebContext, endpoint *flux.EndpointSpec) {
 	}
 }
 
+// Context 返回请求域的Context；如已设置请求超时，返回带有截止时间的Context
+func (c *Context) Context() context.Context {
+	if c.context != nil {
+		return c.context
+	}
+	return c.WebContext.Context()
+}
+
+// SetContext 设置请求域的Context，用于绑定请求的截止时间
+// Note：此函数由内部框架调用，不作为外部使用。
+func (c *Context) SetContext(ctx context.Context) {
+	c.context = ctx
+}
+
 // Application 返回当前Endpoint对应的应用名
 func (c *Context) Application() string {
 	return c.endpoint.Application
@@ -119,6 +136,11 @@ func (c *Context) SetAttribute(key string, value interface{}) {
 	c.attributes[key] = value
 }
 
+// RemoveAttribute 删除Context自身的Attribute键值对
+func (c *Context) RemoveAttribute(key string) {
+	delete(c.attributes, key)
+}
+
 // StartAt 返回Http请求起始的服务器时间
 func (c *Context) StartAt() time.Time {
 	return c.startTime
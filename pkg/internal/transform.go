@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+var (
+	// 状态码映射规则：<jsonpath> <op> <value> -> <status>
+	transformStatusPattern = regexp.MustCompile(`^\s*(\$\S*)\s*(==|!=|>=|<=|>|<)\s*(.+?)\s*->\s*(\d{3})\s*$`)
+)
+
+// HasResponseTransform 判断Endpoint是否声明了响应数据转换属性
+func HasResponseTransform(ep *flux.EndpointSpec) bool {
+	for _, attr := range ep.Attributes {
+		if isTransformAttr(attr.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// TransformResponse 根据Endpoint声明的转换属性，转换后端服务的响应数据：
+// 1. 状态码映射规则，基于原始响应数据执行，首个匹配规则生效；
+// 2. 字段选择、重命名、删除、常量设置，按属性声明顺序执行；
+// 响应数据不是JSON结构时，不执行转换。
+func TransformResponse(ctx flux.Context, resp *flux.ServeResponse) error {
+	ep := ctx.Endpoint()
+	if !HasResponseTransform(ep) {
+		return nil
+	}
+	data, err := ext.JSONMarshalObject(resp.Body)
+	if nil != err {
+		return err
+	}
+	var body interface{}
+	if err := ext.JSONUnmarshal(data, &body); nil != err {
+		ctx.Logger().Warnw("TRANSFORM:RESPONSE:NOT_JSON", "error", err)
+		resp.Body = data
+		return nil
+	}
+	if err := mapResponseStatus(ep.MultiAttributes(flux.EndpointAttrTransformStatus), body, resp); nil != err {
+		return err
+	}
+	for _, attr := range ep.Attributes {
+		if !isTransformAttr(attr.Name) || strings.EqualFold(attr.Name, flux.EndpointAttrTransformStatus) {
+			continue
+		}
+		for _, rule := range transformRules(attr) {
+			if body, err = applyTransformRule(strings.ToLower(attr.Name), rule, body); nil != err {
+				return err
+			}
+		}
+	}
+	resp.Body = body
+	return nil
+}
+
+func applyTransformRule(name, rule string, body interface{}) (interface{}, error) {
+	switch name {
+	case flux.EndpointAttrTransformSelect:
+		path, err := toolkit.ParseJSONPath(rule)
+		if nil != err {
+			return nil, err
+		}
+		value, _ := path.Get(body)
+		return value, nil
+
+	case flux.EndpointAttrTransformRemove:
+		path, err := toolkit.ParseJSONPath(rule)
+		if nil != err {
+			return nil, err
+		}
+		if path.IsRoot() {
+			return nil, fmt.Errorf("transform remove: not allow remove root, rule: %s", rule)
+		}
+		body, _ = path.Remove(body)
+		return body, nil
+
+	case flux.EndpointAttrTransformRename:
+		from, to, err := parseTransformPair(rule)
+		if nil != err {
+			return nil, err
+		}
+		dest, err := toolkit.ParseJSONPath(to)
+		if nil != err {
+			return nil, err
+		}
+		if from.IsRoot() || dest.IsRoot() {
+			return nil, fmt.Errorf("transform rename: not allow rename root, rule: %s", rule)
+		}
+		value, ok := from.Get(body)
+		if !ok {
+			return body, nil
+		}
+		body, _ = from.Remove(body)
+		return dest.Set(body, value)
+
+	case flux.EndpointAttrTransformSet:
+		path, literal, err := parseTransformPair(rule)
+		if nil != err {
+			return nil, err
+		}
+		return path.Set(body, parseLiteral(literal))
+
+	default:
+		return body, nil
+	}
+}
+
+func mapResponseStatus(attrs flux.Attributes, body interface{}, resp *flux.ServeResponse) error {
+	for _, attr := range attrs {
+		for _, rule := range transformRules(attr) {
+			status, matched, err := matchStatusRule(rule, body)
+			if nil != err {
+				return err
+			}
+			if matched {
+				resp.StatusCode = status
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func matchStatusRule(rule string, body interface{}) (int, bool, error) {
+	groups := transformStatusPattern.FindStringSubmatch(rule)
+	if groups == nil {
+		return 0, false, fmt.Errorf("transform status: invalid rule: %s", rule)
+	}
+	path, err := toolkit.ParseJSONPath(groups[1])
+	if nil != err {
+		return 0, false, err
+	}
+	status, _ := strconv.Atoi(groups[4])
+	actual, _ := path.Get(body)
+	return status, compareValue(actual, groups[2], parseLiteral(groups[3])), nil
+}
+
+func compareValue(actual interface{}, op string, expected interface{}) bool {
+	af, aerr := cast.ToFloat64E(actual)
+	ef, eerr := cast.ToFloat64E(expected)
+	_, anum := actual.(float64)
+	_, enum := expected.(float64)
+	if anum || enum {
+		if aerr != nil || eerr != nil {
+			// 数值与非数值比较，仅支持不等判定
+			return op == "!="
+		}
+		switch op {
+		case "==":
+			return af == ef
+		case "!=":
+			return af != ef
+		case ">":
+			return af > ef
+		case ">=":
+			return af >= ef
+		case "<":
+			return af < ef
+		case "<=":
+			return af <= ef
+		}
+		return false
+	}
+	if actual == nil || expected == nil {
+		switch op {
+		case "==":
+			return actual == expected
+		case "!=":
+			return actual != expected
+		}
+		return false
+	}
+	as, es := cast.ToString(actual), cast.ToString(expected)
+	switch op {
+	case "==":
+		return as == es
+	case "!=":
+		return as != es
+	case ">":
+		return as > es
+	case ">=":
+		return as >= es
+	case "<":
+		return as < es
+	case "<=":
+		return as <= es
+	}
+	return false
+}
+
+// parseTransformPair 解析 <jsonpath>=<value> 格式的规则
+func parseTransformPair(rule string) (toolkit.JSONPath, string, error) {
+	idx := strings.IndexByte(rule, '=')
+	if idx <= 0 {
+		return nil, "", fmt.Errorf("transform: invalid rule, require <path>=<value>, was: %s", rule)
+	}
+	path, err := toolkit.ParseJSONPath(rule[:idx])
+	if nil != err {
+		return nil, "", err
+	}
+	return path, strings.TrimSpace(rule[idx+1:]), nil
+}
+
+// parseLiteral 解析JSON字面值；非JSON字面值按字符串处理
+func parseLiteral(literal string) interface{} {
+	var value interface{}
+	if err := ext.JSONUnmarshal([]byte(literal), &value); nil == err {
+		return value
+	}
+	return literal
+}
+
+// transformRules 读取属性的规则列表；字符串值作为单个规则，不按空白字符拆分
+func transformRules(attr flux.NamedValueSpec) []string {
+	if rule, ok := attr.Value.(string); ok {
+		return []string{rule}
+	}
+	return cast.ToStringSlice(attr.Value)
+}
+
+func isTransformAttr(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "flux.go/transform.")
+}
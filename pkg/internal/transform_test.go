@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/stretchr/testify/assert"
+)
+
+type stdJSONSerializer struct{}
+
+func (stdJSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONSerializer) Unmarshal(d []byte, v interface{}) error {
+	return json.Unmarshal(d, v)
+}
+
+func transformTest(t *testing.T, body string, attrs flux.Attributes) *flux.ServeResponse {
+	ext.RegisterSerializer(ext.TypeNameSerializerJson, stdJSONSerializer{})
+	ctx := NewContext()
+	ctx.Reset(nil, &flux.EndpointSpec{Attributes: attrs})
+	resp := flux.NewServeResponse(flux.StatusOK, []byte(body))
+	if err := TransformResponse(ctx, resp); nil != err {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestTransformResponseEnvelope(t *testing.T) {
+	tester := assert.New(t)
+	resp := transformTest(t, `{"code":0,"msg":"ok","data":{"user_name":"yongjia","password":"x","tags":[{"id":1,"secret":"a"}]}}`, flux.Attributes{
+		{Name: flux.EndpointAttrTransformStatus, Value: "$.code != 0 -> 502"},
+		{Name: flux.EndpointAttrTransformSelect, Value: "$.data"},
+		{Name: flux.EndpointAttrTransformRemove, Value: []string{"$.password", "$.tags[*].secret"}},
+		{Name: flux.EndpointAttrTransformRename, Value: "$.user_name=$.userName"},
+		{Name: flux.EndpointAttrTransformSet, Value: `$.meta.source="gateway"`},
+	})
+	tester.Equal(flux.StatusOK, resp.StatusCode)
+	tester.Equal(map[string]interface{}{
+		"userName": "yongjia",
+		"tags":     []interface{}{map[string]interface{}{"id": float64(1)}},
+		"meta":     map[string]interface{}{"source": "gateway"},
+	}, resp.Body)
+}
+
+func TestTransformResponseStatus(t *testing.T) {
+	tester := assert.New(t)
+	rules := flux.Attributes{
+		{Name: flux.EndpointAttrTransformStatus, Value: []string{`$.code == "NOT_FOUND" -> 404`, "$.code != 0 -> 502"}},
+	}
+	tester.Equal(502, transformTest(t, `{"code":500}`, rules).StatusCode)
+	tester.Equal(404, transformTest(t, `{"code":"NOT_FOUND"}`, rules).StatusCode)
+	tester.Equal(200, transformTest(t, `{"code":0}`, rules).StatusCode)
+	// 非JSON数据不转换
+	resp := transformTest(t, `plain text`, rules)
+	tester.Equal(200, resp.StatusCode)
+	tester.Equal([]byte("plain text"), resp.Body)
+}
+
+func TestTransformResponseInvalidRule(t *testing.T) {
+	ext.RegisterSerializer(ext.TypeNameSerializerJson, stdJSONSerializer{})
+	ctx := NewContext()
+	ctx.Reset(nil, &flux.EndpointSpec{Attributes: flux.Attributes{
+		{Name: flux.EndpointAttrTransformStatus, Value: "code -> 502"},
+	}})
+	assert.Error(t, TransformResponse(ctx, flux.NewServeResponse(flux.StatusOK, []byte(`{}`))))
+}
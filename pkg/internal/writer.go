@@ -30,6 +30,12 @@ func (r *JSONServeResponseWriter) Write(ctx flux.Context, response *flux.ServeRe
 }
 
 func (r *JSONServeResponseWriter) WriteError(ctx flux.Context, err *flux.ServeError) {
+	header := ctx.ResponseWriter().Header()
+	for k, hv := range err.Header {
+		for _, v := range hv {
+			header.Add(k, v)
+		}
+	}
 	bytes, _ := ext.JSONMarshalObject(map[string]interface{}{
 		"status":  "error",
 		"code":    err.ErrorCode,
@@ -1,29 +1,125 @@
 package listener
 
 import (
-	"github.com/bytepowered/fluxgo/pkg/flux"
-	"github.com/bytepowered/fluxgo/pkg/logger"
+	"bytes"
+	"io/ioutil"
 	"time"
 )
 
+import (
+	"github.com/bytepowered/fluxgo/pkg/accesslog"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/spf13/cast"
+)
+
+// NewAccessLogFilter 创建默认配置的访问日志Filter：JSON格式，输出到系统日志组件
 func NewAccessLogFilter() flux.WebFilter {
+	formatter, _ := accesslog.NewJSONFormatter(accesslog.DefaultFields)
+	return NewAccessLogFilterWith(accesslog.NewAccessLogger(formatter, accesslog.NewLoggerSink()))
+}
+
+// NewAccessLogFilterWith 使用指定的访问日志组件创建Filter；
+// Filter在请求结束后记录访问日志，Endpoint相关字段由Dispatcher在路由时填充。
+func NewAccessLogFilterWith(al *accesslog.AccessLogger) flux.WebFilter {
 	return func(next flux.WebHandlerFunc) flux.WebHandlerFunc {
 		return func(webc flux.WebContext) error {
-			multiWriter := NewEmptyHttpResponseMultiWriter(webc.ResponseWriter())
-			webc.SetResponseWriter(multiWriter)
-			defer func(trace flux.Logger, start time.Time) {
-				trace.Infow("SERVER:TRAFFIC:LOG",
-					"listener-id", webc.WebListener().ListenerId(),
-					"remote-ip", webc.RemoteAddr(),
-					"request.uri", webc.URI(),
-					"request.method", webc.Method(),
-					"request.header", webc.HeaderVars(),
-					"response.header", webc.ResponseWriter().Header(),
-					"response.status", multiWriter.StatusCode(),
-					"latency", time.Since(start).String(),
-				)
-			}(logger.Trace(webc.RequestId()), time.Now())
-			return next(webc)
+			request := webc.Request()
+			record := &accesslog.Record{
+				Time:        time.Now(),
+				RequestId:   webc.RequestId(),
+				RemoteAddr:  webc.RemoteAddr(),
+				Method:      webc.Method(),
+				URI:         webc.URI(),
+				Proto:       request.Proto,
+				Host:        request.Host,
+				Referer:     request.Referer(),
+				UserAgent:   request.UserAgent(),
+				RequestSize: request.ContentLength,
+			}
+			if webl := webc.WebListener(); webl != nil {
+				record.ListenerId = webl.ListenerId()
+			}
+			captureReq, captureResp := al.CaptureBody()
+			if captureReq || record.RequestSize < 0 {
+				body := requestBodyOf(webc)
+				record.RequestSize = int64(len(body))
+				if captureReq {
+					record.RequestBody = al.MaskBody(body)
+				}
+			}
+			if captureReqH, _ := al.CaptureHeader(); captureReqH {
+				record.RequestHeader = al.MaskHeader(request.Header)
+			}
+			webc.SetVariable(accesslog.VarKeyRecord, record)
+			counter := &responseCounter{capture: captureResp, limit: al.BodyLimit()}
+			writer := NewHttpMultiResponseWriter(counter, webc.ResponseWriter())
+			webc.SetResponseWriter(writer)
+			err := next(webc)
+			webc.SetResponseWriter(writer.ResponseWriter)
+			record.Latency = time.Since(record.Time)
+			record.Status, record.ResponseSize = writer.StatusCode(), counter.size
+			if record.Status == 0 && counter.size > 0 {
+				record.Status = flux.StatusOK
+			}
+			if !flux.IsNil(err) {
+				// 错误由WebListener的错误处理函数写入响应
+				record.Status = flux.StatusServerError
+				if serr, ok := err.(*flux.ServeError); ok {
+					record.Status = serr.StatusCode
+					if record.ErrorCode == "" {
+						record.ErrorCode = cast.ToString(serr.ErrorCode)
+					}
+				}
+			}
+			if !al.Sampled(record.Status) {
+				return err
+			}
+			if _, captureRespH := al.CaptureHeader(); captureRespH {
+				record.ResponseHeader = al.MaskHeader(writer.Header())
+			}
+			if captureResp {
+				record.ResponseBody = al.MaskBody(counter.buffer.Bytes())
+			}
+			al.Log(record)
+			return err
+		}
+	}
+}
+
+func requestBodyOf(webc flux.WebContext) []byte {
+	request := webc.Request()
+	if request.GetBody == nil {
+		return nil
+	}
+	reader, err := request.GetBody()
+	if nil != err {
+		return nil
+	}
+	defer reader.Close()
+	data, _ := ioutil.ReadAll(reader)
+	return data
+}
+
+// responseCounter 统计响应数据长度，并按需复制不超过limit长度的响应数据
+type responseCounter struct {
+	capture bool
+	limit   int
+	size    int64
+	buffer  bytes.Buffer
+}
+
+func (c *responseCounter) Write(b []byte) (int, error) {
+	n := len(b)
+	c.size += int64(n)
+	if c.capture {
+		if remain := c.limit - c.buffer.Len(); c.limit <= 0 {
+			c.buffer.Write(b)
+		} else if remain > 0 {
+			if len(b) > remain {
+				b = b[:remain]
+			}
+			c.buffer.Write(b)
 		}
 	}
+	return n, nil
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 import (
@@ -27,10 +28,12 @@ func NewWebContext(ctx echo.Context, reqid string, listener flux.WebListener) fl
 }
 
 type AdaptWebContext struct {
-	listener  flux.WebListener
-	context   context.Context
-	echoc     echo.Context
-	variables map[interface{}]interface{}
+	listener   flux.WebListener
+	context    context.Context
+	echoc      echo.Context
+	variables  map[interface{}]interface{}
+	remoteAddr string // 经可信代理解析的客户端地址
+	trusted    bool   // 直连地址属于可信代理
 }
 
 func (w *AdaptWebContext) WebListener() flux.WebListener {
@@ -70,9 +73,16 @@ func (w *AdaptWebContext) Host() string {
 }
 
 func (w *AdaptWebContext) RemoteAddr() string {
+	if "" != w.remoteAddr {
+		return w.remoteAddr
+	}
 	return w.Request().RemoteAddr
 }
 
+func (w *AdaptWebContext) TrustedProxy() bool {
+	return w.trusted
+}
+
 func (w *AdaptWebContext) HeaderVars() http.Header {
 	return w.Request().Header
 }
@@ -146,11 +156,19 @@ func (w *AdaptWebContext) Write(statusCode int, contentType string, data []byte)
 	return w.WriteStream(statusCode, contentType, bytes.NewReader(data))
 }
 
+// WriteStream 写入响应流；SSE(text/event-stream)类型的响应，每次写入数据后立即Flush到客户端
 func (w *AdaptWebContext) WriteStream(statusCode int, contentType string, reader io.Reader) error {
 	writer := w.echoc.Response()
 	writer.Header().Set(echo.HeaderContentType, contentType)
 	writer.WriteHeader(statusCode)
-	if _, err := io.Copy(writer, reader); nil != err {
+	var err error
+	if flusher, ok := writer.Writer.(http.Flusher); ok && strings.HasPrefix(contentType, flux.MIMETextEventStream) {
+		flusher.Flush()
+		_, err = io.Copy(&flushWriter{writer: writer, flusher: flusher}, reader)
+	} else {
+		_, err = io.Copy(writer, reader)
+	}
+	if nil != err {
 		return fmt.Errorf("web context write failed, error: %w", err)
 	}
 	return nil
@@ -183,3 +201,16 @@ func (w *AdaptWebContext) GetVariable(key string) (interface{}, bool) {
 	v = w.echoc.Get(key)
 	return v, nil != v
 }
+
+type flushWriter struct {
+	writer  io.Writer
+	flusher http.Flusher
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if n > 0 {
+		w.flusher.Flush()
+	}
+	return n, err
+}
@@ -61,7 +61,7 @@ func WithHandlers(tuples []WebHandlerTuple) Option {
 func WithHandler(tuples ...WebHandlerTuple) Option {
 	return func(server flux.WebListener) {
 		for _, h := range tuples {
-			server.AddHandler(h.Method, h.Pattern, h.Handler)
+			server.AddHandler(h.Method, h.Pattern, h.Handler, h.Filters...)
 		}
 	}
 }
@@ -70,6 +70,8 @@ type WebHandlerTuple struct {
 	Method  string
 	Pattern string
 	Handler flux.WebHandlerFunc
+	// 仅作用于此Handler的Filter
+	Filters []flux.WebFilter
 }
 
 func WithHttpHandlers(tuples []HttpHandlerTuple) Option {
@@ -3,16 +3,22 @@ package listener
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 import (
+	"github.com/bytepowered/fluxgo/pkg/accesslog"
 	"github.com/bytepowered/fluxgo/pkg/ext"
 	"github.com/bytepowered/fluxgo/pkg/flux"
 	"github.com/bytepowered/fluxgo/pkg/internal"
 	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/bytepowered/fluxgo/pkg/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -22,6 +28,9 @@ const (
 	ConfigKeyBindPort    = "bind_port"
 	ConfigKeyTLSCertFile = "tls_cert_file"
 	ConfigKeyTLSKeyFile  = "tls_key_file"
+	ConfigKeyAccessLog   = "access_log"
+	// 可信代理地址列表(CIDR)；直连地址属于可信代理时，从 X-Forwarded-For/X-Real-IP 解析客户端IP
+	ConfigKeyTrustedProxies = "trusted_proxies"
 )
 
 const (
@@ -52,6 +61,17 @@ func NewAdaptWebListenerWith(listenerId string, options *flux.Configuration, ide
 		id:           listenerId,
 		server:       server,
 		bodyResolver: DefaultRequestBodyResolver,
+		routes:       make(map[string]route, 16),
+	}
+	// 动态路由：Echo路由不支持删除，由独立的路由表查找处理函数，路由变更时重建路由表
+	webListener.router.Store(echo.NewRouter(server))
+	server.Any("/*", webListener.dispatch)
+	trusted, err := toolkit.ParseCIDRs(options.GetStringSlice(ConfigKeyTrustedProxies))
+	flux.AssertM(err == nil, func() string {
+		return fmt.Sprintf("parse trusted proxies, listener-id: %s, error: %s", listenerId, err)
+	})
+	if len(trusted) > 0 {
+		logger.Infof("WebListener(id:%s), trusted proxies: %s", listenerId, trusted)
 	}
 	// Init context
 	server.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -59,6 +79,16 @@ func NewAdaptWebListenerWith(listenerId string, options *flux.Configuration, ide
 			id := identifier(echoc)
 			flux.Assert("" != id, "<request-id> is empty, return by id lookup func")
 			swc := NewWebContext(echoc, id, webListener)
+			if len(trusted) > 0 {
+				swc.(*AdaptWebContext).remoteAddr = toolkit.ResolveClientIP(echoc.Request().RemoteAddr, echoc.Request().Header, trusted)
+				swc.(*AdaptWebContext).trusted = toolkit.ContainsIP(trusted, toolkit.ParseHostIP(echoc.Request().RemoteAddr))
+			}
+			// Tracing: 创建请求的Server Span，并绑定到请求Context
+			spanctx, span := tracing.StartServerSpan(swc)
+			swc.(*AdaptWebContext).context = spanctx
+			defer func() {
+				tracing.EndServerSpan(span, echoc.Response().Status)
+			}()
 			// Note: 不要修改echo.context.request对象引用，echo路由绑定了函数入口的request对象，
 			// 从而导致后续基于request修改路由时，会导致Http路由失败。
 			flux.AssertNil(echoc.Get(string(internal.CtxkeyWebContext)), "<web-context> must be nil")
@@ -101,9 +131,15 @@ func NewAdaptWebListenerWith(listenerId string, options *flux.Configuration, ide
 		server.Pre(middleware.CSRF())
 	}
 	// 流量日志
-	if enabled := features.GetBool(ConfigKeyFeatureTrafficEnable); enabled {
+	accessLog := options.Sub(ConfigKeyAccessLog)
+	if enabled := features.GetBool(ConfigKeyFeatureTrafficEnable) || accessLog.GetBool(accesslog.ConfigKeyEnable); enabled {
 		logger.Infof("WebListener(id:%s), feature TRAFFIC: enabled", webListener.id)
-		webListener.AddFilter(NewAccessLogFilter())
+		al, err := accesslog.NewAccessLoggerWith(accessLog)
+		flux.AssertM(err == nil, func() string {
+			return fmt.Sprintf("init access log, listener-id: %s, error: %s", webListener.id, err)
+		})
+		webListener.accessLogger = al
+		webListener.AddFilter(NewAccessLogFilterWith(al))
 	}
 	// After features
 	if mws != nil && len(mws.AfterFeature) > 0 {
@@ -122,6 +158,17 @@ type AdaptWebListener struct {
 	tlsKeyFile   string
 	address      string
 	started      bool
+	router       atomic.Value     // 当前路由表：*echo.Router
+	routes       map[string]route // 已注册的路由
+	routesMu     sync.Mutex
+	dirty        int32    // 路由表是否需要重建
+	handlers     sync.Map // 路由当前绑定的处理函数
+	accessLogger *accesslog.AccessLogger
+}
+
+type route struct {
+	method string
+	path   string
 }
 
 func (s *AdaptWebListener) ListenerId() string {
@@ -174,7 +221,7 @@ func (s *AdaptWebListener) SetErrorHandler(handler flux.WebErrorHandlerFunc) {
 		}
 		webex, ok := c.Get(string(internal.CtxkeyWebContext)).(flux.WebContext)
 		flux.Assert(ok, "<web-context> is invalid in http-error-handler")
-		handler(webex, err)
+		handler(webex, toServeError(err))
 	}
 }
 
@@ -199,7 +246,7 @@ func (s *AdaptWebListener) AddHandler(method, pattern string, h flux.WebHandlerF
 	for i, mi := range is {
 		wms[i] = AdaptWebFilter(mi).AdaptFunc
 	}
-	s.server.Add(strings.ToUpper(method), s.resolve(pattern), AdaptWebHandler(h).AdaptFunc, wms...)
+	s.route(method, pattern, AdaptWebHandler(h).AdaptFunc, wms)
 }
 
 func (s *AdaptWebListener) AddHttpHandler(method, pattern string, h http.Handler, m ...func(http.Handler) http.Handler) {
@@ -210,7 +257,20 @@ func (s *AdaptWebListener) AddHttpHandler(method, pattern string, h http.Handler
 	for i, mf := range m {
 		wms[i] = echo.WrapMiddleware(mf)
 	}
-	s.server.Add(strings.ToUpper(method), s.resolve(pattern), echo.WrapHandler(h), wms...)
+	s.route(method, pattern, echo.WrapHandler(h), wms)
+}
+
+func (s *AdaptWebListener) RemoveHandler(method, pattern string) {
+	flux.AssertNotEmpty(method, "<http-method> must not empty")
+	flux.AssertNotEmpty(pattern, "<http-pattern> must not empty")
+	key := s.routeKey(method, pattern)
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	s.handlers.Delete(key)
+	if _, ok := s.routes[key]; ok {
+		delete(s.routes, key)
+		s.rebuild()
+	}
 }
 
 func (s *AdaptWebListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -227,7 +287,13 @@ func (s *AdaptWebListener) ShadowServer() interface{} {
 
 func (s *AdaptWebListener) OnShutdown(ctx context.Context) error {
 	s.started = false
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+	if s.accessLogger != nil {
+		if cerr := s.accessLogger.Close(); nil != cerr {
+			logger.Warnw("SERVER:EVENT:WEBLISTENER:ACCESSLOG:CLOSE", "listener-id", s.id, "error", cerr)
+		}
+	}
+	return err
 }
 
 func (s *AdaptWebListener) mustNotStarted() *AdaptWebListener {
@@ -245,6 +311,83 @@ func (s *AdaptWebListener) resolve(pattern string) string {
 	}
 }
 
+// route 注册路由处理函数；路由表中的转发函数查找当前绑定的处理函数，
+// 更新已存在路由的处理函数时不需要重建路由表。新增路由仅标记路由表待重建，
+// 批量注册时由下一次请求分发合并重建一次。
+func (s *AdaptWebListener) route(method, pattern string, h echo.HandlerFunc, wms []echo.MiddlewareFunc) {
+	for i := len(wms) - 1; i >= 0; i-- {
+		h = wms[i](h)
+	}
+	key := s.routeKey(method, pattern)
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	s.handlers.Store(key, h)
+	if _, ok := s.routes[key]; !ok {
+		s.routes[key] = route{method: strings.ToUpper(method), path: s.resolve(pattern)}
+		atomic.StoreInt32(&s.dirty, 1)
+	}
+}
+
+// rebuild 以已注册的路由重建路由表，并替换当前路由表；删除的路由不再参与匹配，
+// 避免已删除的静态路由遮蔽参数路由。调用方需持有 routesMu 锁。
+func (s *AdaptWebListener) rebuild() {
+	router := echo.NewRouter(s.server)
+	for key, r := range s.routes {
+		key := key
+		router.Add(r.method, r.path, func(echoc echo.Context) error {
+			if h, ok := s.handlers.Load(key); ok {
+				return h.(echo.HandlerFunc)(echoc)
+			}
+			return s.server.NotFoundHandler(echoc)
+		})
+	}
+	s.router.Store(router)
+	atomic.StoreInt32(&s.dirty, 0)
+}
+
+// current 返回当前路由表；存在待重建的路由变更时，先重建路由表
+func (s *AdaptWebListener) current() *echo.Router {
+	if atomic.LoadInt32(&s.dirty) == 1 {
+		s.routesMu.Lock()
+		if atomic.LoadInt32(&s.dirty) == 1 {
+			s.rebuild()
+		}
+		s.routesMu.Unlock()
+	}
+	return s.router.Load().(*echo.Router)
+}
+
+// dispatch 在当前路由表中查找并执行处理函数；路径匹配但Method不匹配时，
+// 路由表绑定的处理函数返回 echo.ErrMethodNotAllowed 错误。
+func (s *AdaptWebListener) dispatch(echoc echo.Context) error {
+	echoc.SetHandler(s.server.NotFoundHandler)
+	request := echoc.Request()
+	s.current().Find(request.Method, echo.GetPath(request), echoc)
+	return echoc.Handler()(echoc)
+}
+
+// toServeError 将Echo框架返回的Http错误(405/413等)转换为相同状态码的ServeError
+func toServeError(err error) error {
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		return err
+	}
+	code := flux.ErrorCodeRequestInvalid
+	if he.Code >= http.StatusInternalServerError {
+		code = flux.ErrorCodeGatewayInternal
+	}
+	return &flux.ServeError{
+		StatusCode: he.Code,
+		ErrorCode:  code,
+		Message:    fmt.Sprintf("%v", he.Message),
+		CauseError: he,
+	}
+}
+
+func (s *AdaptWebListener) routeKey(method, pattern string) string {
+	return strings.ToUpper(method) + "#" + s.resolve(pattern)
+}
+
 type AdaptMiddleware struct {
 	BeforeFeature []echo.MiddlewareFunc
 	AfterFeature  []echo.MiddlewareFunc
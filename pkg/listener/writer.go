@@ -34,7 +34,11 @@ func (w *HttpResponseMultiWriter) Flush() {
 	w.ResponseWriter.(http.Flusher).Flush()
 }
 
+// Hijack 接管连接；接管后的响应状态码记录为101
 func (w *HttpResponseMultiWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusSwitchingProtocols
+	}
 	return w.ResponseWriter.(http.Hijacker).Hijack()
 }
 
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+)
+
+// LocalRateLimiter 基于本地内存的限流计数器，支持令牌桶和滑动窗口算法
+type LocalRateLimiter struct {
+	counters  map[string]*localCounter
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+type localCounter struct {
+	// token bucket
+	tokens float64
+	// sliding window
+	start       time.Time
+	prev, curr  int
+	last        time.Time
+	idleTimeout time.Duration
+}
+
+func NewLocalRateLimiter() *LocalRateLimiter {
+	return &LocalRateLimiter{
+		counters:  make(map[string]*localCounter, 64),
+		lastSweep: time.Now(),
+	}
+}
+
+// Acquire 对指定Key申请一个请求许可
+func (l *LocalRateLimiter) Acquire(key, algorithm string, limit int, window time.Duration, now time.Time) flux.RateLimitResult {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.sweep(now)
+	c, ok := l.counters[key]
+	if !ok {
+		c = &localCounter{idleTimeout: window * 2}
+		l.counters[key] = c
+	}
+	if algorithm == flux.RateLimitAlgorithmSlidingWindow {
+		return c.slidingWindow(limit, window, now)
+	}
+	return c.tokenBucket(limit, window, now)
+}
+
+// sweep 定期清理长时间未使用的计数器
+func (l *LocalRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for k, c := range l.counters {
+		if now.Sub(c.last) > c.idleTimeout {
+			delete(l.counters, k)
+		}
+	}
+}
+
+func (c *localCounter) tokenBucket(limit int, window time.Duration, now time.Time) flux.RateLimitResult {
+	rate := float64(limit) / window.Seconds()
+	if c.last.IsZero() {
+		c.tokens = float64(limit)
+	} else {
+		c.tokens = math.Min(float64(limit), c.tokens+now.Sub(c.last).Seconds()*rate)
+	}
+	c.last = now
+	allowed := c.tokens >= 1
+	if allowed {
+		c.tokens--
+	}
+	return TokenBucketResult(allowed, c.tokens, limit, window)
+}
+
+func (c *localCounter) slidingWindow(limit int, window time.Duration, now time.Time) flux.RateLimitResult {
+	if c.start.IsZero() {
+		c.start = now.Truncate(window)
+	}
+	if elapsed := now.Sub(c.start); elapsed >= 2*window {
+		c.prev, c.curr, c.start = 0, 0, now.Truncate(window)
+	} else if elapsed >= window {
+		c.prev, c.curr, c.start = c.curr, 0, c.start.Add(window)
+	}
+	c.last = now
+	result := SlidingWindowResult(c.prev, c.curr, limit, window, now.Sub(c.start))
+	if result.Allowed {
+		c.curr++
+	}
+	return result
+}
+
+// TokenBucketResult 根据令牌桶申请后的剩余令牌数，计算许可结果
+func TokenBucketResult(allowed bool, tokens float64, limit int, window time.Duration) flux.RateLimitResult {
+	rate := float64(limit) / window.Seconds()
+	result := flux.RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  int(tokens),
+		ResetAfter: secondsOf((float64(limit) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsOf((1 - tokens) / rate)
+	}
+	return result
+}
+
+// SlidingWindowResult 根据前一窗口与当前窗口（计数前）的计数，按加权滑动窗口计算许可结果
+func SlidingWindowResult(prev, curr, limit int, window, elapsed time.Duration) flux.RateLimitResult {
+	weight := 1 - float64(elapsed)/float64(window)
+	count := float64(prev)*weight + float64(curr)
+	result := flux.RateLimitResult{Limit: limit, ResetAfter: window - elapsed}
+	if count+1 <= float64(limit) {
+		result.Allowed = true
+		result.Remaining = int(math.Max(0, float64(limit)-count-1))
+		return result
+	}
+	// 计算计数降低到限额以下所需的时间
+	if curr < limit && prev > 0 {
+		ratio := 1 - float64(limit-curr-1)/float64(prev)
+		result.RetryAfter = time.Duration(ratio*float64(window)) - elapsed
+	} else {
+		result.RetryAfter = window - elapsed
+		if curr > 0 {
+			result.RetryAfter += time.Duration(math.Max(0, 1-float64(limit-1)/float64(curr)) * float64(window))
+		}
+	}
+	if result.RetryAfter <= 0 {
+		result.RetryAfter = time.Millisecond
+	}
+	return result
+}
+
+func secondsOf(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second))
+}
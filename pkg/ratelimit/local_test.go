@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalRateLimiterTokenBucket(t *testing.T) {
+	tester := assert.New(t)
+	limiter := NewLocalRateLimiter()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		tester.True(limiter.Acquire("k", flux.RateLimitAlgorithmTokenBucket, 3, time.Second, now).Allowed)
+	}
+	result := limiter.Acquire("k", flux.RateLimitAlgorithmTokenBucket, 3, time.Second, now)
+	tester.False(result.Allowed)
+	tester.Equal(0, result.Remaining)
+	tester.InDelta(float64(time.Second/3), float64(result.RetryAfter), float64(time.Millisecond))
+	// refill one token
+	tester.True(limiter.Acquire("k", flux.RateLimitAlgorithmTokenBucket, 3, time.Second, now.Add(time.Second/3+time.Millisecond)).Allowed)
+	// other key
+	tester.True(limiter.Acquire("k2", flux.RateLimitAlgorithmTokenBucket, 3, time.Second, now).Allowed)
+}
+
+func TestLocalRateLimiterSlidingWindow(t *testing.T) {
+	tester := assert.New(t)
+	limiter := NewLocalRateLimiter()
+	start := time.Now().Truncate(time.Second)
+	for i := 0; i < 4; i++ {
+		tester.True(limiter.Acquire("k", flux.RateLimitAlgorithmSlidingWindow, 4, time.Second, start).Allowed)
+	}
+	result := limiter.Acquire("k", flux.RateLimitAlgorithmSlidingWindow, 4, time.Second, start.Add(time.Second/2))
+	tester.False(result.Allowed)
+	tester.True(result.RetryAfter > 0)
+	// half of previous window weighted: 4*0.5=2
+	next := start.Add(time.Second + time.Second/2)
+	tester.True(limiter.Acquire("k", flux.RateLimitAlgorithmSlidingWindow, 4, time.Second, next).Allowed)
+	tester.True(limiter.Acquire("k", flux.RateLimitAlgorithmSlidingWindow, 4, time.Second, next).Allowed)
+	tester.False(limiter.Acquire("k", flux.RateLimitAlgorithmSlidingWindow, 4, time.Second, next).Allowed)
+}
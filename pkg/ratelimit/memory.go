@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+)
+
+const (
+	MemoryStoreId = "memory"
+	RedisStoreId  = "redis"
+)
+
+func init() {
+	ext.RegisterRateLimitStore(MemoryStoreId, NewMemoryRateLimitStore())
+	ext.RegisterRateLimitStore(RedisStoreId, NewRedisRateLimitStore())
+}
+
+var _ flux.RateLimitStore = new(MemoryRateLimitStore)
+
+// MemoryRateLimitStore 基于本地内存的限流计数存储；限流计数仅在当前网关实例内有效
+type MemoryRateLimitStore struct {
+	limiter *LocalRateLimiter
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		limiter: NewLocalRateLimiter(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Acquire(_ context.Context, key, algorithm string, limit int, window time.Duration) (flux.RateLimitResult, error) {
+	return s.limiter.Acquire(key, algorithm, limit, window, time.Now()), nil
+}
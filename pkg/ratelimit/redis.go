@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/go-redis/redis"
+	"github.com/spf13/cast"
+)
+
+const (
+	ConfigKeyRedisAddress      = "address"
+	ConfigKeyRedisPassword     = "password"
+	ConfigKeyRedisDatabase     = "database"
+	ConfigKeyRedisPoolSize     = "pool_size"
+	ConfigKeyRedisDialTimeout  = "dial_timeout"
+	ConfigKeyRedisReadTimeout  = "read_timeout"
+	ConfigKeyRedisWriteTimeout = "write_timeout"
+	ConfigKeyRedisKeyPrefix    = "key_prefix"
+)
+
+// 当前时间以Redis服务端时间为准，避免多个网关实例的本地时钟偏差；
+// 脚本包含非确定性命令TIME，按命令而非脚本复制到从节点。
+const redisNowMsScript = `
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+`
+
+// 令牌桶：ARGV = limit, window(ms)；返回 {allowed, tokens}
+var tokenBucketScript = redis.NewScript(redisNowMsScript + `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = limit
+	ts = now
+end
+if now > ts then
+	tokens = math.min(limit, tokens + (now - ts) * limit / window)
+	ts = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {allowed, tostring(tokens)}
+`)
+
+// 滑动窗口：ARGV = limit, window(ms)；返回 {allowed, prev, curr(计数前), elapsed(ms)}
+var slidingWindowScript = redis.NewScript(redisNowMsScript + `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local data = redis.call('HMGET', KEYS[1], 'start', 'prev', 'curr')
+local aligned = now - (now % window)
+local start = tonumber(data[1]) or aligned
+local prev = tonumber(data[2]) or 0
+local curr = tonumber(data[3]) or 0
+if now - start >= 2 * window then
+	prev, curr, start = 0, 0, aligned
+elseif now - start >= window then
+	prev, curr, start = curr, 0, start + window
+end
+local elapsed = now - start
+local count = prev * (1 - elapsed / window) + curr
+local allowed = 0
+if count + 1 <= limit then
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'start', start, 'prev', prev, 'curr', curr + allowed)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {allowed, prev, curr, elapsed}
+`)
+
+var _ flux.RateLimitStore = new(RedisRateLimitStore)
+
+// RedisRateLimitStore 基于Redis的限流计数存储；多个网关实例共享限流计数，实现全局限流
+type RedisRateLimitStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRateLimitStore() *RedisRateLimitStore {
+	return &RedisRateLimitStore{}
+}
+
+func (s *RedisRateLimitStore) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyRedisAddress:      "127.0.0.1:6379",
+		ConfigKeyRedisDatabase:     0,
+		ConfigKeyRedisPoolSize:     16,
+		ConfigKeyRedisDialTimeout:  "1s",
+		ConfigKeyRedisReadTimeout:  "200ms",
+		ConfigKeyRedisWriteTimeout: "200ms",
+		ConfigKeyRedisKeyPrefix:    "fluxgo:ratelimit:",
+	})
+	s.prefix = config.GetString(ConfigKeyRedisKeyPrefix)
+	s.client = redis.NewClient(&redis.Options{
+		Addr:         config.GetString(ConfigKeyRedisAddress),
+		Password:     config.GetString(ConfigKeyRedisPassword),
+		DB:           config.GetInt(ConfigKeyRedisDatabase),
+		PoolSize:     config.GetInt(ConfigKeyRedisPoolSize),
+		DialTimeout:  config.GetDuration(ConfigKeyRedisDialTimeout),
+		ReadTimeout:  config.GetDuration(ConfigKeyRedisReadTimeout),
+		WriteTimeout: config.GetDuration(ConfigKeyRedisWriteTimeout),
+	})
+	logger.Infow("RATELIMIT:STORE:REDIS:INIT", "address", config.GetString(ConfigKeyRedisAddress),
+		"database", config.GetInt(ConfigKeyRedisDatabase), "key-prefix", s.prefix)
+	return nil
+}
+
+func (s *RedisRateLimitStore) OnShutdown(_ context.Context) error {
+	if s.client != nil {
+		return s.client.Close()
+	}
+	return nil
+}
+
+func (s *RedisRateLimitStore) Acquire(ctx context.Context, key, algorithm string, limit int, window time.Duration) (flux.RateLimitResult, error) {
+	if s.client == nil {
+		return flux.RateLimitResult{}, fmt.Errorf("redis rate limit store not initialized")
+	}
+	client := s.client.WithContext(ctx)
+	windowMs := window.Milliseconds()
+	if algorithm == flux.RateLimitAlgorithmSlidingWindow {
+		values, err := runScript(client, slidingWindowScript, s.prefix+key, 4, limit, windowMs)
+		if nil != err {
+			return flux.RateLimitResult{}, err
+		}
+		prev, curr, elapsed := cast.ToInt(values[1]), cast.ToInt(values[2]), cast.ToInt64(values[3])
+		result := SlidingWindowResult(prev, curr, limit, window, time.Duration(elapsed)*time.Millisecond)
+		// 以存储端的计数结果为准
+		result.Allowed = cast.ToInt(values[0]) == 1
+		return result, nil
+	}
+	values, err := runScript(client, tokenBucketScript, s.prefix+key, 2, limit, windowMs)
+	if nil != err {
+		return flux.RateLimitResult{}, err
+	}
+	return TokenBucketResult(cast.ToInt(values[0]) == 1, cast.ToFloat64(values[1]), limit, window), nil
+}
+
+func runScript(client *redis.Client, script *redis.Script, key string, size int, args ...interface{}) ([]interface{}, error) {
+	ret, err := script.Run(client, []string{key}, args...).Result()
+	if nil != err {
+		return nil, err
+	}
+	values, ok := ret.([]interface{})
+	if !ok || len(values) < size {
+		return nil, fmt.Errorf("redis rate limit script returns unexpected result: %v", ret)
+	}
+	return values, nil
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisRateLimitStore, *miniredis.Miniredis) {
+	server, err := miniredis.Run()
+	if nil != err {
+		t.Fatal(err)
+	}
+	store := NewRedisRateLimitStore()
+	if err := store.OnInit(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyRedisAddress: server.Addr(),
+	})); nil != err {
+		t.Fatal(err)
+	}
+	return store, server
+}
+
+func TestRedisStoreTokenBucket(t *testing.T) {
+	tester := assert.New(t)
+	store, server := newTestRedisStore(t)
+	defer server.Close()
+	defer store.OnShutdown(context.Background())
+	for i := 0; i < 3; i++ {
+		result, err := store.Acquire(context.Background(), "k", flux.RateLimitAlgorithmTokenBucket, 3, time.Minute)
+		tester.NoError(err)
+		tester.True(result.Allowed)
+		tester.Equal(2-i, result.Remaining)
+	}
+	result, err := store.Acquire(context.Background(), "k", flux.RateLimitAlgorithmTokenBucket, 3, time.Minute)
+	tester.NoError(err)
+	tester.False(result.Allowed)
+	tester.True(result.RetryAfter > 0)
+	tester.True(server.Exists("fluxgo:ratelimit:k"))
+}
+
+func TestRedisStoreServerTime(t *testing.T) {
+	tester := assert.New(t)
+	store, server := newTestRedisStore(t)
+	defer server.Close()
+	defer store.OnShutdown(context.Background())
+	// 以Redis服务端时间计算令牌补充，与网关本地时间无关
+	now := time.Now()
+	for _, algorithm := range []string{flux.RateLimitAlgorithmTokenBucket, flux.RateLimitAlgorithmSlidingWindow} {
+		server.SetTime(now)
+		result, err := store.Acquire(context.Background(), algorithm, algorithm, 1, time.Minute)
+		tester.NoError(err)
+		tester.True(result.Allowed)
+		result, err = store.Acquire(context.Background(), algorithm, algorithm, 1, time.Minute)
+		tester.NoError(err)
+		tester.False(result.Allowed)
+		server.SetTime(now.Add(time.Minute * 2))
+		result, err = store.Acquire(context.Background(), algorithm, algorithm, 1, time.Minute)
+		tester.NoError(err)
+		tester.True(result.Allowed)
+	}
+}
+
+func TestRedisStoreSlidingWindow(t *testing.T) {
+	tester := assert.New(t)
+	store, server := newTestRedisStore(t)
+	defer server.Close()
+	defer store.OnShutdown(context.Background())
+	// 两个网关实例共享同一计数
+	other := NewRedisRateLimitStore()
+	tester.NoError(other.OnInit(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyRedisAddress: server.Addr(),
+	})))
+	defer other.OnShutdown(context.Background())
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		for _, s := range []*RedisRateLimitStore{store, other} {
+			result, err := s.Acquire(context.Background(), "w", flux.RateLimitAlgorithmSlidingWindow, 5, time.Hour)
+			tester.NoError(err)
+			if result.Allowed {
+				allowed++
+			}
+		}
+	}
+	tester.Equal(5, allowed)
+}
+
+func TestRedisStoreUnreachable(t *testing.T) {
+	tester := assert.New(t)
+	store, server := newTestRedisStore(t)
+	server.Close()
+	_, err := store.Acquire(context.Background(), "k", flux.RateLimitAlgorithmTokenBucket, 3, time.Minute)
+	tester.Error(err)
+}
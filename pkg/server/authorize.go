@@ -0,0 +1,205 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+const (
+	// ConfigKeyAuthorize WebListener配置：Endpoint认证授权的默认配置
+	ConfigKeyAuthorize               = "authorize"
+	ConfigKeyAuthorizeDefault        = "default"
+	ConfigKeyAuthorizeApplications   = "applications"
+	ConfigKeyAuthorizeAuthenticators = "authenticators"
+)
+
+const (
+	// TypeIdAuthenticateGate 认证关卡Filter的标识
+	TypeIdAuthenticateGate = "authenticate_gate"
+	// AttrKeyAuthenticator 认证通过后，绑定认证Filter标识的Attribute键名
+	AttrKeyAuthenticator = "flux.go/authenticator"
+)
+
+// AuthorizeConfig Endpoint认证配置。Endpoint是否需要认证，优先使用 flux.go/authorize 注解，
+// 其次使用应用级别的默认配置，最后使用Listener级别的默认配置。
+type AuthorizeConfig struct {
+	// Listener级别的默认值
+	Default bool
+	// 应用级别的默认值
+	Applications map[string]bool
+	// 认证Filter标识列表；需要认证的Endpoint，按顺序执行，任一认证通过即可；
+	// 不需要认证的Endpoint，跳过这些Filter。为空时不启用认证关卡。
+	Authenticators []string
+}
+
+// NewAuthorizeConfig 从WebListener的 authorize 配置中加载
+func NewAuthorizeConfig(config *flux.Configuration) AuthorizeConfig {
+	apps := cast.ToStringMapBool(config.Get(ConfigKeyAuthorizeApplications))
+	return AuthorizeConfig{
+		Default:        config.GetBool(ConfigKeyAuthorizeDefault),
+		Applications:   apps,
+		Authenticators: config.GetStringSlice(ConfigKeyAuthorizeAuthenticators),
+	}
+}
+
+// WithAuthorizeConfig 配置Endpoint认证；认证Filter需已注册
+func WithAuthorizeConfig(c AuthorizeConfig) DispatcherOptionFunc {
+	return func(d *Dispatcher) {
+		err := d.SetAuthorizeConfig(c)
+		flux.AssertM(err == nil, func() string {
+			return err.Error()
+		})
+	}
+}
+
+// SetAuthorizeConfig 配置Endpoint认证，并从已注册的Filter中查找认证Filter；认证Filter未注册时返回错误
+func (d *Dispatcher) SetAuthorizeConfig(c AuthorizeConfig) error {
+	authenticators, err := lookupAuthenticators(c.Authenticators)
+	if nil != err {
+		return err
+	}
+	apps := make(map[string]bool, len(c.Applications))
+	for name, v := range c.Applications {
+		apps[toolkit.LowerKey(name)] = v
+	}
+	c.Applications = apps
+	d.authorize = c
+	d.authenticateGate = &authenticateGate{authenticators: authenticators}
+	return nil
+}
+
+// authorizeOf 返回Endpoint是否需要认证
+func (d *Dispatcher) authorizeOf(ep *flux.EndpointSpec) bool {
+	if anno, ok := ep.AnnotationEx(flux.EndpointAnnotationAuthorize); ok && anno.IsValid() {
+		return anno.GetBoolean()
+	}
+	if v, ok := d.authorize.Applications[toolkit.LowerKey(ep.Application)]; ok {
+		return v
+	}
+	return d.authorize.Default
+}
+
+// authorizeFilters 从Filter列表中移除认证Filter；需要认证时，在首个认证Filter的位置插入认证关卡，
+// 如列表中不包含认证Filter，则认证关卡作为第一个Filter。
+func (d *Dispatcher) authorizeFilters(ctx flux.Context, filters []flux.Filter) []flux.Filter {
+	if len(d.authorize.Authenticators) == 0 {
+		return filters
+	}
+	out := make([]flux.Filter, 0, len(filters)+1)
+	at := -1
+	for _, f := range filters {
+		if isAuthenticator(d.authorize.Authenticators, f.FilterId()) {
+			if at < 0 {
+				at = len(out)
+			}
+			continue
+		}
+		out = append(out, f)
+	}
+	if !d.authorizeOf(ctx.Endpoint()) {
+		return out
+	}
+	if at < 0 {
+		at = 0
+	}
+	return append(out[:at], append([]flux.Filter{d.authenticateGate}, out[at:]...)...)
+}
+
+func isAuthenticator(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupAuthenticators(ids []string) ([]flux.Filter, error) {
+	registered := append(ext.GlobalFilters(), ext.SelectiveFilters()...)
+	out := make([]flux.Filter, 0, len(ids))
+	for _, id := range ids {
+		found := false
+		for _, f := range registered {
+			if f.FilterId() == id {
+				out = append(out, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("authorize: authenticator filter not registered, id: %s", id)
+		}
+	}
+	return out, nil
+}
+
+// authenticateGate 按顺序执行认证Filter，任一认证通过后继续执行后续Filter链；全部失败时返回认证错误
+type authenticateGate struct {
+	authenticators []flux.Filter
+}
+
+func (g *authenticateGate) FilterId() string {
+	return TypeIdAuthenticateGate
+}
+
+func (g *authenticateGate) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		defer func() {
+			ctx.AddMetric(g.FilterId(), time.Since(ctx.StartAt()))
+		}()
+		if len(g.authenticators) == 0 {
+			return &flux.ServeError{
+				StatusCode: flux.StatusServerError,
+				ErrorCode:  flux.ErrorCodeGatewayInternal,
+				Message:    flux.ErrorMessageAuthenticatorNotFound,
+				CauseError: fmt.Errorf("no authenticator registered for endpoint: %s", ctx.Endpoint().HttpPattern),
+			}
+		}
+		var failed *flux.ServeError
+		for _, f := range g.authenticators {
+			// 跳过处理的认证Filter视为未提交凭证，不作为认证通过
+			if skippable, ok := f.(flux.FilterSkippable); ok && skippable.ShouldSkip(ctx) {
+				continue
+			}
+			passed := false
+			serr := f.DoFilter(func(_ flux.Context) *flux.ServeError {
+				passed = true
+				return nil
+			})(ctx)
+			if passed && serr == nil {
+				ctx.SetAttribute(AttrKeyAuthenticator, f.FilterId())
+				return next(ctx)
+			}
+			// 优先返回已提交凭证但校验失败的错误
+			if serr != nil && (failed == nil || (isCredentialNotFound(failed) && !isCredentialNotFound(serr))) {
+				failed = serr
+			}
+		}
+		if failed == nil || isCredentialNotFound(failed) {
+			return &flux.ServeError{
+				StatusCode: flux.StatusUnauthorized,
+				ErrorCode:  flux.ErrorCodeAuthenticationRequired,
+				Message:    flux.ErrorMessageAuthenticationRequired,
+			}
+		}
+		return failed
+	}
+}
+
+// credentialNotFoundCodes 认证Filter未找到请求凭证时返回的错误码
+var credentialNotFoundCodes = map[string]bool{
+	flux.ErrorCodeJwtNotFound:       true,
+	flux.ErrorCodeApiKeyNotFound:    true,
+	flux.ErrorCodeSignatureNotFound: true,
+}
+
+func isCredentialNotFound(serr *flux.ServeError) bool {
+	return credentialNotFoundCodes[cast.ToString(serr.ErrorCode)]
+}
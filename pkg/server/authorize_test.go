@@ -0,0 +1,171 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// headerAuthenticator Header值为good时认证通过；缺少Header时返回凭证不存在的错误
+type headerAuthenticator struct {
+	id     string
+	header string
+}
+
+func (a *headerAuthenticator) FilterId() string {
+	return a.id
+}
+
+func (a *headerAuthenticator) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		switch ctx.HeaderVar(a.header) {
+		case "good":
+			return next(ctx)
+		case "":
+			return &flux.ServeError{StatusCode: flux.StatusUnauthorized, ErrorCode: flux.ErrorCodeApiKeyNotFound}
+		default:
+			return &flux.ServeError{StatusCode: flux.StatusUnauthorized, ErrorCode: "TEST:" + a.id + ":INVALID"}
+		}
+	}
+}
+
+// skipAuthenticator 请求包含X-Skip时跳过认证，直接执行后续Filter
+type skipAuthenticator struct {
+	headerAuthenticator
+}
+
+func (a *skipAuthenticator) ShouldSkip(ctx flux.Context) bool {
+	return ctx.HeaderVar("X-Skip") != ""
+}
+
+func (a *skipAuthenticator) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		if a.ShouldSkip(ctx) {
+			return next(ctx)
+		}
+		return a.headerAuthenticator.DoFilter(next)(ctx)
+	}
+}
+
+type nopFilter string
+
+func (f nopFilter) FilterId() string {
+	return string(f)
+}
+
+func (f nopFilter) DoFilter(next flux.FilterInvoker) flux.FilterInvoker {
+	return next
+}
+
+func TestDispatcherAuthorizeFilters(t *testing.T) {
+	tester := assert.New(t)
+	authA := &headerAuthenticator{id: "authn_a", header: "X-A"}
+	authB := &headerAuthenticator{id: "authn_b", header: "X-B"}
+	ext.AddSelectiveFilter(authA)
+	ext.AddSelectiveFilter(authB)
+	d := newDispatcher(listener.New("authorize", flux.NewConfiguration("authorize_test"), nil))
+	newContext := func(app string, annotations flux.Annotations, headers map[string]string) flux.Context {
+		request := httptest.NewRequest("GET", "/orders", nil)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{Application: app, HttpPattern: "/orders", Annotations: annotations})
+		return ctx
+	}
+	ids := func(filters []flux.Filter) []string {
+		out := make([]string, len(filters))
+		for i, f := range filters {
+			out[i] = f.FilterId()
+		}
+		return out
+	}
+	filters := []flux.Filter{nopFilter("ratelimit"), authA, nopFilter("permission")}
+	// 未配置认证Filter时，不改变Filter列表
+	tester.Equal([]string{"ratelimit", "authn_a", "permission"}, ids(d.authorizeFilters(newContext("orders", nil, nil), filters)))
+
+	config := flux.NewConfiguration("authorize_test")
+	config.Set(ConfigKeyAuthorizeDefault, false)
+	config.Set(ConfigKeyAuthorizeApplications, map[string]interface{}{"Orders": true})
+	config.Set(ConfigKeyAuthorizeAuthenticators, []string{"authn_a", "authn_b"})
+	tester.NoError(d.SetAuthorizeConfig(NewAuthorizeConfig(config)))
+	// 优先级：注解 > 应用 > Listener
+	tester.False(d.authorizeOf(&flux.EndpointSpec{Application: "users"}))
+	tester.True(d.authorizeOf(&flux.EndpointSpec{Application: "Orders"}))
+	tester.True(d.authorizeOf(&flux.EndpointSpec{Application: "orders"}))
+	tester.False(d.authorizeOf(&flux.EndpointSpec{Application: "orders",
+		Annotations: flux.Annotations{flux.EndpointAnnotationAuthorize: "false"}}))
+	tester.True(d.authorizeOf(&flux.EndpointSpec{Application: "users",
+		Annotations: flux.Annotations{flux.EndpointAnnotationAuthorize: true}}))
+	tester.Equal([]string{"ratelimit", "permission"}, ids(d.authorizeFilters(newContext("users", nil, nil), filters)))
+	tester.Equal([]string{"ratelimit", TypeIdAuthenticateGate, "permission"}, ids(d.authorizeFilters(newContext("orders", nil, nil), filters)))
+	tester.Equal([]string{TypeIdAuthenticateGate, "ratelimit"}, ids(d.authorizeFilters(newContext("orders", nil, nil), filters[:1])))
+
+	invoke := func(headers map[string]string) *flux.ServeError {
+		ctx := newContext("orders", nil, headers)
+		chain := d.makeFilterChain(func(ctx flux.Context) *flux.ServeError {
+			return nil
+		}, d.authorizeFilters(ctx, filters))
+		return chain(ctx)
+	}
+	tester.Nil(invoke(map[string]string{"X-A": "good"}))
+	tester.Nil(invoke(map[string]string{"X-A": "bad", "X-B": "good"}))
+	serr := invoke(nil)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeAuthenticationRequired, serr.ErrorCode)
+	serr = invoke(map[string]string{"X-B": "bad"})
+	tester.NotNil(serr)
+	tester.Equal("TEST:authn_b:INVALID", serr.ErrorCode)
+	// 认证Filter未注册
+	config.Set(ConfigKeyAuthorizeAuthenticators, []string{"authn_a", "authn_none"})
+	tester.Error(d.SetAuthorizeConfig(NewAuthorizeConfig(config)))
+}
+
+func TestAuthenticateGateSkipped(t *testing.T) {
+	tester := assert.New(t)
+	gate := &authenticateGate{authenticators: []flux.Filter{
+		&skipAuthenticator{headerAuthenticator{id: "authn_skip", header: "X-S"}},
+	}}
+	invoke := func(headers map[string]string) (*flux.ServeError, bool) {
+		request := httptest.NewRequest("GET", "/orders", nil)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{HttpPattern: "/orders"})
+		invoked := false
+		serr := gate.DoFilter(func(ctx flux.Context) *flux.ServeError {
+			invoked = true
+			return nil
+		})(ctx)
+		return serr, invoked
+	}
+	serr, invoked := invoke(map[string]string{"X-S": "good"})
+	tester.Nil(serr)
+	tester.True(invoked)
+	// 跳过认证的Filter不作为认证通过
+	serr, invoked = invoke(map[string]string{"X-Skip": "true"})
+	tester.NotNil(serr)
+	tester.False(invoked)
+	tester.Equal(flux.ErrorCodeAuthenticationRequired, serr.ErrorCode)
+}
+
+func TestIsCredentialNotFound(t *testing.T) {
+	tester := assert.New(t)
+	for _, code := range []string{flux.ErrorCodeJwtNotFound, flux.ErrorCodeApiKeyNotFound, flux.ErrorCodeSignatureNotFound} {
+		tester.True(isCredentialNotFound(&flux.ServeError{ErrorCode: code}), code)
+	}
+	// 非认证Filter的错误码，不按凭证不存在处理
+	tester.False(isCredentialNotFound(&flux.ServeError{ErrorCode: "CUSTOM:USER:NOTFOUND"}))
+	tester.False(isCredentialNotFound(&flux.ServeError{ErrorCode: flux.ErrorCodeJwtInvalid}))
+}
@@ -1,21 +1,35 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 import (
 	"github.com/jinzhu/copier"
+	"github.com/spf13/cast"
 )
 
 import (
+	"github.com/bytepowered/fluxgo/pkg/accesslog"
 	"github.com/bytepowered/fluxgo/pkg/ext"
 	"github.com/bytepowered/fluxgo/pkg/flux"
 	"github.com/bytepowered/fluxgo/pkg/internal"
 	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/tracing"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ConfigKeyRequestTimeout WebListener配置：Endpoint请求的默认超时时长；为0时不限制
+	ConfigKeyRequestTimeout = "request_timeout"
 )
 
 type (
@@ -25,9 +39,13 @@ type (
 type Dispatcher struct {
 	flux.WebListener
 	metrics                *Metrics
+	endpointMetrics        *EndpointMetrics
 	pooled                 *sync.Pool
 	responseWriter         flux.ServeResponseWriter
 	versionLocator         flux.WebRequestVersionLocator
+	requestTimeout         time.Duration
+	authorize              AuthorizeConfig
+	authenticateGate       *authenticateGate
 	onContextHooks         []flux.OnContextHookFunc
 	onBeforeFilterHooks    []flux.OnBeforeFilterHookFunc
 	onBeforeTransportHooks []flux.OnBeforeTransportHookFunc
@@ -47,6 +65,13 @@ func WithServeResponseWriter(writer flux.ServeResponseWriter) DispatcherOptionFu
 	}
 }
 
+// WithRequestTimeout 配置Endpoint请求的默认超时时长
+func WithRequestTimeout(timeout time.Duration) DispatcherOptionFunc {
+	return func(d *Dispatcher) {
+		d.SetRequestTimeout(timeout)
+	}
+}
+
 // WithOnBeforeTransportHookFunc 配置Transporter调用前的钩子函数列表
 func WithOnBeforeTransportHookFunc(hooks ...flux.OnBeforeTransportHookFunc) DispatcherOptionFunc {
 	return func(d *Dispatcher) {
@@ -69,6 +94,7 @@ func newDispatcher(listener flux.WebListener) *Dispatcher {
 	return &Dispatcher{
 		WebListener:            listener,
 		metrics:                NewMetricsWith(listener.ListenerId()),
+		endpointMetrics:        NewEndpointMetrics(),
 		pooled:                 &sync.Pool{New: func() interface{} { return internal.NewContext() }},
 		versionLocator:         DefaultRequestVersionLocateFunc,
 		responseWriter:         new(internal.JSONServeResponseWriter),
@@ -112,6 +138,24 @@ func (d *Dispatcher) route(webex flux.WebContext, versions *flux.MVCEndpoint) (e
 	ctxw := d.pooled.Get().(flux.Context)
 	defer d.pooled.Put(ctxw)
 	ctxw.(*internal.Context).Reset(webex, &endpoint)
+	// 绑定请求的截止时间；截止时间约束后端服务返回响应之前的处理，不限制流式响应体的写入
+	if timeout := d.timeoutOf(&endpoint); timeout > 0 {
+		toctx, cancel := context.WithTimeout(ctxw.Context(), timeout)
+		defer cancel()
+		ctxw.(*internal.Context).SetContext(toctx)
+	}
+	tracing.SetServerRoute(ctxw)
+	// 绑定统计指标的Endpoint标签
+	observation := &metricObservation{labels: metricLabelsOf(d.ListenerId(), ctxw)}
+	webex.SetVariable(metricObservationKey, observation)
+	// 访问日志的Endpoint字段
+	if record := accesslog.RecordOf(webex); record != nil {
+		record.Pattern, _, record.Version = ctxw.Exposed()
+		record.Application, record.ServiceId = ctxw.Application(), ctxw.ServiceID()
+	}
+	inflight := d.endpointMetrics.InFlight.WithLabelValues(observation.labels...)
+	inflight.Inc()
+	defer inflight.Dec()
 	ctxw.SetAttribute(flux.XRequestTime, ctxw.StartAt().Unix())
 	ctxw.SetAttribute(flux.XRequestId, ctxw.RequestId())
 	logger.TraceVerbose(ctxw).Infow("DISPATCH:EVEN:ROUTE:START")
@@ -146,6 +190,12 @@ func (d *Dispatcher) dispatch(ctx flux.Context) *flux.ServeError {
 	}
 	// fin: transport and write data (response,error)
 	if ferr := d.makeFilterChain(next, filters)(ctx); ferr != nil {
+		if observation, ok := ctx.Variable(metricObservationKey).(*metricObservation); ok {
+			observation.errorCode = cast.ToString(ferr.ErrorCode)
+		}
+		if record := accesslog.RecordOf(ctx); record != nil {
+			record.ErrorCode = cast.ToString(ferr.ErrorCode)
+		}
 		d.responseWriter.WriteError(ctx, ferr)
 	}
 	return nil // always return nil
@@ -153,11 +203,48 @@ func (d *Dispatcher) dispatch(ctx flux.Context) *flux.ServeError {
 
 func (d *Dispatcher) makeFilterChain(next flux.FilterInvoker, filters []flux.Filter) flux.FilterInvoker {
 	for i := len(filters) - 1; i >= 0; i-- {
-		next = filters[i].DoFilter(next)
+		next = d.traceFilter(filters[i].FilterId(), filters[i].DoFilter(next))
 	}
 	return next
 }
 
+// observeFilter 记录请求的Endpoint统计指标；未匹配Endpoint的请求，统一使用 MetricsUnmatchedLabel 标签
+func (d *Dispatcher) observeFilter(next flux.WebHandlerFunc) flux.WebHandlerFunc {
+	return func(webex flux.WebContext) error {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: webex.ResponseWriter()}
+		webex.SetResponseWriter(recorder)
+		err := next(webex)
+		webex.SetResponseWriter(recorder.ResponseWriter)
+		observation, ok := webex.Variable(metricObservationKey).(*metricObservation)
+		if !ok {
+			observation = &metricObservation{labels: []string{d.ListenerId(), metricMethodOf(webex.Method()),
+				MetricsUnmatchedLabel, MetricsUnmatchedLabel, MetricsUnmatchedLabel}}
+		}
+		status, code := recorder.statusCode(), observation.errorCode
+		if !flux.IsNil(err) {
+			// 错误由WebListener的错误处理函数写入响应
+			status = flux.StatusServerError
+			if serr, ok := err.(*flux.ServeError); ok {
+				status, code = serr.StatusCode, cast.ToString(serr.ErrorCode)
+			}
+		}
+		d.endpointMetrics.Observe(observation.labels, status, code, time.Since(start))
+		return err
+	}
+}
+
+// traceFilter 为Filter调用创建子Span；Filter的Span包含后续Filter链的调用
+func (d *Dispatcher) traceFilter(filterId string, invoker flux.FilterInvoker) flux.FilterInvoker {
+	return func(ctx flux.Context) *flux.ServeError {
+		span, end := tracing.StartSpan(ctx, "filter:"+filterId)
+		defer end()
+		ferr := invoker(ctx)
+		tracing.SetSpanError(span, ferr)
+		return ferr
+	}
+}
+
 func (d *Dispatcher) lookup(webex flux.WebContext, server flux.WebListener, endpoints *flux.MVCEndpoint) (*flux.EndpointSpec, bool) {
 	// 动态Endpoint版本选择
 	for _, selector := range ext.EndpointSelectors() {
@@ -177,7 +264,10 @@ func (d *Dispatcher) handlePlugins(ctx flux.Context) *flux.ServeError {
 	}()
 	for _, plugin := range d.selectPlugins(ctx) {
 		timer := d.metrics.NewRouteVecTimer("Plugin", plugin.PluginId())
+		span, end := tracing.StartSpan(ctx, "plugin:"+plugin.PluginId())
 		pherr := plugin.DoHandle(ctx)
+		tracing.SetSpanError(span, pherr)
+		end()
 		timer.ObserveDuration()
 		if pherr != nil {
 			return pherr
@@ -190,9 +280,7 @@ func (d *Dispatcher) handlePlugins(ctx flux.Context) *flux.ServeError {
 func (d *Dispatcher) doTransport(ctx flux.Context) *flux.ServeError {
 	select {
 	case <-ctx.Context().Done():
-		return &flux.ServeError{StatusCode: flux.StatusBadRequest,
-			ErrorCode: "DISPATCHER:TRANSPORT:CANCELED/100", CauseError: ctx.Context().Err(),
-		}
+		return d.doneError(ctx, "DISPATCHER:TRANSPORT:CANCELED/100")
 	default:
 		break
 	}
@@ -212,16 +300,32 @@ func (d *Dispatcher) doTransport(ctx flux.Context) *flux.ServeError {
 	for _, hook := range d.onBeforeTransportHooks {
 		hook(ctx, transporter)
 	}
-	timer := d.metrics.NewRouteVecTimer("Transporter", proto)
-	invret, inverr := transporter.DoInvoke(ctx, ctx.Service())
-	timer.ObserveDuration()
+	upstream := time.Now()
+	invret, inverr := d.doInvoke(ctx, transporter)
+	if record := accesslog.RecordOf(ctx); record != nil {
+		record.UpstreamLatency = time.Since(upstream)
+	}
+	// 客户端连接已被Transporter接管，不再写入响应
+	if invret != nil && invret.IsHijacked() {
+		return nil
+	}
 	select {
 	case <-ctx.Context().Done():
-		return &flux.ServeError{StatusCode: flux.StatusBadRequest,
-			ErrorCode: "DISPATCHER:TRANSPORT:CANCELED/200", CauseError: ctx.Context().Err(),
-		}
+		discardResponse(invret)
+		return d.doneError(ctx, "DISPATCHER:TRANSPORT:CANCELED/200")
 	default:
 		if flux.IsNil(inverr) {
+			if body, ok := streamBodyOf(ctx, invret); ok {
+				d.writeStream(ctx, invret, body)
+				return nil
+			}
+			if err := internal.TransformResponse(ctx, invret); nil != err {
+				return &flux.ServeError{StatusCode: flux.StatusServerError,
+					ErrorCode:  flux.ErrorCodeGatewayEndpoint,
+					Message:    flux.ErrorMessageTransformResponseFailed,
+					CauseError: err,
+				}
+			}
 			d.responseWriter.Write(ctx, invret)
 			return nil
 		}
@@ -229,6 +333,64 @@ func (d *Dispatcher) doTransport(ctx flux.Context) *flux.ServeError {
 	}
 }
 
+// doInvoke 调用后端服务；按Service声明的重试策略，对可重试的调用结果执行退避重试
+func (d *Dispatcher) doInvoke(ctx flux.Context, transporter flux.Transporter) (*flux.ServeResponse, *flux.ServeError) {
+	policy := NewRetryPolicy(ctx.Service())
+	for attempt := 1; ; attempt++ {
+		timer := d.metrics.NewRouteVecTimer("Transporter", ctx.Service().Protocol)
+		span, end := tracing.StartSpan(ctx, "transporter:"+ctx.Service().Protocol,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(tracing.AttrServiceId.String(ctx.ServiceID()), attribute.Int("flux.transporter.attempt", attempt)),
+		)
+		invret, inverr := transporter.DoInvoke(ctx, ctx.Service())
+		tracing.SetSpanError(span, inverr)
+		end()
+		timer.ObserveDuration()
+		if policy.MaxAttempts > 1 {
+			ctx.AddMetric(fmt.Sprintf("transporter.attempt.%d", attempt), time.Since(ctx.StartAt()))
+		}
+		if attempt >= policy.MaxAttempts || !policy.Retryable(ctx, invret, inverr) {
+			return invret, inverr
+		}
+		backoff := policy.BackoffOf(attempt)
+		if !waitBackoff(ctx, backoff) {
+			return invret, inverr
+		}
+		discardResponse(invret)
+		logger.TraceVerbose(ctx).Infow("DISPATCH:EVEN:TRANSPORT:RETRY", "attempt", attempt, "backoff", backoff,
+			"service-id", ctx.ServiceID(), "error", inverr)
+	}
+}
+
+// doneError 返回请求结束时的错误：超出截止时间返回请求超时错误，否则为请求取消错误
+func (d *Dispatcher) doneError(ctx flux.Context, code string) *flux.ServeError {
+	if transporter.IsRequestTimeout(ctx) {
+		return transporter.NewRequestTimeoutError(ctx)
+	}
+	return &flux.ServeError{StatusCode: flux.StatusBadRequest,
+		ErrorCode: code, CauseError: ctx.Context().Err(),
+	}
+}
+
+// timeoutOf 返回Endpoint请求的超时时长：优先使用Endpoint注解，否则使用Listener的默认配置；
+// 注解值支持Duration字符串或毫秒数。
+func (d *Dispatcher) timeoutOf(ep *flux.EndpointSpec) time.Duration {
+	if anno, ok := ep.AnnotationEx(flux.EndpointAnnotationTimeout); ok {
+		value := strings.TrimSpace(anno.GetString())
+		if millis, err := strconv.ParseInt(value, 10, 64); nil == err {
+			return time.Duration(millis) * time.Millisecond
+		}
+		if timeout, err := cast.ToDurationE(value); nil == err {
+			return timeout
+		}
+	}
+	return d.requestTimeout
+}
+
+func (d *Dispatcher) SetRequestTimeout(timeout time.Duration) {
+	d.requestTimeout = timeout
+}
+
 func (d *Dispatcher) SetResponseWriter(w flux.ServeResponseWriter) {
 	d.responseWriter = w
 }
@@ -256,7 +418,7 @@ func (d *Dispatcher) selectFilters(ctx flux.Context) []flux.Filter {
 			selective = append(selective, selector.DoSelect(ctx)...)
 		}
 	}
-	return append(ext.GlobalFilters(), selective...)
+	return d.authorizeFilters(ctx, append(ext.GlobalFilters(), selective...))
 }
 
 func (d *Dispatcher) selectPlugins(ctx flux.Context) []flux.Plugin {
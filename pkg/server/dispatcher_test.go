@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/accesslog"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingTransporter struct{}
+
+type streamBody struct {
+	*bytes.Reader
+	closed bool
+}
+
+func (b *streamBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+type streamTransporter struct {
+	header http.Header
+	body   *streamBody
+	cancel context.CancelFunc
+}
+
+func (t *streamTransporter) DoInvoke(_ flux.Context, _ flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	return &flux.ServeResponse{StatusCode: http.StatusOK, Headers: t.header, Body: t.body}, nil
+}
+
+func (t *blockingTransporter) DoInvoke(ctx flux.Context, _ flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	<-ctx.Context().Done()
+	return nil, &flux.ServeError{StatusCode: flux.StatusBadGateway, ErrorCode: flux.ErrorCodeGatewayTransporter}
+}
+
+func TestDispatcherTimeoutOf(t *testing.T) {
+	tester := assert.New(t)
+	d := newDispatcher(listener.New("timeoutof", flux.NewConfiguration("timeout_test"), nil))
+	d.SetRequestTimeout(time.Second)
+	tester.Equal(time.Second, d.timeoutOf(&flux.EndpointSpec{}))
+	tester.Equal(time.Millisecond*1500, d.timeoutOf(&flux.EndpointSpec{
+		Annotations: flux.Annotations{flux.EndpointAnnotationTimeout: "1500"},
+	}))
+	tester.Equal(time.Second*3, d.timeoutOf(&flux.EndpointSpec{
+		Annotations: flux.Annotations{flux.EndpointAnnotationTimeout: "3s"},
+	}))
+}
+
+func TestDispatcherRequestTimeout(t *testing.T) {
+	tester := assert.New(t)
+	ext.RegisterTransporter("blocking", &blockingTransporter{})
+	d := newDispatcher(listener.New("requesttimeout", flux.NewConfiguration("timeout_test"), nil))
+	ctx := internal.NewContext()
+	request := httptest.NewRequest("GET", "/timeout", nil)
+	ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil), &flux.EndpointSpec{
+		Service: flux.ServiceSpec{Interface: "timeout.test", Method: "get", Protocol: "blocking"},
+	})
+	toctx, cancel := context.WithTimeout(ctx.Context(), time.Millisecond*20)
+	defer cancel()
+	ctx.SetContext(toctx)
+	serr := d.doTransport(ctx)
+	tester.NotNil(serr)
+	tester.Equal(flux.StatusTimeout, serr.StatusCode)
+	tester.Equal(flux.ErrorCodeRequestTimeout, serr.ErrorCode)
+}
+
+func TestDispatcherStreamResponse(t *testing.T) {
+	tester := assert.New(t)
+	tr := &streamTransporter{}
+	ext.RegisterTransporter("streamtest", tr)
+	d := newDispatcher(listener.New("streamtest", flux.NewConfiguration("stream_test"), nil))
+	serve := func(header http.Header, data []byte, cancel bool) (*httptest.ResponseRecorder, *streamBody) {
+		tr.header, tr.body, tr.cancel = header, &streamBody{Reader: bytes.NewReader(data)}, nil
+		recorder := httptest.NewRecorder()
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(httptest.NewRequest("GET", "/stream", nil), recorder), "test-id", nil), &flux.EndpointSpec{
+			Service: flux.ServiceSpec{Interface: "stream.test", Method: "get", Protocol: "streamtest"},
+		})
+		if cancel {
+			cctx, cancel := context.WithCancel(ctx.Context())
+			tr.cancel = cancel
+			ctx.SetContext(cctx)
+		}
+		_ = d.doTransport(ctx)
+		return recorder, tr.body
+	}
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	recorder, body := serve(http.Header{
+		"Content-Type":      []string{"image/png"},
+		"Content-Length":    []string{"6"},
+		"Transfer-Encoding": []string{"chunked"},
+		"X-Trace":           []string{"t1"},
+	}, binary, false)
+	tester.True(body.closed)
+	tester.Equal(binary, recorder.Body.Bytes())
+	tester.Equal("image/png", recorder.Header().Get("Content-Type"))
+	tester.Equal("6", recorder.Header().Get("Content-Length"))
+	tester.Equal("t1", recorder.Header().Get("X-Trace"))
+	tester.Empty(recorder.Header().Get("Transfer-Encoding"))
+	// SSE响应立即Flush到客户端
+	recorder, body = serve(http.Header{"Content-Type": []string{"text/event-stream"}}, []byte("data: hello\n\n"), false)
+	tester.True(body.closed)
+	tester.True(recorder.Flushed)
+	tester.Equal("data: hello\n\n", recorder.Body.String())
+	// 客户端取消请求时，关闭响应数据流
+	recorder, body = serve(http.Header{}, []byte("canceled"), true)
+	tester.True(body.closed)
+	tester.Empty(recorder.Body.String())
+}
+
+func TestDispatcherAccessLogRecord(t *testing.T) {
+	tester := assert.New(t)
+	ext.RegisterTransporter("accesslogtest", &flakyTransporter{})
+	ext.RegisterService(flux.ServiceSpec{Interface: "accesslog.test", Method: "get", Protocol: "accesslogtest"})
+	buffer := new(bytes.Buffer)
+	formatter, _ := accesslog.NewJSONFormatter(nil)
+	webl := listener.New("accesslogtest", flux.NewConfiguration("accesslog_test"), nil)
+	webl.AddFilter(listener.NewAccessLogFilterWith(accesslog.NewAccessLogger(formatter, accesslog.NewWriterSink(buffer))))
+	dm := NewDispatcherManager(WithNewDispatcherOptions(webl))
+	dm.onEndpointEvent(flux.EndpointEvent{EventType: flux.EventTypeAdded, Endpoint: flux.EndpointSpec{
+		Version: "v1", HttpMethod: "GET", HttpPattern: "/accesslog/{id}", ServiceId: "accesslog.test:get",
+		Application: "accesslogapp", Attributes: flux.Attributes{}, Annotations: flux.Annotations{
+			flux.EndpointAnnotationListenerSel: "accesslogtest",
+		},
+	}})
+	serve := func(uri string) map[string]interface{} {
+		buffer.Reset()
+		webl.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", uri, nil))
+		out := make(map[string]interface{})
+		tester.NoError(json.Unmarshal(buffer.Bytes(), &out))
+		return out
+	}
+	matched := serve("/accesslog/1")
+	tester.Equal(200.0, matched[accesslog.FieldStatus])
+	tester.Equal("/accesslog/{id}", matched[accesslog.FieldEndpoint])
+	tester.Equal("accesslogapp", matched[accesslog.FieldApplication])
+	tester.Equal("accesslog.test:get", matched[accesslog.FieldServiceId])
+	tester.Equal("accesslogtest", matched[accesslog.FieldListenerId])
+	tester.True(matched[accesslog.FieldResponseSize].(float64) > 0)
+	unmatched := serve("/notfound/1")
+	tester.Equal(404.0, unmatched[accesslog.FieldStatus])
+	tester.Equal(flux.ErrorCodeRequestNotFound, unmatched[accesslog.FieldErrorCode])
+	tester.Equal("", unmatched[accesslog.FieldEndpoint])
+}
@@ -26,6 +26,7 @@ func init() {
 	// Endpoint discovery
 	ext.RegisterMetadataDiscovery(discovery.NewZookeeperMetadataDiscovery(discovery.ZookeeperId))
 	ext.RegisterMetadataDiscovery(discovery.NewResourceMetadataDiscovery(discovery.ResourceId))
+	ext.RegisterMetadataDiscovery(discovery.NewAdminMetadataDiscovery(discovery.AdminId))
 }
 
 // InitConfig 初始化配置
@@ -1,6 +1,18 @@
 package server
 
 import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -58,3 +70,157 @@ func NewMetricsWith(listener string) *Metrics {
 		}, []string{"ComponentKind", "TypeId"}),
 	}
 }
+
+const (
+	// MetricsUnmatchedLabel 未匹配Endpoint的请求（路由不存在、Endpoint版本不存在、非Endpoint处理函数），
+	// 统一使用此标签值，避免原始URI导致统计指标的标签数量膨胀。
+	MetricsUnmatchedLabel = "UNMATCHED"
+)
+
+var (
+	endpointMetrics     *EndpointMetrics
+	endpointMetricsOnce sync.Once
+)
+
+// EndpointMetrics 按Endpoint统计的请求量、错误量、耗时和并发请求数（RED）指标
+type EndpointMetrics struct {
+	// 请求次数统计
+	Requests *prometheus.CounterVec
+	// 错误次数统计
+	Errors *prometheus.CounterVec
+	// 请求耗时统计
+	Duration *prometheus.HistogramVec
+	// 处理中的请求数
+	InFlight *prometheus.GaugeVec
+}
+
+// Observe 记录请求的统计指标；labels 为：Listener, Method, Pattern, Version, Application
+func (m *EndpointMetrics) Observe(labels []string, status int, errorCode string, elapsed time.Duration) {
+	class := StatusClassOf(status)
+	values := make([]string, len(labels), len(labels)+2)
+	copy(values, labels)
+	m.Requests.WithLabelValues(append(values, class)...).Inc()
+	m.Duration.WithLabelValues(labels...).Observe(elapsed.Seconds())
+	if errorCode != "" || status >= http.StatusBadRequest {
+		if errorCode == "" {
+			errorCode = "NONE"
+		}
+		m.Errors.WithLabelValues(append(values, class, errorCode)...).Inc()
+	}
+}
+
+// StatusClassOf 返回Http状态码的分类：1xx, 2xx, 3xx, 4xx, 5xx
+func StatusClassOf(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// NewEndpointMetrics 返回全局的Endpoint统计指标；各WebListener共享，以Listener标签区分
+func NewEndpointMetrics() *EndpointMetrics {
+	endpointMetricsOnce.Do(func() {
+		const namespace, subsystem = "fluxgo", "endpoint"
+		var labels = []string{"Listener", "Method", "Pattern", "Version", "Application"}
+		endpointMetrics = &EndpointMetrics{
+			Requests: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "requests_total",
+				Help:      "Number of endpoint requests, by status class",
+			}, append(labels, "StatusClass")),
+			Errors: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "errors_total",
+				Help:      "Number of endpoint error requests, by status class and error code",
+			}, append(labels, "StatusClass", "ErrorCode")),
+			Duration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "duration_seconds",
+				Help:      "Spend time by processing endpoint requests",
+				Buckets:   defaultMetricBuckets,
+			}, labels),
+			InFlight: promauto.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "in_flight",
+				Help:      "Number of endpoint requests being processed",
+			}, labels),
+		}
+	})
+	return endpointMetrics
+}
+
+const (
+	metricObservationKey = "flux.go/server.metric.observation"
+)
+
+// metricObservation 请求的统计指标观测数据；Dispatcher在路由到Endpoint时绑定到WebContext
+type metricObservation struct {
+	labels    []string
+	errorCode string
+}
+
+func metricLabelsOf(listenerId string, ctx flux.Context) []string {
+	pattern, method, version := ctx.Exposed()
+	if version == "" {
+		version = "default"
+	}
+	return []string{listenerId, metricMethodOf(method), pattern, version, ctx.Application()}
+}
+
+// metricMethodOf 不支持的Http方法统一使用 MetricsUnmatchedLabel 标签
+func metricMethodOf(method string) string {
+	switch method = strings.ToUpper(method); method {
+	case http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodPut,
+		http.MethodHead, http.MethodOptions, http.MethodPatch, http.MethodTrace:
+		return method
+	default:
+		return MetricsUnmatchedLabel
+	}
+}
+
+// statusRecorder 记录写入的响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := r.ResponseWriter.(http.Hijacker); ok {
+		if r.status == 0 {
+			r.status = http.StatusSwitchingProtocols
+		}
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response writer not support hijack")
+}
+
+func (r *statusRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointMetricsLabels(t *testing.T) {
+	tester := assert.New(t)
+	ext.RegisterTransporter("metrictest", &flakyTransporter{})
+	ext.RegisterService(flux.ServiceSpec{Interface: "metric.test", Method: "get", Protocol: "metrictest"})
+	webl := listener.New("metrictest", flux.NewConfiguration("metric_test"), nil)
+	dm := NewDispatcherManager(WithNewDispatcherOptions(webl))
+	dm.onEndpointEvent(flux.EndpointEvent{EventType: flux.EventTypeAdded, Endpoint: flux.EndpointSpec{
+		Version: "v1", HttpMethod: "GET", HttpPattern: "/metric/{id}", ServiceId: "metric.test:get",
+		Application: "metricapp", Attributes: flux.Attributes{}, Annotations: flux.Annotations{
+			flux.EndpointAnnotationListenerSel: "metrictest",
+		},
+	}})
+	serve := func(uri string) int {
+		recorder := httptest.NewRecorder()
+		webl.ServeHTTP(recorder, httptest.NewRequest("GET", uri, nil))
+		return recorder.Code
+	}
+	tester.Equal(200, serve("/metric/1"))
+	tester.Equal(200, serve("/metric/2"))
+	tester.Equal(404, serve("/notfound/1"))
+	tester.Equal(404, serve("/notfound/2"))
+	metrics := NewEndpointMetrics()
+	tester.Equal(2.0, testutil.ToFloat64(metrics.Requests.WithLabelValues(
+		"metrictest", "GET", "/metric/{id}", "v1", "metricapp", "2xx")))
+	// 未匹配的路由，合并为同一标签值
+	tester.Equal(2.0, testutil.ToFloat64(metrics.Requests.WithLabelValues(
+		"metrictest", "GET", MetricsUnmatchedLabel, MetricsUnmatchedLabel, MetricsUnmatchedLabel, "4xx")))
+	tester.Equal(2.0, testutil.ToFloat64(metrics.Errors.WithLabelValues(
+		"metrictest", "GET", MetricsUnmatchedLabel, MetricsUnmatchedLabel, MetricsUnmatchedLabel, "4xx", flux.ErrorCodeRequestNotFound)))
+	tester.Equal(0.0, testutil.ToFloat64(metrics.InFlight.WithLabelValues(
+		"metrictest", "GET", "/metric/{id}", "v1", "metricapp")))
+}
+
+func TestStatusClassOf(t *testing.T) {
+	tester := assert.New(t)
+	tester.Equal("2xx", StatusClassOf(200))
+	tester.Equal("5xx", StatusClassOf(504))
+	tester.Equal("unknown", StatusClassOf(0))
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/spf13/cast"
+)
+
+var (
+	// DefaultRetryStatus 默认可重试的响应状态码
+	DefaultRetryStatus = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	// DefaultRetryErrorCodes 默认可重试的ServeError错误码
+	DefaultRetryErrorCodes = []string{flux.ErrorCodeGatewayTransporter}
+)
+
+// RetryPolicy 后端服务调用的重试策略
+type RetryPolicy struct {
+	MaxAttempts    int
+	Backoff        time.Duration
+	MaxBackoff     time.Duration
+	RetryStatus    []int
+	RetryCodes     []string
+	IdempotentOnly bool
+}
+
+// NewRetryPolicy 根据Service的重试注解，构建重试策略；未声明重试注解时，最大调用次数为1
+func NewRetryPolicy(service flux.ServiceSpec) RetryPolicy {
+	policy := RetryPolicy{
+		MaxAttempts:    1,
+		Backoff:        time.Millisecond * 100,
+		MaxBackoff:     time.Second * 2,
+		RetryStatus:    DefaultRetryStatus,
+		RetryCodes:     DefaultRetryErrorCodes,
+		IdempotentOnly: true,
+	}
+	if anno, ok := service.AnnotationEx(flux.ServiceAnnotationRetryAttempts); ok {
+		policy.MaxAttempts = cast.ToInt(anno.GetString())
+	}
+	if policy.MaxAttempts <= 1 {
+		policy.MaxAttempts = 1
+		return policy
+	}
+	if anno, ok := service.AnnotationEx(flux.ServiceAnnotationRetryBackoff); ok {
+		policy.Backoff = cast.ToDuration(anno.GetString())
+	}
+	if anno, ok := service.AnnotationEx(flux.ServiceAnnotationRetryMaxBackoff); ok {
+		policy.MaxBackoff = cast.ToDuration(anno.GetString())
+	}
+	if anno, ok := service.AnnotationEx(flux.ServiceAnnotationRetryStatus); ok {
+		policy.RetryStatus = cast.ToIntSlice(splitAnnotationValues(anno.Value))
+	}
+	if anno, ok := service.AnnotationEx(flux.ServiceAnnotationRetryErrorCodes); ok {
+		policy.RetryCodes = splitAnnotationValues(anno.Value)
+	}
+	if anno, ok := service.AnnotationEx(flux.ServiceAnnotationRetryIdempotent); ok {
+		policy.IdempotentOnly = anno.GetBoolean()
+	}
+	return policy
+}
+
+// Retryable 判断本次调用结果是否可重试
+func (p RetryPolicy) Retryable(ctx flux.Context, resp *flux.ServeResponse, serr *flux.ServeError) bool {
+	if p.MaxAttempts <= 1 {
+		return false
+	}
+	if p.IdempotentOnly && !IsIdempotentMethod(ctx.Method()) {
+		return false
+	}
+	if serr != nil {
+		code := cast.ToString(serr.ErrorCode)
+		for _, c := range p.RetryCodes {
+			if c == code {
+				return true
+			}
+		}
+		return p.matchStatus(serr.StatusCode)
+	}
+	return resp != nil && p.matchStatus(resp.StatusCode)
+}
+
+// BackoffOf 返回第attempt次调用失败后的退避时长：按指数递增，并在[d/2, d]范围内随机抖动
+func (p RetryPolicy) BackoffOf(attempt int) time.Duration {
+	if p.Backoff <= 0 {
+		return 0
+	}
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+func (p RetryPolicy) matchStatus(status int) bool {
+	for _, s := range p.RetryStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIdempotentMethod 判断Http请求方法是否为幂等方法
+func IsIdempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitBackoff 等待退避时长；如请求已取消，或剩余时间不足以完成退避，返回false
+func waitBackoff(ctx flux.Context, backoff time.Duration) bool {
+	if deadline, ok := ctx.Context().Deadline(); ok && time.Until(deadline) <= backoff {
+		return false
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// discardResponse 丢弃重试前的响应数据，释放后端连接
+func discardResponse(resp *flux.ServeResponse) {
+	if resp == nil {
+		return
+	}
+	if closer, ok := resp.Body.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+func splitAnnotationValues(value interface{}) []string {
+	if str, ok := value.(string); ok {
+		out := make([]string, 0, 4)
+		for _, v := range strings.Split(str, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	return cast.ToStringSlice(value)
+}
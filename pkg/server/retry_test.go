@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyTransporter struct {
+	failures int
+	invokes  int
+}
+
+func (t *flakyTransporter) DoInvoke(flux.Context, flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	t.invokes++
+	if t.invokes <= t.failures {
+		return nil, &flux.ServeError{StatusCode: flux.StatusServerError, ErrorCode: flux.ErrorCodeGatewayTransporter}
+	}
+	return flux.NewServeResponse(flux.StatusOK, "ok"), nil
+}
+
+func newRetryTestContext(method string, annotations flux.Annotations) *internal.Context {
+	ctx := internal.NewContext()
+	request := httptest.NewRequest(method, "/retry", nil)
+	ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil), &flux.EndpointSpec{
+		HttpMethod: method, HttpPattern: "/retry",
+		Service: flux.ServiceSpec{Interface: "retry.test", Method: "get", Protocol: flux.ProtoEcho, Annotations: annotations},
+	})
+	return ctx
+}
+
+func TestRetryPolicyAnnotations(t *testing.T) {
+	tester := assert.New(t)
+	policy := NewRetryPolicy(flux.ServiceSpec{})
+	tester.Equal(1, policy.MaxAttempts)
+	policy = NewRetryPolicy(flux.ServiceSpec{Annotations: flux.Annotations{
+		flux.ServiceAnnotationRetryAttempts:   "3",
+		flux.ServiceAnnotationRetryBackoff:    "10ms",
+		flux.ServiceAnnotationRetryMaxBackoff: "30ms",
+		flux.ServiceAnnotationRetryStatus:     "500, 503",
+		flux.ServiceAnnotationRetryIdempotent: false,
+	}})
+	tester.Equal(3, policy.MaxAttempts)
+	tester.Equal([]int{500, 503}, policy.RetryStatus)
+	tester.False(policy.IdempotentOnly)
+	for attempt := 1; attempt <= 4; attempt++ {
+		backoff := policy.BackoffOf(attempt)
+		tester.True(backoff >= 5*time.Millisecond && backoff <= 30*time.Millisecond, backoff)
+	}
+	ctx := newRetryTestContext("POST", nil)
+	tester.True(policy.Retryable(ctx, flux.NewServeResponse(503, nil), nil))
+	tester.False(policy.Retryable(ctx, flux.NewServeResponse(502, nil), nil))
+}
+
+func TestDispatcherInvokeRetry(t *testing.T) {
+	tester := assert.New(t)
+	d := newDispatcher(listener.New("retrytest", flux.NewConfiguration("retry_test"), nil))
+	annotations := flux.Annotations{
+		flux.ServiceAnnotationRetryAttempts: 3,
+		flux.ServiceAnnotationRetryBackoff:  "1ms",
+	}
+	// 两次失败后成功
+	transporter := &flakyTransporter{failures: 2}
+	ctx := newRetryTestContext("GET", annotations)
+	resp, serr := d.doInvoke(ctx, transporter)
+	tester.Nil(serr)
+	tester.Equal("ok", resp.Body)
+	tester.Equal(3, transporter.invokes)
+	// 超出最大调用次数
+	transporter = &flakyTransporter{failures: 5}
+	_, serr = d.doInvoke(newRetryTestContext("GET", annotations), transporter)
+	tester.NotNil(serr)
+	tester.Equal(3, transporter.invokes)
+	// 非幂等方法不重试
+	transporter = &flakyTransporter{failures: 5}
+	_, serr = d.doInvoke(newRetryTestContext("POST", annotations), transporter)
+	tester.NotNil(serr)
+	tester.Equal(1, transporter.invokes)
+}
+
+func TestDispatcherInvokeRetryDeadline(t *testing.T) {
+	tester := assert.New(t)
+	d := newDispatcher(listener.New("retrydeadline", flux.NewConfiguration("retry_test"), nil))
+	ctx := internal.NewContext()
+	timeout, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	request := httptest.NewRequest("GET", "/retry", nil).WithContext(timeout)
+	ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil), &flux.EndpointSpec{
+		Service: flux.ServiceSpec{Annotations: flux.Annotations{
+			flux.ServiceAnnotationRetryAttempts: 5,
+			flux.ServiceAnnotationRetryBackoff:  "50ms",
+		}},
+	})
+	transporter := &flakyTransporter{failures: 5}
+	start := time.Now()
+	_, serr := d.doInvoke(ctx, transporter)
+	tester.NotNil(serr)
+	tester.Equal(1, transporter.invokes)
+	tester.True(time.Since(start) < 20*time.Millisecond)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/bytepowered/fluxgo/pkg/internal"
 	"github.com/bytepowered/fluxgo/pkg/logger"
 	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/bytepowered/fluxgo/pkg/tracing"
 )
 
 const (
@@ -118,6 +119,9 @@ func (d *DispatchServer) Init() error {
 		if err := webListener.OnInit(config); nil != err {
 			return err
 		}
+		if config.IsSet(ConfigKeyRequestTimeout) {
+			dis.SetRequestTimeout(config.GetDuration(ConfigKeyRequestTimeout))
+		}
 	}
 	// 2. EDS
 	for _, eds := range ext.MetadataDiscoveries() {
@@ -145,7 +149,26 @@ func (d *DispatchServer) Init() error {
 			return err
 		}
 	}
-	// 4. Static Filters
+	// 4. RateLimit Stores
+	for id, store := range ext.RateLimitStores() {
+		ext.AddStartupHook(store)
+		ext.AddShutdownHook(store)
+		err := onInitializer(store, func(initable flux.Initializer) error {
+			rsc := flux.NewConfigurationByKeys(flux.NamespaceRateLimitStores, id)
+			logger.Infow("SERVER:EVENT:INIT:RATELIMIT_STORE", "store-id", id, "store-type", reflect.TypeOf(store))
+			return initable.OnInit(rsc)
+		})
+		if nil != err {
+			return err
+		}
+	}
+	// 5. Tracing
+	tracer := tracing.NewTraceProvider()
+	ext.AddShutdownHook(tracer)
+	if err := tracer.OnInit(flux.NewConfiguration(flux.NamespaceTracing)); nil != err {
+		return err
+	}
+	// 6. Static Filters
 	for _, filter := range append(ext.GlobalFilters(), ext.SelectiveFilters()...) {
 		err := onInitializer(filter, func(initable flux.Initializer) error {
 			fic := flux.NewConfiguration(filter.FilterId())
@@ -162,7 +185,7 @@ func (d *DispatchServer) Init() error {
 			return err
 		}
 	}
-	// 5. Dynamic Filters
+	// 7. Dynamic Filters
 	dynFilters, err := dynamicFilters()
 	if nil != err {
 		return err
@@ -191,6 +214,13 @@ func (d *DispatchServer) Init() error {
 			return err
 		}
 	}
+	// 8. Authorize: 认证Filter在全部Filter注册后查找
+	for id, dis := range d.dispatchers {
+		config := NewWebListenerConfig(id)
+		if err := dis.SetAuthorizeConfig(NewAuthorizeConfig(config.Sub(ConfigKeyAuthorize))); nil != err {
+			return fmt.Errorf("web listener: %s, %w", id, err)
+		}
+	}
 	return nil
 }
 
@@ -213,12 +243,9 @@ func (d *DispatchServer) start() error {
 	}
 	logger.Info("SERVER:EVEN:STARTUP:OK")
 	// Discovery
+	// 事件队列由各Discovery并发写入，不主动关闭；通过ctx取消通知Discovery停止提交
 	endpoints := make(chan flux.EndpointEvent, 2)
 	services := make(chan flux.ServiceEvent, 2)
-	defer func() {
-		close(endpoints)
-		close(services)
-	}()
 	logger.Info("SERVER:EVEN:DISCOVERY:START")
 	ctx, canceled := context.WithCancel(context.Background())
 	defer canceled()
@@ -361,10 +388,7 @@ func (d *DispatchServer) onEndpointEvent(event flux.EndpointEvent) {
 		mvce.Update(ep.Version, &ep)
 		if register {
 			// 根据Endpoint注解属性，选择ListenServer来绑定
-			var listenerId = ListenerIdDefault
-			if anno, ok := ep.AnnotationEx(flux.EndpointAnnotationListenerSel); ok && anno.IsValid() {
-				listenerId = anno.GetString()
-			}
+			listenerId := ListenerIdOf(&ep)
 			if webListener, ok := d.WebListenerById(listenerId); ok {
 				logger.Infow("SERVER:EVENT:ENDPOINT:HTTP_HANDLER/"+listenerId, epvars...)
 				webListener.AddHandler(ep.HttpMethod, ep.HttpPattern, d.newEndpointHandler(mvce))
@@ -379,6 +403,10 @@ func (d *DispatchServer) onEndpointEvent(event flux.EndpointEvent) {
 	case flux.EventTypeRemoved:
 		logger.Infow("SERVER:EVENT:ENDPOINT:REMOVE", epvars...)
 		mvce.Delete(ep.Version)
+		// 全部版本已删除，注销路由
+		if mvce.IsEmpty() {
+			d.removeMVCEndpoint(&ep)
+		}
 	}
 }
 
@@ -429,7 +457,9 @@ func (d *DispatchServer) SetWebNotfoundHandler(nfh flux.WebHandlerFunc) {
 func (d *DispatchServer) AddWebListener(listenerID string, listener flux.WebListener) {
 	flux.AssertNotNil(listener, "<web-listener> must not nil")
 	flux.AssertNotEmpty(listenerID, "<web-listener-id> must not empty")
-	d.dispatchers[listenerID] = newDispatcher(listener)
+	dis := newDispatcher(listener)
+	listener.AddFilter(dis.observeFilter)
+	d.dispatchers[listenerID] = dis
 }
 
 // WebListenerById 返回ListenServer实例
@@ -454,6 +484,16 @@ func (d *DispatchServer) selectMVCEndpoint(endpoint *flux.EndpointSpec) (*flux.M
 	}
 }
 
+func (d *DispatchServer) removeMVCEndpoint(endpoint *flux.EndpointSpec) {
+	ext.RemoveEndpoint(ext.MakeEndpointKey(endpoint.HttpMethod, endpoint.HttpPattern))
+	listenerId := ListenerIdOf(endpoint)
+	if webListener, ok := d.WebListenerById(listenerId); ok {
+		logger.Infow("SERVER:EVENT:ENDPOINT:HTTP_HANDLER:REMOVE/"+listenerId,
+			"ep-method", endpoint.HttpMethod, "ep-pattern", endpoint.HttpPattern)
+		webListener.RemoveHandler(endpoint.HttpMethod, endpoint.HttpPattern)
+	}
+}
+
 func (d *DispatchServer) defaultListener() flux.WebListener {
 	count := len(d.dispatchers)
 	if count == 0 {
@@ -504,6 +544,14 @@ func (d *DispatchServer) syncEndpoint(srv *flux.ServiceSpec) {
 	}
 }
 
+// ListenerIdOf 根据Endpoint注解属性，返回绑定的ListenServer的ID
+func ListenerIdOf(ep *flux.EndpointSpec) string {
+	if anno, ok := ep.AnnotationEx(flux.EndpointAnnotationListenerSel); ok && anno.IsValid() {
+		return anno.GetString()
+	}
+	return ListenerIdDefault
+}
+
 func onInitializer(v interface{}, f func(initable flux.Initializer) error) error {
 	if init, ok := v.(flux.Initializer); ok {
 		return f(init)
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointEventRemoveRoute(t *testing.T) {
+	tester := assert.New(t)
+	webl := listener.New("routetest", flux.NewConfiguration("route_test"), nil)
+	dm := NewDispatcherManager(WithNewDispatcherOptions(webl))
+	newEvent := func(evt flux.EventType, version string) flux.EndpointEvent {
+		return flux.EndpointEvent{EventType: evt, Endpoint: flux.EndpointSpec{
+			Version: version, HttpMethod: "GET", HttpPattern: "/route/{id}", ServiceId: "route.test:get",
+			Attributes: flux.Attributes{}, Annotations: flux.Annotations{
+				flux.EndpointAnnotationListenerSel: "routetest",
+			},
+		}}
+	}
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		webl.ServeHTTP(recorder, httptest.NewRequest("GET", "/route/1", nil))
+		return recorder.Code
+	}
+	key := ext.MakeEndpointKey("GET", "/route/{id}")
+	dm.onEndpointEvent(newEvent(flux.EventTypeAdded, "v1"))
+	dm.onEndpointEvent(newEvent(flux.EventTypeAdded, "v2"))
+	tester.NotEqual(404, serve())
+	// 删除部分版本，保留路由
+	dm.onEndpointEvent(newEvent(flux.EventTypeRemoved, "v1"))
+	_, ok := ext.EndpointByKey(key)
+	tester.True(ok)
+	tester.NotEqual(404, serve())
+	// 删除全部版本，注销路由
+	dm.onEndpointEvent(newEvent(flux.EventTypeRemoved, "v2"))
+	_, ok = ext.EndpointByKey(key)
+	tester.False(ok)
+	tester.Equal(404, serve())
+	// 重新注册
+	dm.onEndpointEvent(newEvent(flux.EventTypeAdded, "v3"))
+	mvce, ok := ext.EndpointByKey(key)
+	tester.True(ok)
+	_, ok = mvce.Lookup("v3")
+	tester.True(ok)
+	tester.NotEqual(404, serve())
+}
+
+func TestRemoveStaticRouteFallbackToParam(t *testing.T) {
+	tester := assert.New(t)
+	webl := listener.New("routetest-static", flux.NewConfiguration("route_test"), nil)
+	handler := func(name string) flux.WebHandlerFunc {
+		return func(webex flux.WebContext) error {
+			return webex.Write(200, flux.MIMEApplicationOctetStream, []byte(name+":"+webex.PathVar("id")))
+		}
+	}
+	serve := func(uri string) (int, string) {
+		recorder := httptest.NewRecorder()
+		webl.ServeHTTP(recorder, httptest.NewRequest("GET", uri, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+	webl.AddHandler("GET", "/users/{id}", handler("param"))
+	webl.AddHandler("GET", "/users/me", handler("static"))
+	_, body := serve("/users/me")
+	tester.Equal("static:", body)
+	_, body = serve("/users/1")
+	tester.Equal("param:1", body)
+	// 删除静态路由后，请求由参数路由处理
+	webl.RemoveHandler("GET", "/users/me")
+	code, body := serve("/users/me")
+	tester.Equal(200, code)
+	tester.Equal("param:me", body)
+	// 删除参数路由后，请求按路由不存在处理
+	webl.RemoveHandler("GET", "/users/{id}")
+	code, _ = serve("/users/1")
+	tester.Equal(404, code)
+	// 重新注册
+	webl.AddHandler("GET", "/users/me", handler("static"))
+	_, body = serve("/users/me")
+	tester.Equal("static:", body)
+	code, _ = serve("/users/1")
+	tester.Equal(404, code)
+}
+
+func TestAddRouteWhileServing(t *testing.T) {
+	tester := assert.New(t)
+	webl := listener.New("routetest-batch", flux.NewConfiguration("route_test"), nil)
+	handler := func(webex flux.WebContext) error {
+		return webex.Write(200, flux.MIMEApplicationOctetStream, []byte(webex.URI()))
+	}
+	serve := func(uri string) int {
+		recorder := httptest.NewRecorder()
+		webl.ServeHTTP(recorder, httptest.NewRequest("GET", uri, nil))
+		return recorder.Code
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			serve("/batch/0")
+		}
+	}()
+	// 批量注册路由，与请求分发并发执行
+	for i := 0; i < 100; i++ {
+		webl.AddHandler("GET", "/batch/"+strconv.Itoa(i), handler)
+	}
+	<-done
+	tester.Equal(200, serve("/batch/0"))
+	tester.Equal(200, serve("/batch/99"))
+	tester.Equal(404, serve("/batch/100"))
+}
+
+func TestRouteMethodNotAllowed(t *testing.T) {
+	tester := assert.New(t)
+	webl := listener.New("routetest-method", flux.NewConfiguration("route_test"), nil)
+	webl.AddHandler("GET", "/users/{id}", func(webex flux.WebContext) error {
+		return webex.Write(200, flux.MIMEApplicationOctetStream, []byte(webex.PathVar("id")))
+	})
+	serve := func(method, uri string) int {
+		recorder := httptest.NewRecorder()
+		webl.ServeHTTP(recorder, httptest.NewRequest(method, uri, nil))
+		return recorder.Code
+	}
+	tester.Equal(200, serve("GET", "/users/1"))
+	// 路径匹配但方法不匹配
+	tester.Equal(405, serve("POST", "/users/1"))
+	tester.Equal(404, serve("POST", "/orders/1"))
+	// 注销路由后按路由不存在处理
+	webl.RemoveHandler("GET", "/users/{id}")
+	tester.Equal(404, serve("POST", "/users/1"))
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"io"
+	"net/http"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+)
+
+// streamBodyOf 响应数据体为数据流，且Endpoint未声明响应数据转换时，返回以流式透传的响应数据体
+func streamBodyOf(ctx flux.Context, resp *flux.ServeResponse) (io.ReadCloser, bool) {
+	if resp == nil || internal.HasResponseTransform(ctx.Endpoint()) {
+		return nil, false
+	}
+	body, ok := resp.Body.(io.ReadCloser)
+	return body, ok
+}
+
+// writeStream 将后端服务的响应数据流直接写入客户端，保留Content-Type和Content-Length；
+// 未声明Content-Length的响应，由Http服务以分块编码写入。响应数据流在写入完成或失败后关闭。
+func (d *Dispatcher) writeStream(ctx flux.Context, resp *flux.ServeResponse, body io.ReadCloser) {
+	defer body.Close()
+	header := ctx.ResponseWriter().Header()
+	source := resp.Headers.Clone()
+	if source == nil {
+		source = make(http.Header, 0)
+	}
+	toolkit.RemoveHopByHopHeaders(source)
+	contentType := source.Get(flux.HeaderContentType)
+	if contentType == "" {
+		contentType = flux.MIMEApplicationOctetStream
+	}
+	source.Del(flux.HeaderContentType)
+	for k, hv := range source {
+		for _, v := range hv {
+			header.Add(k, v)
+		}
+	}
+	if err := ctx.WriteStream(resp.StatusCode, contentType, body); nil != err {
+		ctx.Logger().Warnw("RESP-WRITER:STREAM:ERROR", "error", err)
+	} else {
+		ctx.Logger().Infow("RESP-WRITER:STREAM:COMPLETED", "content-type", contentType)
+	}
+}
@@ -0,0 +1,33 @@
+package toolkit
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HopByHopHeaders 逐跳Header，代理转发时不应透传
+var HopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// RemoveHopByHopHeaders 删除逐跳Header，以及Connection中声明的Header
+func RemoveHopByHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range HopByHopHeaders {
+		header.Del(name)
+	}
+}
@@ -0,0 +1,93 @@
+package toolkit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// ParseCIDRs 解析CIDR列表；支持不带掩码的单个IP地址
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); "" == v {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip address: %s", v)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				v = ip4.String() + "/32"
+			} else {
+				v = ip.String() + "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(v)
+		if nil != err {
+			return nil, fmt.Errorf("invalid cidr: %s, error: %w", v, err)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets, nil
+}
+
+// ContainsIP 判断IP是否属于任一网段
+func ContainsIP(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHostIP 解析 host:port 或 host 格式地址中的IP
+func ParseHostIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); nil == err {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
+
+// ResolveClientIP 解析真实客户端IP。仅当直连地址属于可信代理时，才使用 X-Forwarded-For/X-Real-IP：
+// 从 X-Forwarded-For 右侧向左跳过可信代理，返回第一个非可信代理地址；解析失败时返回直连地址。
+func ResolveClientIP(remoteAddr string, header http.Header, trusted []*net.IPNet) string {
+	peer := ParseHostIP(remoteAddr)
+	if peer == nil || !ContainsIP(trusted, peer) {
+		return remoteAddr
+	}
+	hops := make([]string, 0, 4)
+	for _, v := range header.Values(HeaderXForwardedFor) {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) == 0 {
+		if ip := ParseHostIP(header.Get(HeaderXRealIP)); ip != nil {
+			return ip.String()
+		}
+		return remoteAddr
+	}
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := ParseHostIP(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !ContainsIP(trusted, ip) {
+			break
+		}
+	}
+	return client.String()
+}
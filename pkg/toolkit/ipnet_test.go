@@ -0,0 +1,43 @@
+package toolkit
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	tester := assert.New(t)
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1"})
+	tester.NoError(err)
+	cases := []struct {
+		remote string
+		xff    string
+		xrip   string
+		expect string
+	}{
+		// 非可信代理，忽略转发头
+		{remote: "1.1.1.1:1000", xff: "2.2.2.2", expect: "1.1.1.1:1000"},
+		{remote: "10.0.0.1:1000", xff: "2.2.2.2", expect: "2.2.2.2"},
+		// 跳过可信代理；客户端伪造的最左侧地址被忽略
+		{remote: "10.0.0.1:1000", xff: "9.9.9.9, 3.3.3.3, 192.168.1.1", expect: "3.3.3.3"},
+		{remote: "10.0.0.1:1000", xff: "10.0.0.3, 10.0.0.2", expect: "10.0.0.3"},
+		{remote: "10.0.0.1:1000", xrip: "4.4.4.4", expect: "4.4.4.4"},
+		{remote: "10.0.0.1:1000", expect: "10.0.0.1:1000"},
+	}
+	for _, c := range cases {
+		header := http.Header{}
+		if c.xff != "" {
+			header.Set(HeaderXForwardedFor, c.xff)
+		}
+		if c.xrip != "" {
+			header.Set(HeaderXRealIP, c.xrip)
+		}
+		tester.Equal(c.expect, ResolveClientIP(c.remote, header, trusted), c.xff)
+	}
+	_, err = ParseCIDRs([]string{"10.0.0.0/33"})
+	tester.Error(err)
+}
@@ -0,0 +1,195 @@
+package toolkit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath 简化的JSONPath表达式，支持：$根节点，.field 字段，[n] 数组下标，[*] 全部数组元素。
+// 例如：$.data.items[0].name，$.data.items[*].id
+type JSONPath []jsonPathToken
+
+type jsonPathToken struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ParseJSONPath 解析JSONPath表达式
+func ParseJSONPath(expr string) (JSONPath, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("jsonpath must starts with '$', was: %s", expr)
+	}
+	path := make(JSONPath, 0, 4)
+	for rest := expr[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("jsonpath has empty field, was: %s", expr)
+			}
+			path = append(path, jsonPathToken{key: key})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath has unclosed '[', was: %s", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			if inner == "*" {
+				path = append(path, jsonPathToken{isIndex: true, wildcard: true})
+			} else if idx, err := strconv.Atoi(inner); nil == err && idx >= 0 {
+				path = append(path, jsonPathToken{isIndex: true, index: idx})
+			} else if key := strings.Trim(inner, `'"`); len(inner) > 2 && key != inner {
+				path = append(path, jsonPathToken{key: key})
+			} else {
+				return nil, fmt.Errorf("jsonpath has invalid index: %s, was: %s", inner, expr)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath has unexpected char: %c, was: %s", rest[0], expr)
+		}
+	}
+	return path, nil
+}
+
+// IsRoot 判断是否为根节点路径
+func (p JSONPath) IsRoot() bool {
+	return len(p) == 0
+}
+
+// Get 查询路径对应的值；包含[*]的路径返回匹配值的列表
+func (p JSONPath) Get(node interface{}) (interface{}, bool) {
+	if len(p) == 0 {
+		return node, true
+	}
+	token, next := p[0], p[1:]
+	if token.isIndex {
+		array, ok := node.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		if token.wildcard {
+			out := make([]interface{}, 0, len(array))
+			for _, elem := range array {
+				if v, ok := next.Get(elem); ok {
+					out = append(out, v)
+				}
+			}
+			return out, true
+		}
+		if token.index >= len(array) {
+			return nil, false
+		}
+		return next.Get(array[token.index])
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	child, ok := object[token.key]
+	if !ok {
+		return nil, false
+	}
+	return next.Get(child)
+}
+
+// Set 设置路径对应的值，自动创建不存在的中间对象；返回设置后的根节点
+func (p JSONPath) Set(node interface{}, value interface{}) (interface{}, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	token, next := p[0], p[1:]
+	if token.isIndex {
+		array, ok := node.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("jsonpath set: node is not an array")
+		}
+		if token.wildcard {
+			for i := range array {
+				v, err := next.Set(array[i], value)
+				if nil != err {
+					return nil, err
+				}
+				array[i] = v
+			}
+			return array, nil
+		}
+		if token.index >= len(array) {
+			return nil, fmt.Errorf("jsonpath set: index out of range: %d", token.index)
+		}
+		v, err := next.Set(array[token.index], value)
+		if nil != err {
+			return nil, err
+		}
+		array[token.index] = v
+		return array, nil
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		if node != nil {
+			return nil, fmt.Errorf("jsonpath set: node is not an object, field: %s", token.key)
+		}
+		object = make(map[string]interface{}, 4)
+	}
+	v, err := next.Set(object[token.key], value)
+	if nil != err {
+		return nil, err
+	}
+	object[token.key] = v
+	return object, nil
+}
+
+// Remove 删除路径对应的值；返回删除后的根节点，以及是否删除了任意值
+func (p JSONPath) Remove(node interface{}) (interface{}, bool) {
+	if len(p) == 0 {
+		return nil, true
+	}
+	token, next := p[0], p[1:]
+	if token.isIndex {
+		array, ok := node.([]interface{})
+		if !ok {
+			return node, false
+		}
+		if token.wildcard && len(next) == 0 {
+			return array[:0], len(array) > 0
+		}
+		if !token.wildcard && token.index >= len(array) {
+			return node, false
+		}
+		if len(next) == 0 {
+			return append(array[:token.index], array[token.index+1:]...), true
+		}
+		removed := false
+		for i := range array {
+			if token.wildcard || i == token.index {
+				if v, ok := next.Remove(array[i]); ok {
+					array[i], removed = v, true
+				}
+			}
+		}
+		return array, removed
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return node, false
+	}
+	child, ok := object[token.key]
+	if !ok {
+		return node, false
+	}
+	if len(next) == 0 {
+		delete(object, token.key)
+		return object, true
+	}
+	v, removed := next.Remove(child)
+	object[token.key] = v
+	return object, removed
+}
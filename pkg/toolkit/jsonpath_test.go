@@ -0,0 +1,52 @@
+package toolkit
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tester := assert.New(t)
+	path, err := ParseJSONPath("$.data.items[0]['a.b']")
+	tester.NoError(err)
+	tester.Equal(4, len(path))
+	path, err = ParseJSONPath("$")
+	tester.NoError(err)
+	tester.True(path.IsRoot())
+	for _, expr := range []string{"data", "$..a", "$.a[", "$.a[-1]", "$a"} {
+		_, err = ParseJSONPath(expr)
+		tester.Error(err, expr)
+	}
+}
+
+func TestJSONPathGetSetRemove(t *testing.T) {
+	tester := assert.New(t)
+	node := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": 1, "name": "a"},
+			map[string]interface{}{"id": 2, "name": "b"},
+		},
+	}
+	path, _ := ParseJSONPath("$.items[*].id")
+	v, ok := path.Get(node)
+	tester.True(ok)
+	tester.Equal([]interface{}{1, 2}, v)
+	path, _ = ParseJSONPath("$.items[1].name")
+	v, ok = path.Get(node)
+	tester.True(ok)
+	tester.Equal("b", v)
+	path, _ = ParseJSONPath("$.meta.source")
+	out, err := path.Set(node, "gw")
+	tester.NoError(err)
+	tester.Equal("gw", out.(map[string]interface{})["meta"].(map[string]interface{})["source"])
+	path, _ = ParseJSONPath("$.items[0]")
+	out, ok = path.Remove(out)
+	tester.True(ok)
+	tester.Equal(1, len(out.(map[string]interface{})["items"].([]interface{})))
+	path, _ = ParseJSONPath("$.missing.field")
+	_, ok = path.Remove(out)
+	tester.False(ok)
+}
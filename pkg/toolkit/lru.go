@@ -0,0 +1,108 @@
+package toolkit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRUCache 支持条目数量和数据大小限制的LRU缓存；每个条目可设置独立的过期时间
+type LRUCache struct {
+	capacity int
+	maxBytes int64
+	bytes    int64
+	ll       *list.List
+	items    map[string]*list.Element
+	mutex    sync.Mutex
+}
+
+type lruEntry struct {
+	key      string
+	value    interface{}
+	size     int64
+	expireAt time.Time
+}
+
+// NewLRUCache 创建LRU缓存；capacity为最大条目数量，maxBytes为最大数据大小，小于等于0表示不限制数据大小
+func NewLRUCache(capacity int, maxBytes int64) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LRUCache{
+		capacity: capacity,
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// Get 查询缓存值；条目不存在或已过期时返回false
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set 设置缓存值；size为条目数据大小，ttl小于等于0表示永不过期。超出限制时淘汰最久未使用的条目
+func (c *LRUCache) Set(key string, value interface{}, size int64, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		c.bytes += size - entry.size
+		entry.value, entry.size, entry.expireAt = value, size, expireAt
+		c.ll.MoveToFront(elem)
+	} else {
+		c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, size: size, expireAt: expireAt})
+		c.bytes += size
+	}
+	for c.ll.Len() > c.capacity || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Remove 删除缓存条目
+func (c *LRUCache) Remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len 返回缓存条目数量
+func (c *LRUCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ll.Len()
+}
+
+// Bytes 返回缓存数据大小
+func (c *LRUCache) Bytes() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.bytes
+}
+
+func (c *LRUCache) removeElement(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*lruEntry)
+	delete(c.items, entry.key)
+	c.bytes -= entry.size
+}
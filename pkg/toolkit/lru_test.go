@@ -0,0 +1,42 @@
+package toolkit
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	tester := assert.New(t)
+	cache := NewLRUCache(2, 10)
+	cache.Set("a", 1, 4, 0)
+	cache.Set("b", 2, 4, 0)
+	_, ok := cache.Get("a")
+	tester.True(ok)
+	// evict least recently used: b
+	cache.Set("c", 3, 4, 0)
+	_, ok = cache.Get("b")
+	tester.False(ok)
+	tester.Equal(2, cache.Len())
+	// evict by bytes
+	cache.Set("d", 4, 8, 0)
+	tester.Equal(1, cache.Len())
+	tester.Equal(int64(8), cache.Bytes())
+	// entry too large
+	cache.Set("e", 5, 11, 0)
+	_, ok = cache.Get("e")
+	tester.False(ok)
+}
+
+func TestLRUCacheExpiration(t *testing.T) {
+	tester := assert.New(t)
+	cache := NewLRUCache(4, 0)
+	cache.Set("a", 1, 1, time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	_, ok := cache.Get("a")
+	tester.False(ok)
+	tester.Equal(0, cache.Len())
+}
@@ -34,3 +34,9 @@ func MatchOn(expects []string, in string, tf func(in, expect string) bool) bool
 func stringEqual(v, expect string) bool {
 	return expect == v
 }
+
+// LowerKey 返回配置映射键名的规范形式。配置加载时键名统一转换为小写，
+// 按名称构建和查找配置映射时均使用此函数，以保证名称大小写不敏感。
+func LowerKey(name string) string {
+	return strings.ToLower(name)
+}
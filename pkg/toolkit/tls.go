@@ -0,0 +1,41 @@
+package toolkit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// NewTLSConfig 创建客户端TLS配置；指定CA证书文件时追加到系统证书池，
+// 指定客户端证书和私钥文件时加载客户端证书(mTLS)。
+func NewTLSConfig(caFiles []string, certFile, keyFile, serverName string, insecure bool) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: insecure,
+	}
+	if len(caFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if nil != err || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range caFiles {
+			pem, err := ioutil.ReadFile(file)
+			if nil != err {
+				return nil, fmt.Errorf("read ca file: %s, error: %w", file, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in ca file: %s", file)
+			}
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if nil != err {
+			return nil, fmt.Errorf("load client certificate, error: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
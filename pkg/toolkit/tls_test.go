@@ -0,0 +1,36 @@
+package toolkit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	tester := assert.New(t)
+	config, err := NewTLSConfig(nil, "", "", "api.example.com", true)
+	tester.NoError(err)
+	tester.Equal("api.example.com", config.ServerName)
+	tester.True(config.InsecureSkipVerify)
+	tester.Nil(config.RootCAs)
+	tester.Empty(config.Certificates)
+	dir, err := ioutil.TempDir("", "fluxgo-tls")
+	tester.NoError(err)
+	defer os.RemoveAll(dir)
+	// CA文件不存在
+	_, err = NewTLSConfig([]string{filepath.Join(dir, "not-exists.pem")}, "", "", "", false)
+	tester.Error(err)
+	// CA文件不包含证书
+	invalid := filepath.Join(dir, "invalid.pem")
+	tester.NoError(ioutil.WriteFile(invalid, []byte("invalid"), 0600))
+	_, err = NewTLSConfig([]string{invalid}, "", "", "", false)
+	tester.Error(err)
+	// 客户端证书加载失败
+	_, err = NewTLSConfig(nil, invalid, invalid, "", false)
+	tester.Error(err)
+}
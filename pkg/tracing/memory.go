@@ -0,0 +1,26 @@
+package tracing
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	TypeIdMemoryExporter = "memory"
+)
+
+func init() {
+	ext.RegisterTraceExporter(TypeIdOTLPExporter, NewOTLPExporter())
+	ext.RegisterTraceExporter(TypeIdMemoryExporter, NewMemoryExporter())
+}
+
+// MemoryExporter 将Span数据保存在内存中的导出器；用于测试和调试
+type MemoryExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{
+		InMemoryExporter: tracetest.NewInMemoryExporter(),
+	}
+}
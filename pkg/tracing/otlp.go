@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	TypeIdOTLPExporter = "otlp"
+)
+
+const (
+	ConfigKeyOTLPEndpoint = "endpoint"
+	ConfigKeyOTLPHeaders  = "headers"
+	ConfigKeyOTLPTimeout  = "timeout"
+)
+
+var (
+	_ sdktrace.SpanExporter = new(OTLPExporter)
+	_ flux.Initializer      = new(OTLPExporter)
+)
+
+// OTLPExporter 基于OTLP/HTTP协议导出Span数据到OpenTelemetry Collector；由OpenTelemetry SDK的otlptracehttp实现
+type OTLPExporter struct {
+	endpoint string
+	timeout  time.Duration
+	exporter *otlptrace.Exporter
+}
+
+func NewOTLPExporter() *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: "http://localhost:4318/v1/traces",
+		timeout:  time.Second * 10,
+	}
+}
+
+func (e *OTLPExporter) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyOTLPEndpoint: e.endpoint,
+		ConfigKeyOTLPTimeout:  e.timeout,
+	})
+	e.endpoint = config.GetString(ConfigKeyOTLPEndpoint)
+	e.timeout = config.GetDuration(ConfigKeyOTLPTimeout)
+	endpoint, err := url.Parse(e.endpoint)
+	if nil != err || endpoint.Host == "" {
+		return fmt.Errorf("otlp: invalid endpoint: %s", e.endpoint)
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.Host),
+		otlptracehttp.WithURLPath(endpoint.Path),
+		otlptracehttp.WithHeaders(config.GetStringMapString(ConfigKeyOTLPHeaders)),
+		otlptracehttp.WithTimeout(e.timeout),
+	}
+	if endpoint.Scheme != "https" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if nil != err {
+		return fmt.Errorf("otlp: create exporter, error: %w", err)
+	}
+	e.exporter = exporter
+	logger.Infow("TRACING:EXPORTER:OTLP:INIT", "endpoint", e.endpoint)
+	return nil
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if e.exporter == nil {
+		return errors.New("otlp: exporter not initialized")
+	}
+	return e.exporter.ExportSpans(ctx, spans)
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	if e.exporter == nil {
+		return nil
+	}
+	return e.exporter.Shutdown(ctx)
+}
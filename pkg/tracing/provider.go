@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+const (
+	ConfigKeyEnable      = "enable"
+	ConfigKeyServiceName = "service_name"
+	ConfigKeyExporter    = "exporter"
+	ConfigKeyExporters   = "exporters"
+	ConfigKeySampleRatio = "sample_ratio"
+	ConfigKeySyncExport  = "sync_export"
+)
+
+var (
+	_ flux.Initializer = new(TraceProvider)
+	_ flux.Shutdowner  = new(TraceProvider)
+	_ flux.Orderer     = new(TraceProvider)
+)
+
+// TraceProvider 链路追踪初始化组件；根据配置选择导出器，并设置为全局TracerProvider
+type TraceProvider struct {
+	provider *sdktrace.TracerProvider
+}
+
+func NewTraceProvider() *TraceProvider {
+	return new(TraceProvider)
+}
+
+func (p *TraceProvider) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyEnable:      false,
+		ConfigKeyServiceName: "fluxgo",
+		ConfigKeyExporter:    TypeIdOTLPExporter,
+		ConfigKeySampleRatio: 1.0,
+		ConfigKeySyncExport:  false,
+	})
+	if !config.GetBool(ConfigKeyEnable) {
+		logger.Info("TRACING:INIT:DISABLED")
+		return nil
+	}
+	id := config.GetString(ConfigKeyExporter)
+	exporter, ok := ext.TraceExporterById(id)
+	if !ok {
+		return fmt.Errorf("tracing: exporter not found, id: %s", id)
+	}
+	if init, ok := exporter.(flux.Initializer); ok {
+		if err := init.OnInit(config.Sub(ConfigKeyExporters).Sub(id)); nil != err {
+			return err
+		}
+	}
+	name := config.GetString(ConfigKeyServiceName)
+	ratio := config.GetFloat64(ConfigKeySampleRatio)
+	var processor sdktrace.SpanProcessor
+	if config.GetBool(ConfigKeySyncExport) {
+		processor = sdktrace.NewSimpleSpanProcessor(exporter)
+	} else {
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	}
+	p.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(name))),
+	)
+	otel.SetTracerProvider(p.provider)
+	logger.Infow("TRACING:INIT:OK", "exporter", id, "service-name", name, "sample-ratio", ratio)
+	return nil
+}
+
+// Order 在其它组件停止后再停止，确保导出全部Span数据
+func (p *TraceProvider) Order() int {
+	return math.MaxInt32
+}
+
+func (p *TraceProvider) OnShutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	return p.provider.Shutdown(ctx)
+}
@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/spf13/cast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	InstrumentationName = "github.com/bytepowered/fluxgo"
+)
+
+const (
+	AttrListenerId  = attribute.Key("flux.listener.id")
+	AttrApplication = attribute.Key("flux.application")
+	AttrVersion     = attribute.Key("flux.endpoint.version")
+	AttrServiceId   = attribute.Key("flux.service.id")
+	AttrErrorCode   = attribute.Key("flux.error.code")
+	AttrRequestId   = attribute.Key("flux.request.id")
+)
+
+func init() {
+	// W3C traceparent/tracestate, baggage
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+}
+
+// contextSetter 支持替换请求域Context的flux.Context实现
+type contextSetter interface {
+	SetContext(ctx context.Context)
+}
+
+// Tracer 返回网关的Tracer；未启用链路追踪时，返回的Tracer不记录任何Span
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// StartServerSpan 从请求Header中提取上游的链路信息，创建请求的Server Span
+func StartServerSpan(webex flux.WebContext) (context.Context, trace.Span) {
+	request := webex.Request()
+	parent := otel.GetTextMapPropagator().Extract(webex.Context(), propagation.HeaderCarrier(request.Header))
+	return Tracer().Start(parent, request.Method+" "+request.URL.Path,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(request.Method),
+			semconv.HTTPTargetKey.String(request.RequestURI),
+			semconv.HTTPHostKey.String(request.Host),
+			semconv.HTTPClientIPKey.String(webex.RemoteAddr()),
+			AttrRequestId.String(webex.RequestId()),
+		),
+	)
+}
+
+// EndServerSpan 记录响应状态码，并结束Server Span
+func EndServerSpan(span trace.Span, status int) {
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, strconv.Itoa(status))
+	}
+	span.End()
+}
+
+// SetServerRoute 使用Endpoint的路由信息，更新当前请求的Server Span
+func SetServerRoute(ctx flux.Context) {
+	span := trace.SpanFromContext(ctx.Context())
+	if !span.IsRecording() {
+		return
+	}
+	pattern, method, version := ctx.Exposed()
+	span.SetName(method + " " + pattern)
+	listener := ""
+	if webl := ctx.WebListener(); webl != nil {
+		listener = webl.ListenerId()
+	}
+	span.SetAttributes(
+		semconv.HTTPRouteKey.String(pattern),
+		AttrListenerId.String(listener),
+		AttrApplication.String(ctx.Application()),
+		AttrVersion.String(version),
+		AttrServiceId.String(ctx.ServiceID()),
+	)
+}
+
+// StartSpan 创建当前请求的子Span，并将子Span绑定到请求Context；
+// 返回的结束函数用于结束子Span，并恢复请求Context。
+func StartSpan(ctx flux.Context, name string, opts ...trace.SpanStartOption) (trace.Span, func()) {
+	parent := ctx.Context()
+	spanctx, span := Tracer().Start(parent, name, opts...)
+	setter, ok := ctx.(contextSetter)
+	if !ok {
+		return span, func() { span.End() }
+	}
+	setter.SetContext(spanctx)
+	return span, func() {
+		setter.SetContext(parent)
+		span.End()
+	}
+}
+
+// SetSpanError 记录ServeError到Span
+func SetSpanError(span trace.Span, serr *flux.ServeError) {
+	if serr == nil || !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		AttrErrorCode.String(cast.ToString(serr.ErrorCode)),
+		semconv.HTTPStatusCodeKey.Int(serr.StatusCode),
+	)
+	if serr.CauseError != nil {
+		span.RecordError(serr.CauseError)
+	}
+	span.SetStatus(codes.Error, serr.Message)
+}
+
+// InjectHeader 向Http请求Header注入当前链路信息
+func InjectHeader(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectAttachments 向RPC调用的Attachment注入当前链路信息
+func InjectAttachments(ctx context.Context, attachments map[string]interface{}) {
+	carrier := make(propagation.MapCarrier, 2)
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for k, v := range carrier {
+		attachments[k] = v
+	}
+}
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	testTraceId  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentId = "00f067aa0ba902b7"
+)
+
+type testWebContext struct {
+	flux.WebContext
+	ctx     context.Context
+	request *http.Request
+}
+
+func (c *testWebContext) Context() context.Context { return c.ctx }
+func (c *testWebContext) Request() *http.Request   { return c.request }
+func (c *testWebContext) RemoteAddr() string       { return c.request.RemoteAddr }
+func (c *testWebContext) RequestId() string        { return "test-id" }
+
+func setupMemoryProvider() *MemoryExporter {
+	exporter := NewMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+}
+
+func TestTracingPropagation(t *testing.T) {
+	tester := assert.New(t)
+	exporter := setupMemoryProvider()
+	request := httptest.NewRequest("GET", "/trace", nil)
+	request.Header.Set("traceparent", "00-"+testTraceId+"-"+testParentId+"-01")
+	webex := &testWebContext{ctx: context.Background(), request: request}
+	// server span
+	spanctx, server := StartServerSpan(webex)
+	webex.ctx = spanctx
+	ctx := internal.NewContext()
+	ctx.Reset(webex, &flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/trace"})
+	// child span
+	span, end := StartSpan(ctx, "transporter:http")
+	tester.Equal(span.SpanContext().SpanID(), trace.SpanContextFromContext(ctx.Context()).SpanID())
+	header := make(http.Header)
+	InjectHeader(ctx.Context(), header)
+	attachments := make(map[string]interface{})
+	InjectAttachments(ctx.Context(), attachments)
+	SetSpanError(span, &flux.ServeError{StatusCode: 502, ErrorCode: flux.ErrorCodeGatewayTransporter, Message: "failed"})
+	end()
+	tester.Equal(server.SpanContext().SpanID(), trace.SpanContextFromContext(ctx.Context()).SpanID())
+	EndServerSpan(server, 200)
+	// propagation
+	expected := "00-" + testTraceId + "-" + span.SpanContext().SpanID().String() + "-01"
+	tester.Equal(expected, header.Get("traceparent"))
+	tester.Equal(expected, attachments["traceparent"])
+	// exported
+	spans := exporter.GetSpans()
+	tester.Equal(2, len(spans))
+	tester.Equal("transporter:http", spans[0].Name)
+	tester.Equal(codes.Error, spans[0].Status.Code)
+	tester.Equal(server.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	tester.Equal(testTraceId, spans[1].SpanContext.TraceID().String())
+	tester.Equal(testParentId, spans[1].Parent.SpanID().String())
+}
+
+func TestOTLPExporter(t *testing.T) {
+	tester := assert.New(t)
+	received := new(coltracepb.ExportTraceServiceRequest)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		tester.Equal("/v1/traces", r.URL.Path)
+		tester.Equal("application/x-protobuf", r.Header.Get("Content-Type"))
+		tester.Equal("test-token", r.Header.Get("X-Token"))
+		tester.Nil(proto.Unmarshal(data, received))
+	}))
+	defer collector.Close()
+	exporter := NewOTLPExporter()
+	tester.Nil(exporter.OnInit(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyOTLPEndpoint: collector.URL + "/v1/traces",
+		ConfigKeyOTLPHeaders:  map[string]interface{}{"X-Token": "test-token"},
+	})))
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer(InstrumentationName).Start(context.Background(), "otlp-test")
+	span.SetAttributes(AttrServiceId.String("test:service"))
+	span.End()
+	tester.Nil(provider.Shutdown(context.Background()))
+	resources := received.GetResourceSpans()
+	tester.Equal(1, len(resources))
+	scope := resources[0].GetScopeSpans()[0]
+	tester.Equal(InstrumentationName, scope.GetScope().GetName())
+	spans := scope.GetSpans()
+	tester.Equal(1, len(spans))
+	tester.Equal("otlp-test", spans[0].GetName())
+	tester.Equal(span.SpanContext().TraceID().String(), hex.EncodeToString(spans[0].GetTraceId()))
+	tester.Equal(string(AttrServiceId), spans[0].GetAttributes()[0].GetKey())
+	// 未初始化的导出器
+	tester.Error(NewOTLPExporter().ExportSpans(context.Background(), nil))
+	tester.Error(NewOTLPExporter().OnInit(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyOTLPEndpoint: "localhost",
+	})))
+}
@@ -0,0 +1,47 @@
+package transporter
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+)
+
+// RemainingTimeout 返回Context截止时间的剩余时长；Context未设置截止时间时，返回false
+func RemainingTimeout(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	if remaining := time.Until(deadline); remaining > 0 {
+		return remaining, true
+	}
+	return 0, true
+}
+
+// FormatTimeout 格式化超时时长为毫秒数字符串，用于向后端服务传递剩余超时时长
+func FormatTimeout(timeout time.Duration) string {
+	return strconv.FormatInt(timeout.Milliseconds(), 10)
+}
+
+// IsRequestTimeout 判断网关请求是否已超出截止时间
+func IsRequestTimeout(ctx flux.Context) bool {
+	if ctx.Context().Err() == context.DeadlineExceeded {
+		return true
+	}
+	deadline, ok := ctx.Context().Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+// NewRequestTimeoutError 构建网关请求超时的错误
+func NewRequestTimeoutError(ctx flux.Context) *flux.ServeError {
+	return &flux.ServeError{
+		StatusCode: flux.StatusTimeout,
+		ErrorCode:  flux.ErrorCodeRequestTimeout,
+		Message:    flux.ErrorMessageRequestTimeout,
+		CauseError: ctx.Context().Err(),
+	}
+}
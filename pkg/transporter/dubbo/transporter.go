@@ -23,6 +23,8 @@ import (
 	"github.com/bytepowered/fluxgo/pkg/ext"
 	"github.com/bytepowered/fluxgo/pkg/flux"
 	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/tracing"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
 	jsoniter "github.com/json-iterator/go"
 )
 
@@ -41,6 +43,8 @@ const (
 var (
 	ErrDecodeInvalidHeaders = errors.New(flux.ErrorMessageTransportDubboDecodeInvalidHeader)
 	ErrDecodeInvalidStatus  = errors.New(flux.ErrorMessageTransportDubboDecodeInvalidStatus)
+	// 超出单次调用超时时长
+	errInvokeTimeout = errors.New("TRANSPORTER:DUBBO:INVOKE/timeout")
 )
 
 var (
@@ -265,6 +269,14 @@ func (b *RpcTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*
 			CauseError: err,
 		}
 	}
+	if attachments == nil {
+		attachments = make(map[string]interface{}, 4)
+	}
+	// 向后端服务传递网关请求的剩余超时时长
+	if remaining, ok := transporter.RemainingTimeout(ctx.Context()); ok {
+		attachments[flux.HeaderXRequestTimeout] = transporter.FormatTimeout(remaining)
+	}
+	tracing.InjectAttachments(ctx.Context(), attachments)
 	// Invoke
 	if b.trace {
 		args, _ := _json.MarshalToString(map[string]interface{}{
@@ -292,6 +304,10 @@ func (b *RpcTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*
 	}
 	select {
 	case <-ctx.Context().Done():
+		if transporter.IsRequestTimeout(ctx) {
+			trace.Info("TRANSPORTER:DUBBO:INVOKE/timeout")
+			return nil, transporter.NewRequestTimeoutError(ctx)
+		}
 		trace.Info("TRANSPORTER:DUBBO:INVOKE/canceled")
 		return nil, &flux.ServeError{
 			StatusCode: flux.StatusBadRequest,
@@ -328,8 +344,15 @@ func (b *RpcTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*
 
 func (b *RpcTransporter) invoke0(ctx flux.Context, service flux.ServiceSpec, types []string, values, attachments interface{}) (interface{}, map[string]interface{}, *flux.ServeError) {
 	generic := b.LoadGenericService(&service)
+	timeout, bounded := invokeTimeout(ctx.Context(), service)
+	if att, ok := attachments.(map[string]interface{}); ok && bounded {
+		att[constant.TIMEOUT_KEY] = transporter.FormatTimeout(timeout)
+	}
 	goctx := context.WithValue(ctx.Context(), constant.AttachmentKey, attachments)
-	ret, att, cause := b.invokeFunc(goctx, []interface{}{service.Method, types, values}, generic)
+	ret, att, cause := b.invokeWithDeadline(goctx, timeout, bounded, []interface{}{service.Method, types, values}, generic)
+	if cause == errInvokeTimeout {
+		return nil, nil, transporter.NewRequestTimeoutError(ctx)
+	}
 	if cause != nil {
 		return nil, nil, &flux.ServeError{
 			StatusCode: flux.StatusBadGateway,
@@ -341,6 +364,50 @@ func (b *RpcTransporter) invoke0(ctx flux.Context, service flux.ServiceSpec, typ
 	return ret, att, nil
 }
 
+// invokeWithDeadline 执行Dubbo泛调用；Dubbo调用不响应Context取消，调用在独立协程中执行，
+// 超出单次调用超时时长时返回 errInvokeTimeout 错误。后端调用本身受Reference级别的超时配置约束，不会长期遗留协程。
+func (b *RpcTransporter) invokeWithDeadline(goctx context.Context, timeout time.Duration, bounded bool, args []interface{}, generic common.RPCService) (interface{}, map[string]interface{}, error) {
+	if err := goctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if !bounded {
+		return b.invokeFunc(goctx, args, generic)
+	}
+	if timeout <= 0 {
+		return nil, nil, errInvokeTimeout
+	}
+	type result struct {
+		ret interface{}
+		att map[string]interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ret, att, err := b.invokeFunc(goctx, args, generic)
+		done <- result{ret: ret, att: att, err: err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.ret, r.att, r.err
+	case <-goctx.Done():
+		return nil, nil, goctx.Err()
+	case <-timer.C:
+		return nil, nil, errInvokeTimeout
+	}
+}
+
+// invokeTimeout 单次调用的超时时长，取网关请求剩余时长与Reference超时配置的较小值；均未设置时返回false
+func invokeTimeout(goctx context.Context, service flux.ServiceSpec) (time.Duration, bool) {
+	timeout, err := time.ParseDuration(service.Annotation(flux.ServiceAnnotationRpcTimeout).GetString())
+	bounded := err == nil && timeout > 0
+	if remaining, ok := transporter.RemainingTimeout(goctx); ok && (!bounded || remaining < timeout) {
+		return remaining, true
+	}
+	return timeout, bounded
+}
+
 // LoadGenericService create and cache dubbo generic service
 func (b *RpcTransporter) LoadGenericService(service *flux.ServiceSpec) common.RPCService {
 	b.servmx.Lock()
@@ -1,8 +1,15 @@
 package dubbo
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
 	"testing"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHasproto(t *testing.T) {
@@ -15,3 +22,56 @@ func TestHasproto(t *testing.T) {
 	tester.False(hasproto("abc.com:99"))
 	tester.False(hasproto("127.0.0.1"))
 }
+
+func TestInvokeWithDeadline(t *testing.T) {
+	tester := assert.New(t)
+	invoked := 0
+	tr := NewTransporterWith(WithGenericInvokeFunc(func(ctx context.Context, args []interface{}, rpc common.RPCService) (interface{}, map[string]interface{}, error) {
+		invoked++
+		_, ok := ctx.Deadline()
+		tester.True(ok)
+		return "ok", nil, nil
+	})).(*RpcTransporter)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ret, _, err := tr.invokeWithDeadline(ctx, time.Second, true, nil, nil)
+	tester.NoError(err)
+	tester.Equal("ok", ret)
+	tester.Equal(1, invoked)
+	// 截止时间已到达，不再发起调用
+	expired, cancel2 := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel2()
+	_, _, err = tr.invokeWithDeadline(expired, 0, true, nil, nil)
+	tester.Equal(context.DeadlineExceeded, err)
+	tester.Equal(1, invoked)
+}
+
+func TestInvokeTimeoutShorterThanReference(t *testing.T) {
+	tester := assert.New(t)
+	tr := NewTransporterWith(WithGenericInvokeFunc(func(ctx context.Context, args []interface{}, rpc common.RPCService) (interface{}, map[string]interface{}, error) {
+		time.Sleep(time.Millisecond * 300)
+		return "ok", nil, nil
+	})).(*RpcTransporter)
+	service := flux.ServiceSpec{Annotations: flux.Annotations{flux.ServiceAnnotationRpcTimeout: "1s"}}
+	// 网关请求剩余时长小于Reference超时配置
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	timeout, bounded := invokeTimeout(ctx, service)
+	tester.True(bounded)
+	tester.True(timeout <= time.Millisecond*50)
+	start := time.Now()
+	_, _, err := tr.invokeWithDeadline(ctx, timeout, bounded, nil, nil)
+	tester.Error(err)
+	tester.True(time.Since(start) < time.Millisecond*300)
+	// Reference超时配置小于网关请求剩余时长
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	timeout, bounded = invokeTimeout(ctx2, flux.ServiceSpec{Annotations: flux.Annotations{flux.ServiceAnnotationRpcTimeout: "100ms"}})
+	tester.True(bounded)
+	tester.Equal(time.Millisecond*100, timeout)
+	_, _, err = tr.invokeWithDeadline(ctx2, timeout, bounded, nil, nil)
+	tester.Equal(errInvokeTimeout, err)
+	// 均未设置超时
+	_, bounded = invokeTimeout(context.Background(), flux.ServiceSpec{})
+	tester.False(bounded)
+}
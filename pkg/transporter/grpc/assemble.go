@@ -0,0 +1,192 @@
+package grpc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// DefaultAssembleMessageFunc 默认实现的gRPC请求消息封装函数：
+// 按Argument名称匹配消息字段（字段名或JSON名），通过 transporter.Resolve 解析参数值并转换为字段类型。
+func DefaultAssembleMessageFunc(ctx flux.Context, arguments []flux.ServiceArgumentSpec, desc protoreflect.MessageDescriptor) (proto.Message, error) {
+	msg := dynamicpb.NewMessage(desc)
+	for _, arg := range arguments {
+		fd := FindFieldDescriptor(desc, arg.Name)
+		if fd == nil {
+			return nil, fmt.Errorf("field not found in message, field: %s, message: %s", arg.Name, desc.FullName())
+		}
+		val, err := transporter.Resolve(ctx, &arg)
+		if nil != err {
+			return nil, err
+		}
+		if err := SetMessageField(msg, fd, val); nil != err {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// DefaultAssembleMetadataFunc 默认实现的gRPC Metadata封装函数，将Context的Attributes作为Metadata传递
+func DefaultAssembleMetadataFunc(ctx flux.Context, _ *flux.ServiceSpec) (metadata.MD, error) {
+	md := make(metadata.MD, 8)
+	for k, v := range ctx.Attributes() {
+		// ':' 表示特定类型的属性 -> tag:xx,  feature:xx
+		// '@' 表示内置状态的属性 -> @com.bytepowered.flux.
+		if strings.ContainsAny(k, "@:") {
+			continue
+		}
+		md.Set(k, cast.ToString(v))
+	}
+	return md, nil
+}
+
+// FindFieldDescriptor 按字段名称或JSON名称查找字段描述
+func FindFieldDescriptor(desc protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := desc.Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(name)
+}
+
+// SetMessageField 将任意值转换为字段类型，并设置到消息中；值为nil时忽略。
+func SetMessageField(msg protoreflect.ProtoMessage, fd protoreflect.FieldDescriptor, value interface{}) error {
+	if flux.IsNil(value) {
+		return nil
+	}
+	rm := msg.ProtoReflect()
+	switch {
+	case fd.IsMap():
+		values, err := cast.ToStringMapE(value)
+		if nil != err {
+			return fmt.Errorf("field %s require map value, error: %w", fd.FullName(), err)
+		}
+		mv := rm.Mutable(fd).Map()
+		for k, v := range values {
+			key, err := toScalarValue(fd.MapKey(), k)
+			if nil != err {
+				return err
+			}
+			val, err := toFieldValue(mv.NewValue, fd.MapValue(), v)
+			if nil != err {
+				return err
+			}
+			mv.Set(key.MapKey(), val)
+		}
+	case fd.IsList():
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			rv = reflect.ValueOf([]interface{}{value})
+		}
+		lv := rm.Mutable(fd).List()
+		for i := 0; i < rv.Len(); i++ {
+			val, err := toFieldValue(lv.NewElement, fd, rv.Index(i).Interface())
+			if nil != err {
+				return err
+			}
+			lv.Append(val)
+		}
+	default:
+		val, err := toFieldValue(func() protoreflect.Value {
+			return rm.NewField(fd)
+		}, fd, value)
+		if nil != err {
+			return err
+		}
+		rm.Set(fd, val)
+	}
+	return nil
+}
+
+func toFieldValue(newValue func() protoreflect.Value, fd protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	if fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind {
+		return toScalarValue(fd, value)
+	}
+	values, err := cast.ToStringMapE(value)
+	if nil != err {
+		return protoreflect.Value{}, fmt.Errorf("field %s require object value, error: %w", fd.FullName(), err)
+	}
+	nv := newValue()
+	sub := nv.Message()
+	for k, v := range values {
+		sfd := FindFieldDescriptor(sub.Descriptor(), k)
+		if sfd == nil {
+			// 忽略未定义的字段，例如POJO参数的class字段
+			continue
+		}
+		if err := SetMessageField(sub.Interface(), sfd, v); nil != err {
+			return protoreflect.Value{}, err
+		}
+	}
+	return nv, nil
+}
+
+func toScalarValue(fd protoreflect.FieldDescriptor, value interface{}) (v protoreflect.Value, err error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = cast.ToBoolE(value)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int32
+		i, err = cast.ToInt32E(value)
+		v = protoreflect.ValueOfInt32(i)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = cast.ToInt64E(value)
+		v = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var i uint32
+		i, err = cast.ToUint32E(value)
+		v = protoreflect.ValueOfUint32(i)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var i uint64
+		i, err = cast.ToUint64E(value)
+		v = protoreflect.ValueOfUint64(i)
+	case protoreflect.FloatKind:
+		var f float32
+		f, err = cast.ToFloat32E(value)
+		v = protoreflect.ValueOfFloat32(f)
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = cast.ToFloat64E(value)
+		v = protoreflect.ValueOfFloat64(f)
+	case protoreflect.StringKind:
+		var s string
+		s, err = cast.ToStringE(value)
+		v = protoreflect.ValueOfString(s)
+	case protoreflect.BytesKind:
+		if bytes, ok := value.([]byte); ok {
+			v = protoreflect.ValueOfBytes(bytes)
+		} else {
+			var s string
+			s, err = cast.ToStringE(value)
+			v = protoreflect.ValueOfBytes([]byte(s))
+		}
+	case protoreflect.EnumKind:
+		if name, ok := value.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(name)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		var i int32
+		i, err = cast.ToInt32E(value)
+		v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(i))
+	default:
+		err = fmt.Errorf("unsupported field kind: %s", fd.Kind())
+	}
+	if nil != err {
+		return protoreflect.Value{}, fmt.Errorf("convert field value, field: %s, error: %w", fd.FullName(), err)
+	}
+	return v, nil
+}
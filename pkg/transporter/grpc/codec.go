@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"errors"
+	"net/http"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// AttachmentKeyHeader 响应Attachment中gRPC Header Metadata的Key
+	AttachmentKeyHeader = "@net.bytepowered.flux/grpc.header"
+	// AttachmentKeyTrailer 响应Attachment中gRPC Trailer Metadata的Key
+	AttachmentKeyTrailer = "@net.bytepowered.flux/grpc.trailer"
+)
+
+var (
+	ErrUnknownGrpcResponse = errors.New("TRANSPORTER:GRPC:UNKNOWN_RESPONSE")
+)
+
+// NewTransportCodecFunc 默认的gRPC响应解析函数：
+// 响应消息以JSON格式（protojson）输出；Header Metadata转换为Http Header；Trailer Metadata作为Attachment透传。
+func NewTransportCodecFunc() flux.TransportCodecFunc {
+	marshaler := protojson.MarshalOptions{EmitUnpopulated: true}
+	return func(ctx flux.Context, value interface{}, att map[string]interface{}) (*flux.ServeResponse, error) {
+		msg, ok := value.(proto.Message)
+		if !ok {
+			return nil, ErrUnknownGrpcResponse
+		}
+		bytes, err := marshaler.Marshal(msg)
+		if nil != err {
+			return nil, err
+		}
+		response := flux.NewServeResponse(flux.StatusOK, bytes)
+		response.Headers.Set(flux.HeaderContentType, flux.MIMEApplicationJSONCharsetUTF8)
+		if header, ok := att[AttachmentKeyHeader].(metadata.MD); ok {
+			MergeMetadataToHeader(response.Headers, header)
+		}
+		if trailer, ok := att[AttachmentKeyTrailer].(metadata.MD); ok {
+			for k, v := range trailer {
+				response.Attachments[k] = v
+			}
+		}
+		return response, nil
+	}
+}
+
+// MergeMetadataToHeader 将gRPC Metadata合并到Http Header中；忽略gRPC保留的伪Header和二进制Metadata。
+func MergeMetadataToHeader(header http.Header, md metadata.MD) {
+	for k, vs := range md {
+		if len(k) == 0 || k[0] == ':' || isBinaryMetadataKey(k) {
+			continue
+		}
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+}
+
+func isBinaryMetadataKey(k string) bool {
+	const suffix = "-bin"
+	return len(k) > len(suffix) && k[len(k)-len(suffix):] == suffix
+}
+
+// StatusCodeOf 将gRPC状态码映射为Http状态码
+// Ref: https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+func StatusCodeOf(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		// Unknown, Internal, DataLoss
+		return http.StatusInternalServerError
+	}
+}
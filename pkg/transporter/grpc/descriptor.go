@@ -0,0 +1,198 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+import (
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type (
+	// DescriptorSource 用于查找gRPC服务的描述元数据，支持Protoset文件和服务端反射两种来源
+	DescriptorSource interface {
+		// FindService 根据服务全限定名称查找服务描述
+		FindService(ctx context.Context, serviceName string) (protoreflect.ServiceDescriptor, error)
+	}
+)
+
+var (
+	_ DescriptorSource = new(ProtosetDescriptorSource)
+	_ DescriptorSource = new(ReflectionDescriptorSource)
+)
+
+// FindMethodDescriptor 根据DescriptorSource查找服务方法的描述元数据
+func FindMethodDescriptor(ctx context.Context, source DescriptorSource, service, method string) (protoreflect.MethodDescriptor, error) {
+	sd, err := source.FindService(ctx, service)
+	if nil != err {
+		return nil, err
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method not found in service, service: %s, method: %s", service, method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("streaming method not supported, service: %s, method: %s", service, method)
+	}
+	return md, nil
+}
+
+// ProtosetDescriptorSource 基于Protoset文件（protoc --descriptor_set_out --include_imports）的描述元数据
+type ProtosetDescriptorSource struct {
+	files *protoregistry.Files
+}
+
+// NewProtosetDescriptorSource 从Protoset文件列表中加载描述元数据
+func NewProtosetDescriptorSource(paths ...string) (*ProtosetDescriptorSource, error) {
+	set := new(descriptorpb.FileDescriptorSet)
+	for _, path := range paths {
+		bytes, err := ioutil.ReadFile(path)
+		if nil != err {
+			return nil, fmt.Errorf("read protoset file, path: %s, error: %w", path, err)
+		}
+		fds := new(descriptorpb.FileDescriptorSet)
+		if err := proto.Unmarshal(bytes, fds); nil != err {
+			return nil, fmt.Errorf("decode protoset file, path: %s, error: %w", path, err)
+		}
+		set.File = append(set.File, fds.File...)
+	}
+	return NewFileDescriptorSetSource(set)
+}
+
+// NewFileDescriptorSetSource 从FileDescriptorSet中加载描述元数据
+func NewFileDescriptorSetSource(set *descriptorpb.FileDescriptorSet) (*ProtosetDescriptorSource, error) {
+	files, err := protodesc.NewFiles(dedupFileDescriptorSet(set))
+	if nil != err {
+		return nil, fmt.Errorf("resolve protoset files, error: %w", err)
+	}
+	return &ProtosetDescriptorSource{files: files}, nil
+}
+
+func (p *ProtosetDescriptorSource) FindService(_ context.Context, serviceName string) (protoreflect.ServiceDescriptor, error) {
+	desc, err := p.files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if nil != err {
+		return nil, fmt.Errorf("service not found in protoset, service: %s, error: %w", serviceName, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("symbol is not a service, symbol: %s", serviceName)
+	}
+	return sd, nil
+}
+
+// ReflectionDescriptorSource 基于gRPC服务端反射(grpc.reflection.v1alpha)的描述元数据；
+// 已解析的服务描述将被缓存。
+type ReflectionDescriptorSource struct {
+	conn     *grpc.ClientConn
+	services sync.Map
+}
+
+// NewReflectionDescriptorSource 基于gRPC连接创建服务端反射的描述元数据来源
+func NewReflectionDescriptorSource(conn *grpc.ClientConn) *ReflectionDescriptorSource {
+	return &ReflectionDescriptorSource{conn: conn}
+}
+
+func (r *ReflectionDescriptorSource) FindService(ctx context.Context, serviceName string) (protoreflect.ServiceDescriptor, error) {
+	if sd, ok := r.services.Load(serviceName); ok {
+		return sd.(protoreflect.ServiceDescriptor), nil
+	}
+	stream, err := rpb.NewServerReflectionClient(r.conn).ServerReflectionInfo(ctx)
+	if nil != err {
+		return nil, fmt.Errorf("open reflection stream, error: %w", err)
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+	fetched := make(map[string]*descriptorpb.FileDescriptorProto, 4)
+	request := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); nil != err {
+			return err
+		}
+		resp, err := stream.Recv()
+		if nil != err {
+			return err
+		}
+		if eresp := resp.GetErrorResponse(); eresp != nil {
+			return fmt.Errorf("reflection error, code: %d, message: %s", eresp.ErrorCode, eresp.ErrorMessage)
+		}
+		for _, bytes := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := new(descriptorpb.FileDescriptorProto)
+			if err := proto.Unmarshal(bytes, fd); nil != err {
+				return err
+			}
+			fetched[fd.GetName()] = fd
+		}
+		return nil
+	}
+	if err := request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: serviceName},
+	}); nil != err {
+		return nil, fmt.Errorf("reflect service, service: %s, error: %w", serviceName, err)
+	}
+	// 补全未返回的依赖文件
+	for missing := missingDependencies(fetched); len(missing) > 0; missing = missingDependencies(fetched) {
+		for _, name := range missing {
+			if err := request(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			}); nil != err {
+				return nil, fmt.Errorf("reflect file, file: %s, error: %w", name, err)
+			}
+			if _, ok := fetched[name]; !ok {
+				return nil, fmt.Errorf("reflect file, file not found: %s", name)
+			}
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{File: make([]*descriptorpb.FileDescriptorProto, 0, len(fetched))}
+	for _, fd := range fetched {
+		set.File = append(set.File, fd)
+	}
+	source, err := NewFileDescriptorSetSource(set)
+	if nil != err {
+		return nil, err
+	}
+	sd, err := source.FindService(ctx, serviceName)
+	if nil != err {
+		return nil, err
+	}
+	r.services.Store(serviceName, sd)
+	return sd, nil
+}
+
+func missingDependencies(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	out := make([]string, 0)
+	for _, fd := range files {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := files[dep]; !ok {
+				// 内置的WellKnownTypes可从全局注册表中补全
+				if gfd, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					files[dep] = protodesc.ToFileDescriptorProto(gfd)
+					continue
+				}
+				out = append(out, dep)
+			}
+		}
+	}
+	return out
+}
+
+func dedupFileDescriptorSet(set *descriptorpb.FileDescriptorSet) *descriptorpb.FileDescriptorSet {
+	seen := make(map[string]struct{}, len(set.File))
+	out := &descriptorpb.FileDescriptorSet{File: make([]*descriptorpb.FileDescriptorProto, 0, len(set.File))}
+	for _, fd := range set.File {
+		if _, ok := seen[fd.GetName()]; ok {
+			continue
+		}
+		seen[fd.GetName()] = struct{}{}
+		out.File = append(out.File, fd)
+	}
+	return out
+}
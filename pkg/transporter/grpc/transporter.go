@@ -0,0 +1,319 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	ConfigKeyTraceEnable   = "trace_enable"
+	ConfigKeyTimeout       = "timeout"
+	ConfigKeyInsecure      = "insecure"
+	ConfigKeyProtosetFiles = "protoset_files"
+	// TLS配置：insecure 为false时生效
+	ConfigKeyTLSCAFiles            = "tls_ca_files"
+	ConfigKeyTLSCertFile           = "tls_cert_file"
+	ConfigKeyTLSKeyFile            = "tls_key_file"
+	ConfigKeyTLSServerName         = "tls_server_name"
+	ConfigKeyTLSInsecureSkipVerify = "tls_insecure_skip_verify"
+)
+
+func init() {
+	ext.RegisterTransporter(flux.ProtoGRPC, NewTransporter())
+}
+
+var _ flux.Transporter = new(RpcTransporter)
+var _ flux.Initializer = new(RpcTransporter)
+var _ flux.Shutdowner = new(RpcTransporter)
+
+type (
+	// Option 配置函数
+	Option func(service *RpcTransporter)
+	// AssembleMessageFunc gRPC请求消息封装函数，根据方法的输入消息描述构建请求消息
+	AssembleMessageFunc func(ctx flux.Context, arguments []flux.ServiceArgumentSpec, desc protoreflect.MessageDescriptor) (proto.Message, error)
+	// AssembleMetadataFunc 封装gRPC请求Metadata的函数
+	AssembleMetadataFunc func(ctx flux.Context, service *flux.ServiceSpec) (metadata.MD, error)
+)
+
+type rpcConn struct {
+	conn   *grpc.ClientConn
+	source DescriptorSource
+}
+
+// RpcTransporter 基于动态描述元数据调用gRPC Unary方法的Transporter；
+// Service.Url 为gRPC服务地址，Service.Interface 为服务全限定名称，Service.Method 为方法名称。
+type RpcTransporter struct {
+	codec            flux.TransportCodecFunc
+	assembleMessage  AssembleMessageFunc
+	assembleMetadata AssembleMetadataFunc
+	dialOptions      []grpc.DialOption
+	source           DescriptorSource
+	trace            bool
+	timeout          time.Duration
+	conns            map[string]*rpcConn
+	mutex            sync.Mutex
+}
+
+func NewTransporter() *RpcTransporter {
+	return &RpcTransporter{
+		codec:            NewTransportCodecFunc(),
+		assembleMessage:  DefaultAssembleMessageFunc,
+		assembleMetadata: DefaultAssembleMetadataFunc,
+		dialOptions:      make([]grpc.DialOption, 0),
+		timeout:          time.Second * 10,
+		conns:            make(map[string]*rpcConn, 8),
+	}
+}
+
+func NewTransporterWith(opts ...Option) *RpcTransporter {
+	bts := NewTransporter()
+	for _, opt := range opts {
+		opt(bts)
+	}
+	return bts
+}
+
+// WithTransportCodec 用于配置响应数据解析实现函数
+func WithTransportCodec(fun flux.TransportCodecFunc) Option {
+	return func(service *RpcTransporter) {
+		service.codec = fun
+	}
+}
+
+// WithAssembleMessageFunc 用于配置gRPC请求消息封装实现函数
+func WithAssembleMessageFunc(fun AssembleMessageFunc) Option {
+	return func(service *RpcTransporter) {
+		service.assembleMessage = fun
+	}
+}
+
+// WithAssembleMetadataFunc 用于配置gRPC请求Metadata封装实现函数
+func WithAssembleMetadataFunc(fun AssembleMetadataFunc) Option {
+	return func(service *RpcTransporter) {
+		service.assembleMetadata = fun
+	}
+}
+
+// WithDialOptions 用于配置gRPC连接参数
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(service *RpcTransporter) {
+		service.dialOptions = append(service.dialOptions, opts...)
+	}
+}
+
+// WithDescriptorSource 用于配置固定的描述元数据来源；未配置时使用服务端反射。
+func WithDescriptorSource(source DescriptorSource) Option {
+	return func(service *RpcTransporter) {
+		service.source = source
+	}
+}
+
+func (b *RpcTransporter) OnInit(config *flux.Configuration) error {
+	config.SetDefaults(map[string]interface{}{
+		ConfigKeyTimeout:  "10s",
+		ConfigKeyInsecure: true,
+	})
+	b.trace = config.GetBool(ConfigKeyTraceEnable)
+	b.timeout = config.GetDuration(ConfigKeyTimeout)
+	if config.GetBool(ConfigKeyInsecure) {
+		b.dialOptions = append(b.dialOptions, grpc.WithInsecure())
+	} else {
+		tc, err := newTLSConfig(config)
+		if nil != err {
+			return err
+		}
+		b.dialOptions = append(b.dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tc)))
+	}
+	if files := config.GetStringSlice(ConfigKeyProtosetFiles); len(files) > 0 && b.source == nil {
+		source, err := NewProtosetDescriptorSource(files...)
+		if nil != err {
+			return err
+		}
+		b.source = source
+	}
+	flux.AssertNotNil(b.codec, "<TransportCodecFunc> MUST NOT nil")
+	flux.AssertNotNil(b.assembleMessage, "<AssembleMessageFunc> MUST NOT nil")
+	flux.AssertNotNil(b.assembleMetadata, "<AssembleMetadataFunc> MUST NOT nil")
+	return nil
+}
+
+func (b *RpcTransporter) OnShutdown(_ context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for target, rc := range b.conns {
+		if err := rc.conn.Close(); nil != err {
+			logger.Warnw("TRANSPORTER:GRPC:SHUTDOWN/close", "target", target, "error", err)
+		}
+	}
+	b.conns = make(map[string]*rpcConn, 8)
+	return nil
+}
+
+func (b *RpcTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	flux.AssertNotEmpty(service.Url, "<service.url> MUST NOT empty in grpc transporter")
+	trace := logger.TraceExtras(ctx.RequestId(), map[string]string{
+		"invoke.service":     service.ServiceID(),
+		"invoke.service.url": service.Url,
+	})
+	rc, err := b.connect(service.Url)
+	if nil != err {
+		trace.Errorw("TRANSPORTER:GRPC:CONNECT/error", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusBadGateway,
+			ErrorCode:  flux.ErrorCodeGatewayTransporter,
+			Message:    flux.ErrorMessageTransportGrpcInvokeFailed,
+			CauseError: err,
+		}
+	}
+	// 服务超时时长不超过网关请求的剩余时长；gRPC通过grpc-timeout向后端传递剩余时长
+	goctx, cancel := context.WithTimeout(ctx.Context(), b.timeoutOf(service))
+	defer cancel()
+	// descriptor
+	md, err := FindMethodDescriptor(goctx, rc.source, service.Interface, service.Method)
+	if nil != err {
+		trace.Errorw("TRANSPORTER:GRPC:DESCRIPTOR/error", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportGrpcDescriptorFailed,
+			CauseError: err,
+		}
+	}
+	// request
+	request, err := b.assembleMessage(ctx, service.Arguments, md.Input())
+	if nil != err {
+		trace.Errorw("TRANSPORTER:GRPC:ASSEMBLE/message", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportGrpcAssembleFailed,
+			CauseError: err,
+		}
+	}
+	outmd, err := b.assembleMetadata(ctx, &service)
+	if nil != err {
+		trace.Errorw("TRANSPORTER:GRPC:ASSEMBLE/metadata", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportGrpcAssembleFailed,
+			CauseError: err,
+		}
+	}
+	if b.trace {
+		trace.Infow("TRANSPORTER:GRPC:INVOKE/args", "arg-message", request, "arg-metadata", outmd)
+	}
+	// invoke
+	response := dynamicpb.NewMessage(md.Output())
+	var header, trailer metadata.MD
+	fullMethod := "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+	err = rc.conn.Invoke(metadata.NewOutgoingContext(goctx, outmd), fullMethod, request, response,
+		grpc.Header(&header), grpc.Trailer(&trailer))
+	if nil != err {
+		if transporter.IsRequestTimeout(ctx) {
+			return nil, transporter.NewRequestTimeoutError(ctx)
+		}
+		if ctx.Context().Err() == context.Canceled {
+			return nil, &flux.ServeError{
+				StatusCode: flux.StatusBadRequest,
+				ErrorCode:  flux.ErrorCodeRequestCanceled,
+				Message:    flux.ErrorMessageTransportGrpcClientCanceled,
+				CauseError: ctx.Context().Err(),
+			}
+		}
+		trace.Errorw("TRANSPORTER:GRPC:INVOKE/error", "error", err)
+		return nil, b.toServeError(err, header, trailer)
+	}
+	// decode response
+	decret, decerr := b.codec(ctx, response, map[string]interface{}{
+		AttachmentKeyHeader:  header,
+		AttachmentKeyTrailer: trailer,
+	})
+	if nil != decerr {
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportCodecError,
+			CauseError: fmt.Errorf("decode grpc response, err: %w", decerr),
+		}
+	}
+	return decret, nil
+}
+
+func (b *RpcTransporter) toServeError(err error, header, trailer metadata.MD) *flux.ServeError {
+	st := status.Convert(err)
+	serr := &flux.ServeError{
+		StatusCode: StatusCodeOf(st.Code()),
+		ErrorCode:  flux.ErrorCodeGatewayTransporter,
+		Message:    flux.ErrorMessageTransportGrpcInvokeFailed,
+		CauseError: err,
+	}
+	headers := make(http.Header, len(header)+len(trailer))
+	MergeMetadataToHeader(headers, header)
+	MergeMetadataToHeader(headers, trailer)
+	serr.MergeHeader(headers)
+	serr.SetExtra("grpc.code", st.Code().String())
+	serr.SetExtra("grpc.message", st.Message())
+	return serr
+}
+
+func (b *RpcTransporter) timeoutOf(service flux.ServiceSpec) time.Duration {
+	if to := service.Annotation(flux.ServiceAnnotationRpcTimeout); to.IsValid() {
+		if d, err := cast.ToDurationE(to.GetString()); err == nil && d > 0 {
+			return d
+		}
+	}
+	return b.timeout
+}
+
+func (b *RpcTransporter) connect(url string) (*rpcConn, error) {
+	target := strings.TrimPrefix(url, "grpc://")
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if rc, ok := b.conns[target]; ok {
+		return rc, nil
+	}
+	conn, err := grpc.Dial(target, b.dialOptions...)
+	if nil != err {
+		return nil, fmt.Errorf("dial grpc target, target: %s, error: %w", target, err)
+	}
+	source := b.source
+	if source == nil {
+		source = NewReflectionDescriptorSource(conn)
+	}
+	rc := &rpcConn{conn: conn, source: source}
+	b.conns[target] = rc
+	return rc, nil
+}
+
+// newTLSConfig 根据TLS配置创建gRPC连接的TLS配置；未配置CA证书时使用系统证书池
+func newTLSConfig(config *flux.Configuration) (*tls.Config, error) {
+	tc, err := toolkit.NewTLSConfig(config.GetStringSlice(ConfigKeyTLSCAFiles),
+		config.GetString(ConfigKeyTLSCertFile), config.GetString(ConfigKeyTLSKeyFile),
+		config.GetString(ConfigKeyTLSServerName), config.GetBool(ConfigKeyTLSInsecureSkipVerify))
+	if nil != err {
+		return nil, fmt.Errorf("grpc transport: %w", err)
+	}
+	return tc, nil
+}
@@ -0,0 +1,268 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const greeterService = "flux.test.Greeter"
+
+var registerOnce sync.Once
+
+func greeterFileProto() *descriptorpb.FileDescriptorProto {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("flux/test/greeter.proto"),
+		Package: proto.String("flux.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("HelloRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			}},
+			{Name: proto.String("HelloReply"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{Name: proto.String("Greeter"), Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("SayHello"), InputType: proto.String(".flux.test.HelloRequest"), OutputType: proto.String(".flux.test.HelloReply")},
+			}},
+		},
+	}
+}
+
+// startGreeterServer 启动进程内的gRPC服务，支持服务端反射
+func startGreeterServer(t *testing.T, opts ...grpc.ServerOption) (string, func()) {
+	fdp := greeterFileProto()
+	fd, err := protodesc.NewFile(fdp, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	sd := fd.Services().Get(0)
+	md := sd.Methods().Get(0)
+	handler := func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := dynamicpb.NewMessage(md.Input())
+		if err := dec(in); nil != err {
+			return nil, err
+		}
+		name := in.Get(md.Input().Fields().ByName("name")).String()
+		age := in.Get(md.Input().Fields().ByName("age")).Int()
+		if name == "error" {
+			_ = grpc.SetTrailer(ctx, metadata.Pairs("x-error-reason", "bad-name"))
+			return nil, status.Error(codes.InvalidArgument, "invalid name")
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-greeter", "flux"))
+		out := dynamicpb.NewMessage(md.Output())
+		out.Set(md.Output().Fields().ByName("message"), protoreflect.ValueOfString("hello "+name))
+		out.Set(md.Output().Fields().ByName("age"), protoreflect.ValueOfInt32(int32(age)))
+		return out, nil
+	}
+	// 反射服务从全局注册表中查找FileDescriptor
+	registerOnce.Do(func() {
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); nil != err {
+			t.Fatal(err)
+		}
+	})
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: greeterService,
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "SayHello", Handler: handler}},
+		Metadata:    fdp.GetName(),
+	}, struct{}{})
+	reflection.Register(server)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	return lis.Addr().String(), server.Stop
+}
+
+func newTestContext(target string) *internal.Context {
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	e := echo.New()
+	req := httptest.NewRequest("GET", "/hello?name=flux&age=9", nil)
+	echoc := e.NewContext(req, httptest.NewRecorder())
+	ctx := internal.NewContext()
+	ctx.Reset(listener.NewWebContext(echoc, "test-id", nil), &flux.EndpointSpec{
+		Service: newTestService(target),
+	})
+	return ctx
+}
+
+func newTestService(target string) flux.ServiceSpec {
+	return flux.ServiceSpec{
+		Protocol:  flux.ProtoGRPC,
+		Url:       "grpc://" + target,
+		Interface: greeterService,
+		Method:    "SayHello",
+		Arguments: []flux.ServiceArgumentSpec{
+			{Name: "name", StructType: flux.ServiceArgumentTypePrimitive, ClassType: "string", HttpName: "name", HttpScope: flux.ScopeQuery},
+			{Name: "age", StructType: flux.ServiceArgumentTypePrimitive, ClassType: "int", HttpName: "age", HttpScope: flux.ScopeQuery},
+		},
+	}
+}
+
+func newTestTransporter(opts ...Option) *RpcTransporter {
+	opts = append(opts, WithDialOptions(grpc.WithInsecure()))
+	return NewTransporterWith(opts...)
+}
+
+func TestTransporter_InvokeWithReflection(t *testing.T) {
+	tester := assert.New(t)
+	target, stop := startGreeterServer(t)
+	defer stop()
+	trans := newTestTransporter()
+	defer trans.OnShutdown(context.Background())
+	ctx := newTestContext(target)
+	resp, serr := trans.DoInvoke(ctx, ctx.Service())
+	tester.Nil(serr)
+	tester.Equal(flux.StatusOK, resp.StatusCode)
+	tester.Equal("flux", resp.Headers.Get("x-greeter"))
+	body := make(map[string]interface{})
+	tester.NoError(json.Unmarshal(resp.Body.([]byte), &body))
+	tester.Equal("hello flux", body["message"])
+	tester.Equal(float64(9), body["age"])
+}
+
+func TestTransporter_InvokeWithProtoset(t *testing.T) {
+	tester := assert.New(t)
+	target, stop := startGreeterServer(t)
+	defer stop()
+	source, err := NewFileDescriptorSetSource(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{greeterFileProto()},
+	})
+	tester.NoError(err)
+	trans := newTestTransporter(WithDescriptorSource(source))
+	defer trans.OnShutdown(context.Background())
+	ctx := newTestContext(target)
+	resp, serr := trans.DoInvoke(ctx, ctx.Service())
+	tester.Nil(serr)
+	body := make(map[string]interface{})
+	tester.NoError(json.Unmarshal(resp.Body.([]byte), &body))
+	tester.Equal("hello flux", body["message"])
+}
+
+func TestTransporter_InvokeStatusError(t *testing.T) {
+	tester := assert.New(t)
+	target, stop := startGreeterServer(t)
+	defer stop()
+	trans := newTestTransporter()
+	defer trans.OnShutdown(context.Background())
+	ctx := newTestContext(target)
+	service := newTestService(target)
+	service.Arguments[0].Annotations = flux.Annotations{flux.ServiceArgumentAnnotationDefault: "error"}
+	service.Arguments[0].HttpName = "not-exists"
+	_, serr := trans.DoInvoke(ctx, service)
+	tester.NotNil(serr)
+	tester.Equal(400, serr.StatusCode)
+	tester.Equal(flux.ErrorCodeGatewayTransporter, serr.ErrorCode)
+	tester.Equal("InvalidArgument", serr.GetExtra("grpc.code"))
+	tester.Equal("bad-name", serr.Header.Get("x-error-reason"))
+}
+
+func TestTransporter_MethodNotFound(t *testing.T) {
+	tester := assert.New(t)
+	target, stop := startGreeterServer(t)
+	defer stop()
+	trans := newTestTransporter()
+	defer trans.OnShutdown(context.Background())
+	ctx := newTestContext(target)
+	service := newTestService(target)
+	service.Method = "NotExists"
+	_, serr := trans.DoInvoke(ctx, service)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorMessageTransportGrpcDescriptorFailed, serr.Message)
+}
+
+func TestTransporter_InvokeWithTLS(t *testing.T) {
+	tester := assert.New(t)
+	// 使用httptest生成的证书作为gRPC服务证书和CA证书
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	cert := ts.TLS.Certificates[0]
+	ts.Close()
+	dir, err := ioutil.TempDir("", "grpc_tls")
+	tester.NoError(err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	tester.NoError(ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	target, stop := startGreeterServer(t, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	defer stop()
+	invoke := func(values map[string]interface{}) *flux.ServeError {
+		trans := NewTransporter()
+		defer trans.OnShutdown(context.Background())
+		tester.NoError(trans.OnInit(flux.NewVarsConfiguration(values)))
+		ctx := newTestContext(target)
+		_, serr := trans.DoInvoke(ctx, ctx.Service())
+		return serr
+	}
+	tester.Nil(invoke(map[string]interface{}{
+		ConfigKeyInsecure:   false,
+		ConfigKeyTLSCAFiles: []string{caFile},
+	}))
+	// 默认使用系统证书池，不信任测试证书
+	tester.NotNil(invoke(map[string]interface{}{
+		ConfigKeyInsecure: false,
+		ConfigKeyTimeout:  "1s",
+	}))
+	trans := NewTransporter()
+	tester.Error(trans.OnInit(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyInsecure:   false,
+		ConfigKeyTLSCAFiles: []string{filepath.Join(dir, "not-exists.pem")},
+	})))
+}
+
+func TestStatusCodeOf(t *testing.T) {
+	tester := assert.New(t)
+	tester.Equal(200, StatusCodeOf(codes.OK))
+	tester.Equal(404, StatusCodeOf(codes.NotFound))
+	tester.Equal(504, StatusCodeOf(codes.DeadlineExceeded))
+	tester.Equal(503, StatusCodeOf(codes.Unavailable))
+	tester.Equal(500, StatusCodeOf(codes.Internal))
+}
@@ -1,13 +1,11 @@
 package http
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 import (
@@ -46,13 +44,7 @@ func DefaultAssembleRequest(ctx flux.Context, service *flux.ServiceSpec) (*http.
 		reader, _ := ctx.BodyReader()
 		newBodyReader = reader
 	}
-
-	timeout, err := cast.ToDurationE(service.Annotation(flux.ServiceAnnotationRpcTimeout).Value)
-	if err != nil {
-		timeout = time.Second * 10
-	}
-	toctx, _ := context.WithTimeout(ctx.Context(), timeout)
-	newRequest, err := http.NewRequestWithContext(toctx, ctx.Method(), newUrl.String(), newBodyReader)
+	newRequest, err := http.NewRequestWithContext(ctx.Context(), MethodOf(ctx, *service), newUrl.String(), newBodyReader)
 	if nil != err {
 		return nil, fmt.Errorf("new request, method: %s, url: %s, err: %w", service.Method, newUrl, err)
 	}
@@ -105,7 +97,8 @@ func ResolvePostFormValues(ctx flux.Context, args []flux.ServiceArgumentSpec) (u
 }
 
 func DefaultAssembleHeaders(ctx flux.Context) (http.Header, error) {
-	header := ctx.HeaderVars()
+	header := ctx.HeaderVars().Clone()
+	ForwardHeaders(ctx, header)
 	for k, v := range ctx.Attributes() {
 		// ':' 表示特定类型的属性 -> tag:xx,  feature:xx
 		// '@' 表示内置状态的属性 -> @com.bytepowered.flux.
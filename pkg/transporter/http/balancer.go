@@ -0,0 +1,154 @@
+package http
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/spf13/cast"
+)
+
+// 负载均衡策略
+const (
+	BalancerRoundRobin     = "round_robin"
+	BalancerWeightedRandom = "weighted_random"
+	BalancerLeastConn      = "least_conn"
+	BalancerConsistentHash = "consistent_hash"
+)
+
+// Balancer 从可用的上游目标中选择一个目标；可用目标列表不为空
+type Balancer interface {
+	Select(ctx flux.Context, available []*Target) *Target
+}
+
+// NewBalancer 根据策略名称创建Balancer；hashKey为一致性Hash的查找表达式
+func NewBalancer(name string, targets []*Target, hashKey string) (Balancer, error) {
+	switch name {
+	case "", BalancerRoundRobin:
+		return &roundRobinBalancer{}, nil
+	case BalancerWeightedRandom:
+		return &weightedRandomBalancer{}, nil
+	case BalancerLeastConn:
+		return &leastConnBalancer{}, nil
+	case BalancerConsistentHash:
+		if "" == hashKey {
+			return nil, fmt.Errorf("balancer: <hash_key> is required for %s", BalancerConsistentHash)
+		}
+		return newConsistentHashBalancer(targets, hashKey), nil
+	default:
+		return nil, fmt.Errorf("balancer: unsupported balancer: %s", name)
+	}
+}
+
+// roundRobinBalancer 平滑加权轮询
+type roundRobinBalancer struct {
+	mu      sync.Mutex
+	current map[*Target]int
+}
+
+func (b *roundRobinBalancer) Select(_ flux.Context, available []*Target) *Target {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.current == nil {
+		b.current = make(map[*Target]int, len(available))
+	}
+	var best *Target
+	total := 0
+	for _, t := range available {
+		b.current[t] += t.Weight
+		total += t.Weight
+		if best == nil || b.current[t] > b.current[best] {
+			best = t
+		}
+	}
+	b.current[best] -= total
+	return best
+}
+
+// weightedRandomBalancer 按权重随机
+type weightedRandomBalancer struct{}
+
+func (b *weightedRandomBalancer) Select(_ flux.Context, available []*Target) *Target {
+	total := 0
+	for _, t := range available {
+		total += t.Weight
+	}
+	n := rand.Intn(total)
+	for _, t := range available {
+		if n < t.Weight {
+			return t
+		}
+		n -= t.Weight
+	}
+	return available[len(available)-1]
+}
+
+// leastConnBalancer 选择当前连接数与权重比值最小的目标；比值相同时随机选择
+type leastConnBalancer struct{}
+
+func (b *leastConnBalancer) Select(_ flux.Context, available []*Target) *Target {
+	var best []*Target
+	var bestActive, bestWeight int64
+	for _, t := range available {
+		active, weight := t.Active(), int64(t.Weight)
+		// active/weight < bestActive/bestWeight
+		if best == nil || active*bestWeight < bestActive*weight {
+			best, bestActive, bestWeight = []*Target{t}, active, weight
+		} else if active*bestWeight == bestActive*weight {
+			best = append(best, t)
+		}
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// consistentHashBalancer 按请求的查找表达式值一致性Hash；目标不可用时，顺延到Hash环上的下一个可用目标。
+// 查找值为空时，随机选择。
+type consistentHashBalancer struct {
+	hashKey string
+	hashes  []uint32
+	ring    map[uint32]*Target
+}
+
+const consistentHashReplicas = 160
+
+func newConsistentHashBalancer(targets []*Target, hashKey string) *consistentHashBalancer {
+	b := &consistentHashBalancer{hashKey: hashKey, ring: make(map[uint32]*Target)}
+	for _, t := range targets {
+		for i := 0; i < consistentHashReplicas*t.Weight; i++ {
+			h := crc32.ChecksumIEEE([]byte(t.URL.String() + "#" + strconv.Itoa(i)))
+			if _, ok := b.ring[h]; !ok {
+				b.ring[h] = t
+				b.hashes = append(b.hashes, h)
+			}
+		}
+	}
+	sort.Slice(b.hashes, func(i, j int) bool { return b.hashes[i] < b.hashes[j] })
+	return b
+}
+
+func (b *consistentHashBalancer) Select(ctx flux.Context, available []*Target) *Target {
+	value, _ := common.LookupValueByExpr(ctx, b.hashKey)
+	key := cast.ToString(value)
+	if "" == key || len(b.hashes) == 0 {
+		return available[rand.Intn(len(available))]
+	}
+	usable := make(map[*Target]bool, len(available))
+	for _, t := range available {
+		usable[t] = true
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(b.hashes), func(i int) bool { return b.hashes[i] >= h })
+	for i := 0; i < len(b.hashes); i++ {
+		if t := b.ring[b.hashes[(start+i)%len(b.hashes)]]; usable[t] {
+			return t
+		}
+	}
+	return available[0]
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+)
+
+const (
+	ConfigKeyTimeout               = "timeout"
+	ConfigKeyMaxIdleConns          = "max_idle_conns"
+	ConfigKeyMaxIdleConnsPerHost   = "max_idle_conns_per_host"
+	ConfigKeyMaxConnsPerHost       = "max_conns_per_host"
+	ConfigKeyIdleConnTimeout       = "idle_conn_timeout"
+	ConfigKeyDialTimeout           = "dial_timeout"
+	ConfigKeyKeepAlive             = "keep_alive"
+	ConfigKeyTLSHandshakeTimeout   = "tls_handshake_timeout"
+	ConfigKeyResponseHeaderTimeout = "response_header_timeout"
+	ConfigKeyHttp2Enable           = "http2_enable"
+	ConfigKeyProxy                 = "proxy"
+	ConfigKeyTLSCAFiles            = "tls_ca_files"
+	ConfigKeyTLSCertFile           = "tls_cert_file"
+	ConfigKeyTLSKeyFile            = "tls_key_file"
+	ConfigKeyTLSServerName         = "tls_server_name"
+	ConfigKeyTLSInsecureSkipVerify = "tls_insecure_skip_verify"
+	// ConfigKeyTransports 命名的传输配置，Service通过 flux.go/http.transport 注解引用
+	ConfigKeyTransports = "transports"
+)
+
+const (
+	// ProxyDirect 不使用代理
+	ProxyDirect = "direct"
+)
+
+// ClientConfig Http客户端的连接池与传输配置
+type ClientConfig struct {
+	// 请求超时时长；作用于请求的超时Context
+	Timeout               time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	Http2Enable           bool
+	// 代理地址；为空时使用环境变量配置，为 direct 时不使用代理
+	Proxy string
+	// 自定义CA证书文件列表，追加到系统证书池
+	TLSCAFiles []string
+	// 双向TLS的客户端证书
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+}
+
+// DefaultClientConfig 返回默认传输配置
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Timeout:             time.Second * 10,
+		MaxIdleConns:        512,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     time.Second * 90,
+		DialTimeout:         time.Second * 5,
+		KeepAlive:           time.Second * 30,
+		TLSHandshakeTimeout: time.Second * 10,
+		Http2Enable:         true,
+	}
+}
+
+// NewClientConfig 从配置中加载传输配置；未配置的项使用base的值
+func NewClientConfig(config *flux.Configuration, base ClientConfig) ClientConfig {
+	c := base
+	durations := map[string]*time.Duration{
+		ConfigKeyTimeout:               &c.Timeout,
+		ConfigKeyIdleConnTimeout:       &c.IdleConnTimeout,
+		ConfigKeyDialTimeout:           &c.DialTimeout,
+		ConfigKeyKeepAlive:             &c.KeepAlive,
+		ConfigKeyTLSHandshakeTimeout:   &c.TLSHandshakeTimeout,
+		ConfigKeyResponseHeaderTimeout: &c.ResponseHeaderTimeout,
+	}
+	for key, ptr := range durations {
+		if config.IsSet(key) {
+			*ptr = config.GetDuration(key)
+		}
+	}
+	ints := map[string]*int{
+		ConfigKeyMaxIdleConns:        &c.MaxIdleConns,
+		ConfigKeyMaxIdleConnsPerHost: &c.MaxIdleConnsPerHost,
+		ConfigKeyMaxConnsPerHost:     &c.MaxConnsPerHost,
+	}
+	for key, ptr := range ints {
+		if config.IsSet(key) {
+			*ptr = config.GetInt(key)
+		}
+	}
+	strs := map[string]*string{
+		ConfigKeyProxy:         &c.Proxy,
+		ConfigKeyTLSCertFile:   &c.TLSCertFile,
+		ConfigKeyTLSKeyFile:    &c.TLSKeyFile,
+		ConfigKeyTLSServerName: &c.TLSServerName,
+	}
+	for key, ptr := range strs {
+		if config.IsSet(key) {
+			*ptr = config.GetString(key)
+		}
+	}
+	if config.IsSet(ConfigKeyHttp2Enable) {
+		c.Http2Enable = config.GetBool(ConfigKeyHttp2Enable)
+	}
+	if config.IsSet(ConfigKeyTLSInsecureSkipVerify) {
+		c.TLSInsecureSkipVerify = config.GetBool(ConfigKeyTLSInsecureSkipVerify)
+	}
+	if config.IsSet(ConfigKeyTLSCAFiles) {
+		c.TLSCAFiles = config.GetStringSlice(ConfigKeyTLSCAFiles)
+	}
+	return c
+}
+
+// NewHttpClient 根据传输配置创建Http客户端。
+// 客户端不设置整体超时，请求超时由请求的超时Context控制。
+func NewHttpClient(c ClientConfig) (*http.Client, error) {
+	dialer := &net.Dialer{
+		Timeout:   c.DialTimeout,
+		KeepAlive: c.KeepAlive,
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     c.Http2Enable,
+	}
+	if !c.Http2Enable {
+		// 非空的TLSNextProto禁用HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	switch proxy := strings.TrimSpace(c.Proxy); proxy {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxyUrl, err := url.Parse(proxy)
+		if nil != err {
+			return nil, fmt.Errorf("http transport: invalid proxy: %s, error: %w", proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	tlsConfig, err := newTLSConfig(c)
+	if nil != err {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+func newTLSConfig(c ClientConfig) (*tls.Config, error) {
+	if len(c.TLSCAFiles) == 0 && c.TLSCertFile == "" && c.TLSServerName == "" && !c.TLSInsecureSkipVerify {
+		return nil, nil
+	}
+	config, err := toolkit.NewTLSConfig(c.TLSCAFiles, c.TLSCertFile, c.TLSKeyFile, c.TLSServerName, c.TLSInsecureSkipVerify)
+	if nil != err {
+		return nil, fmt.Errorf("http transport: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientConfig(t *testing.T) {
+	tester := assert.New(t)
+	config := flux.NewConfiguration("http_client_test")
+	config.Set(ConfigKeyTimeout, "3s")
+	config.Set(ConfigKeyMaxIdleConnsPerHost, 8)
+	config.Set(ConfigKeyProxy, ProxyDirect)
+	base := NewClientConfig(config, DefaultClientConfig())
+	tester.Equal(time.Second*3, base.Timeout)
+	tester.Equal(8, base.MaxIdleConnsPerHost)
+	tester.Equal(512, base.MaxIdleConns)
+	tester.True(base.Http2Enable)
+	// 命名传输配置继承默认配置
+	profile := NewClientConfig(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyTimeout:     "30s",
+		ConfigKeyHttp2Enable: false,
+	}), base)
+	tester.Equal(time.Second*30, profile.Timeout)
+	tester.Equal(8, profile.MaxIdleConnsPerHost)
+	tester.False(profile.Http2Enable)
+	client, err := NewHttpClient(profile)
+	tester.NoError(err)
+	transport := client.Transport.(*http.Transport)
+	tester.Nil(transport.Proxy)
+	tester.NotNil(transport.TLSNextProto)
+	_, err = NewHttpClient(ClientConfig{TLSCAFiles: []string{"not-exists.pem"}})
+	tester.Error(err)
+}
+
+func TestTransporterInvokeMutualTLS(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	// 使用测试服务器的证书作为CA证书和客户端证书
+	dir, err := ioutil.TempDir("", "http_mtls")
+	tester.NoError(err)
+	defer os.RemoveAll(dir)
+	cert := server.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	tester.NoError(err)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	tester.NoError(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	tester.NoError(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600))
+
+	config := flux.NewConfiguration("http_mtls_test")
+	config.Set(ConfigKeyTransports, map[string]interface{}{
+		"mtls": map[string]interface{}{
+			ConfigKeyTLSCAFiles:  []string{certFile},
+			ConfigKeyTLSCertFile: certFile,
+			ConfigKeyTLSKeyFile:  keyFile,
+		},
+	})
+	tr := NewTransporter()
+	tester.NoError(tr.OnInit(config))
+	invoke := func(annotations flux.Annotations) (*http.Response, *flux.ServeError) {
+		ret, serr := tr.invoke0(newTestContext(nil), flux.ServiceSpec{
+			Url: server.URL + "/orders", Method: "GET", Annotations: annotations,
+		})
+		if serr != nil {
+			return nil, serr
+		}
+		resp := ret.(*http.Response)
+		tester.NoError(resp.Body.Close())
+		return resp, nil
+	}
+	resp, serr := invoke(flux.Annotations{flux.ServiceAnnotationHttpTransport: "mtls"})
+	tester.Nil(serr)
+	tester.Equal(http.StatusOK, resp.StatusCode)
+	// 传输配置名称大小写不敏感
+	resp, serr = invoke(flux.Annotations{flux.ServiceAnnotationHttpTransport: "MTLS"})
+	tester.Nil(serr)
+	tester.Equal(http.StatusOK, resp.StatusCode)
+	// 默认传输配置不信任测试服务器证书
+	_, serr = invoke(nil)
+	tester.NotNil(serr)
+	_, serr = invoke(flux.Annotations{flux.ServiceAnnotationHttpTransport: "unknown"})
+	tester.NotNil(serr)
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/spf13/cast"
+)
+
+const (
+	ConfigKeyHealthCheckPath               = "path"
+	ConfigKeyHealthCheckInterval           = "interval"
+	ConfigKeyHealthCheckTimeout            = "timeout"
+	ConfigKeyHealthCheckHealthyThreshold   = "healthy_threshold"
+	ConfigKeyHealthCheckUnhealthyThreshold = "unhealthy_threshold"
+)
+
+// HealthCheckConfig 主动健康检查配置；检查请求返回2xx状态码时为成功
+type HealthCheckConfig struct {
+	// 检查请求路径，拼接在目标地址之后
+	Path     string
+	Interval time.Duration
+	Timeout  time.Duration
+	// 不健康的目标连续成功达到阈值时，恢复为健康
+	HealthyThreshold int
+	// 健康的目标连续失败达到阈值时，标记为不健康
+	UnhealthyThreshold int
+}
+
+func NewHealthCheckConfig(values map[string]interface{}) HealthCheckConfig {
+	c := HealthCheckConfig{
+		Path:               cast.ToString(values[ConfigKeyHealthCheckPath]),
+		Interval:           cast.ToDuration(values[ConfigKeyHealthCheckInterval]),
+		Timeout:            cast.ToDuration(values[ConfigKeyHealthCheckTimeout]),
+		HealthyThreshold:   cast.ToInt(values[ConfigKeyHealthCheckHealthyThreshold]),
+		UnhealthyThreshold: cast.ToInt(values[ConfigKeyHealthCheckUnhealthyThreshold]),
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Second * 10
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = time.Second * 3
+	}
+	if c.HealthyThreshold <= 0 {
+		c.HealthyThreshold = 2
+	}
+	if c.UnhealthyThreshold <= 0 {
+		c.UnhealthyThreshold = 3
+	}
+	return c
+}
+
+type healthChecker struct {
+	upstream *Upstream
+	config   HealthCheckConfig
+	client   *http.Client
+	// 连续成功/失败计数：正数为连续成功次数，负数为连续失败次数
+	counts map[*Target]int
+	mu     sync.Mutex
+	stopc  chan struct{}
+}
+
+// newHealthChecker 检查请求复用传输配置的Http客户端(TLS、代理、连接池等)，仅覆盖超时时长
+func newHealthChecker(u *Upstream, c HealthCheckConfig, client *http.Client) *healthChecker {
+	checker := &http.Client{}
+	if client != nil {
+		*checker = *client
+	}
+	checker.Timeout = c.Timeout
+	return &healthChecker{
+		upstream: u,
+		config:   c,
+		client:   checker,
+		counts:   make(map[*Target]int, len(u.targets)),
+	}
+}
+
+func (h *healthChecker) start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopc != nil {
+		return
+	}
+	h.stopc = make(chan struct{})
+	go h.loop(h.stopc)
+}
+
+func (h *healthChecker) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopc != nil {
+		close(h.stopc)
+		h.stopc = nil
+	}
+}
+
+func (h *healthChecker) loop(stopc chan struct{}) {
+	ticker := time.NewTicker(h.config.Interval)
+	defer ticker.Stop()
+	for {
+		h.checkAll()
+		select {
+		case <-stopc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthChecker) checkAll() {
+	var wg sync.WaitGroup
+	for _, t := range h.upstream.targets {
+		wg.Add(1)
+		go func(t *Target) {
+			defer wg.Done()
+			h.update(t, h.check(t))
+		}(t)
+	}
+	wg.Wait()
+}
+
+func (h *healthChecker) check(t *Target) bool {
+	checkUrl, err := t.Resolve(h.config.Path)
+	if nil != err {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkUrl, nil)
+	if nil != err {
+		return false
+	}
+	resp, err := h.client.Do(req)
+	if nil != err {
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+func (h *healthChecker) update(t *Target, healthy bool) {
+	h.mu.Lock()
+	count := h.counts[t]
+	if healthy {
+		if count < 0 {
+			count = 0
+		}
+		count++
+	} else {
+		if count > 0 {
+			count = 0
+		}
+		count--
+	}
+	h.counts[t] = count
+	h.mu.Unlock()
+	if healthy && count >= h.config.HealthyThreshold {
+		if atomic.CompareAndSwapInt32(&t.unhealthy, 1, 0) {
+			logger.Infow("TRANSPORTER:HTTP:UPSTREAM:HEALTHY", "upstream", h.upstream.name, "target", t.URL.String())
+		}
+	} else if !healthy && -count >= h.config.UnhealthyThreshold {
+		if atomic.CompareAndSwapInt32(&t.unhealthy, 0, 1) {
+			logger.Warnw("TRANSPORTER:HTTP:UPSTREAM:UNHEALTHY", "upstream", h.upstream.name, "target", t.URL.String())
+		}
+	}
+}
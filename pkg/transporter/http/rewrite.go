@@ -0,0 +1,256 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/spf13/cast"
+)
+
+const (
+	HeaderXForwardedProto = "X-Forwarded-Proto"
+)
+
+var (
+	pathVarPattern = regexp.MustCompile(`\{([^{}/]+)}`)
+	// 正则重写的编译缓存
+	rewriteRegexps = new(sync.Map)
+)
+
+// HeaderRule Header操作规则
+type HeaderRule struct {
+	// 操作类型：add, set, remove
+	Op    string
+	Name  string
+	Value string
+}
+
+// RewritePath 根据Service的路径重写注解，重写Service的URL：
+// 1. 声明路径模板时，以动态路径参数替换模板变量；
+// 2. 否则，对请求路径（转义形式）依次执行前缀删除和正则重写；
+// 重写后的路径拼接在Service.Url的路径之后。未声明路径重写注解时，返回原URL。
+func RewritePath(ctx flux.Context, service flux.ServiceSpec) (string, error) {
+	template := service.Annotation(flux.ServiceAnnotationHttpRewritePath)
+	prefix := service.Annotation(flux.ServiceAnnotationHttpStripPrefix)
+	regex := service.Annotation(flux.ServiceAnnotationHttpRewriteRegex)
+	if !template.IsValid() && !prefix.IsValid() && !regex.IsValid() {
+		return service.Url, nil
+	}
+	var path string
+	if template.IsValid() {
+		expanded, err := expandPathTemplate(ctx, template.GetString())
+		if nil != err {
+			return "", err
+		}
+		path = expanded
+	} else {
+		path = ctx.URL().EscapedPath()
+		if prefix.IsValid() {
+			path = stripPathPrefix(path, prefix.GetString())
+		}
+		if regex.IsValid() {
+			rewritten, err := rewritePathRegex(path, regex.GetString())
+			if nil != err {
+				return "", err
+			}
+			path = rewritten
+		}
+	}
+	su, err := url.Parse(service.Url)
+	if nil != err {
+		return "", err
+	}
+	// 路径以转义形式重写，避免路径参数中的特殊字符改变路径结构
+	raw := joinURLPath(su.EscapedPath(), path)
+	if su.Path, err = url.PathUnescape(raw); nil != err {
+		return "", err
+	}
+	su.RawPath = raw
+	return su.String(), nil
+}
+
+func expandPathTemplate(ctx flux.Context, template string) (string, error) {
+	var missing, invalid string
+	path := pathVarPattern.ReplaceAllStringFunc(template, func(v string) string {
+		name := v[1 : len(v)-1]
+		value := ctx.PathVar(name)
+		if value == "" && missing == "" {
+			missing = name
+		}
+		if hasDotSegment(value) && invalid == "" {
+			invalid = name
+		}
+		return url.PathEscape(value)
+	})
+	if missing != "" {
+		return "", fmt.Errorf("rewrite path: path variable not found: %s, template: %s", missing, template)
+	}
+	if invalid != "" {
+		return "", fmt.Errorf("rewrite path: path variable contains dot segment: %s, template: %s", invalid, template)
+	}
+	return path, nil
+}
+
+// hasDotSegment 判断路径参数值（包括逐层反转义后的值）是否包含 . 或 .. 路径段，
+// 避免后端反转义路径后发生目录穿越。
+func hasDotSegment(value string) bool {
+	segments := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+	for _, seg := range segments {
+		if seg == "." || seg == ".." {
+			return true
+		}
+	}
+	if unescaped, err := url.PathUnescape(value); nil == err && unescaped != value {
+		return hasDotSegment(unescaped)
+	}
+	return false
+}
+
+func stripPathPrefix(path, prefix string) string {
+	prefix = "/" + strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "/" {
+		return path
+	}
+	if path == prefix {
+		return "/"
+	}
+	if strings.HasPrefix(path, prefix+"/") {
+		return path[len(prefix):]
+	}
+	return path
+}
+
+func rewritePathRegex(path, expr string) (string, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("rewrite regex: invalid expr, require '<pattern> <replacement>': %s", expr)
+	}
+	var pattern *regexp.Regexp
+	if v, ok := rewriteRegexps.Load(parts[0]); ok {
+		pattern = v.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(parts[0])
+		if nil != err {
+			return "", fmt.Errorf("rewrite regex: invalid pattern: %s, error: %w", parts[0], err)
+		}
+		rewriteRegexps.Store(parts[0], compiled)
+		pattern = compiled
+	}
+	return pattern.ReplaceAllString(path, parts[1]), nil
+}
+
+// MethodOf 返回Service声明的请求方法；未声明时返回请求的方法
+func MethodOf(ctx flux.Context, service flux.ServiceSpec) string {
+	if method := service.Annotation(flux.ServiceAnnotationHttpMethod); method.IsValid() {
+		return strings.ToUpper(strings.TrimSpace(method.GetString()))
+	}
+	return ctx.Method()
+}
+
+// RequestHeaderRules 返回Service声明的请求Header操作规则
+func RequestHeaderRules(service flux.ServiceSpec) []HeaderRule {
+	return headerRules(service, flux.ServiceAnnotationHttpRequestHeaderRemove,
+		flux.ServiceAnnotationHttpRequestHeaderSet, flux.ServiceAnnotationHttpRequestHeaderAdd)
+}
+
+// ResponseHeaderRules 返回Service声明的响应Header操作规则
+func ResponseHeaderRules(service flux.ServiceSpec) []HeaderRule {
+	return headerRules(service, flux.ServiceAnnotationHttpResponseHeaderRemove,
+		flux.ServiceAnnotationHttpResponseHeaderSet, flux.ServiceAnnotationHttpResponseHeaderAdd)
+}
+
+// headerRules 按 remove, set, add 的顺序返回Header操作规则
+func headerRules(service flux.ServiceSpec, remove, set, add string) []HeaderRule {
+	rules := make([]HeaderRule, 0)
+	for _, name := range annotationValues(service, remove) {
+		rules = append(rules, HeaderRule{Op: "remove", Name: name})
+	}
+	for _, op := range [][2]string{{"set", set}, {"add", add}} {
+		for _, entry := range annotationValues(service, op[1]) {
+			kv := strings.SplitN(entry, ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			rules = append(rules, HeaderRule{Op: op[0], Name: strings.TrimSpace(kv[0]), Value: strings.TrimSpace(kv[1])})
+		}
+	}
+	return rules
+}
+
+// ApplyHeaderRules 执行Header操作规则
+func ApplyHeaderRules(header http.Header, rules []HeaderRule) {
+	for _, rule := range rules {
+		switch rule.Op {
+		case "remove":
+			header.Del(rule.Name)
+		case "set":
+			header.Set(rule.Name, rule.Value)
+		case "add":
+			header.Add(rule.Name, rule.Value)
+		}
+	}
+}
+
+// annotationValues 返回注解的值列表：字符串以逗号分隔，或者为字符串列表
+func annotationValues(service flux.ServiceSpec, name string) []string {
+	anno, ok := service.AnnotationEx(name)
+	if !ok {
+		return nil
+	}
+	var values []string
+	if str, ok := anno.Value.(string); ok {
+		values = strings.Split(str, ",")
+	} else {
+		values = cast.ToStringSlice(anno.Value)
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ForwardHeaders 转发请求的Header：删除逐跳Header、Host、Content-Length，以及未声明转发的Cookie；
+// 并添加 X-Forwarded-For, X-Forwarded-Host, X-Forwarded-Proto。
+// 直连地址属于可信代理时，保留代理转发的值；否则覆盖客户端提交的值，避免伪造客户端IP、Host和协议。
+func ForwardHeaders(ctx flux.Context, header http.Header) {
+	toolkit.RemoveHopByHopHeaders(header)
+	header.Del("Host")
+	header.Del(flux.HeaderContentLength)
+	if !ctx.Service().Annotation(flux.ServiceAnnotationHttpForwardCookie).GetBoolean() {
+		header.Del(flux.HeaderCookie)
+	}
+	request := ctx.Request()
+	trusted := ctx.TrustedProxy()
+	if ip, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+		if prior := header.Values(flux.HeaderXForwardedFor); trusted && len(prior) > 0 {
+			ip = strings.Join(prior, ", ") + ", " + ip
+		}
+		header.Set(flux.HeaderXForwardedFor, ip)
+	} else if !trusted {
+		header.Del(flux.HeaderXForwardedFor)
+	}
+	if !trusted || "" == header.Get(flux.HeaderXForwardedHost) {
+		header.Set(flux.HeaderXForwardedHost, request.Host)
+	}
+	if !trusted || "" == header.Get(HeaderXForwardedProto) {
+		proto := "http"
+		if request.TLS != nil {
+			proto = "https"
+		}
+		header.Set(HeaderXForwardedProto, proto)
+	}
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRewriteContext(request *http.Request, service flux.ServiceSpec, params map[string]string) flux.Context {
+	request.GetBody = func() (io.ReadCloser, error) {
+		return http.NoBody, nil
+	}
+	echoc := echo.New().NewContext(request, httptest.NewRecorder())
+	names, values := make([]string, 0), make([]string, 0)
+	for k, v := range params {
+		names, values = append(names, k), append(values, v)
+	}
+	echoc.SetParamNames(names...)
+	echoc.SetParamValues(values...)
+	ctx := internal.NewContext()
+	ctx.Reset(listener.NewWebContext(echoc, "test-id", nil),
+		&flux.EndpointSpec{HttpMethod: request.Method, HttpPattern: "/api/users/:id", Service: service})
+	return ctx
+}
+
+func TestRewritePath(t *testing.T) {
+	tester := assert.New(t)
+	cases := []struct {
+		annotations flux.Annotations
+		expected    string
+	}{
+		{annotations: nil, expected: "http://users.svc/base"},
+		{annotations: flux.Annotations{flux.ServiceAnnotationHttpRewritePath: "/v2/users/{id}"}, expected: "http://users.svc/base/v2/users/u%201"},
+		{annotations: flux.Annotations{flux.ServiceAnnotationHttpStripPrefix: "/api/"}, expected: "http://users.svc/base/users/u%201"},
+		{annotations: flux.Annotations{flux.ServiceAnnotationHttpStripPrefix: "/apix"}, expected: "http://users.svc/base/api/users/u%201"},
+		{annotations: flux.Annotations{
+			flux.ServiceAnnotationHttpStripPrefix:  "/api",
+			flux.ServiceAnnotationHttpRewriteRegex: `^/users/(.+)$ /v3/members/$1`,
+		}, expected: "http://users.svc/base/v3/members/u%201"},
+	}
+	for _, c := range cases {
+		service := flux.ServiceSpec{Url: "http://users.svc/base", Annotations: c.annotations}
+		ctx := newRewriteContext(httptest.NewRequest("GET", "/api/users/u%201", nil), service, map[string]string{"id": "u 1"})
+		rewritten, err := RewritePath(ctx, service)
+		tester.NoError(err)
+		tester.Equal(c.expected, rewritten)
+	}
+	// 路径参数中的特殊字符保持转义
+	service := flux.ServiceSpec{Url: "http://users.svc", Annotations: flux.Annotations{flux.ServiceAnnotationHttpRewritePath: "/users/{id}"}}
+	rewritten, err := RewritePath(newRewriteContext(httptest.NewRequest("GET", "/api/users/a%2Fb", nil), service, map[string]string{"id": "a/b"}), service)
+	tester.NoError(err)
+	tester.Equal("http://users.svc/users/a%2Fb", rewritten)
+	// 路径参数包含 . 或 .. 路径段（包括反转义后）
+	for _, id := range []string{".", "..", "%2E%2E", "%252E%252E", "a/../b", "a%2F..%2Fb", `..\b`} {
+		_, err = RewritePath(newRewriteContext(httptest.NewRequest("GET", "/api/users/x", nil), service, map[string]string{"id": id}), service)
+		tester.Error(err, id)
+	}
+	_, err = RewritePath(newRewriteContext(httptest.NewRequest("GET", "/api/users/x", nil), service, map[string]string{"id": "a..b"}), service)
+	tester.NoError(err)
+	service = flux.ServiceSpec{Url: "http://users.svc", Annotations: flux.Annotations{flux.ServiceAnnotationHttpRewritePath: "/users/{uid}"}}
+	_, err = RewritePath(newRewriteContext(httptest.NewRequest("GET", "/api/users/1", nil), service, nil), service)
+	tester.Error(err)
+	service = flux.ServiceSpec{Url: "http://users.svc", Annotations: flux.Annotations{flux.ServiceAnnotationHttpRewriteRegex: "(["}}
+	_, err = RewritePath(newRewriteContext(httptest.NewRequest("GET", "/api/users/1", nil), service, nil), service)
+	tester.Error(err)
+}
+
+func TestTransporterInvokeRewrite(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.Header().Set("X-Internal", "secret")
+		w.Header().Set("Keep-Alive", "timeout=5")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	tr := NewTransporter()
+	tester.NoError(tr.OnInit(flux.NewConfiguration("http_rewrite_test")))
+	service := flux.ServiceSpec{Url: server.URL, Annotations: flux.Annotations{
+		flux.ServiceAnnotationHttpRewritePath:          "/v2/users/{id}",
+		flux.ServiceAnnotationHttpMethod:               "post",
+		flux.ServiceAnnotationHttpRequestHeaderSet:     "X-Env: prod",
+		flux.ServiceAnnotationHttpRequestHeaderAdd:     []interface{}{"Accept: text/plain, application/json"},
+		flux.ServiceAnnotationHttpRequestHeaderRemove:  "X-Debug",
+		flux.ServiceAnnotationHttpResponseHeaderSet:    "Cache-Control: no-store",
+		flux.ServiceAnnotationHttpResponseHeaderRemove: "X-Internal",
+	}}
+	request := httptest.NewRequest("GET", "http://gateway.io/api/users/1", nil)
+	request.Header.Set("Connection", "keep-alive, X-Hop")
+	request.Header.Set("X-Hop", "1")
+	request.Header.Set("Cookie", "sid=1")
+	request.Header.Set("X-Debug", "true")
+	request.Header.Set("X-Forwarded-For", "10.0.0.1")
+	request.Header.Set("X-Forwarded-Host", "evil.io")
+	request.Header.Set("X-Forwarded-Proto", "https")
+	ret, serr := tr.invoke0(newRewriteContext(request, service, map[string]string{"id": "1"}), service)
+	tester.Nil(serr)
+	resp := ret.(*http.Response)
+	tester.NoError(resp.Body.Close())
+	tester.Equal("POST", received.Method)
+	tester.Equal("/v2/users/1", received.URL.Path)
+	tester.Equal("prod", received.Header.Get("X-Env"))
+	tester.Equal([]string{"text/plain, application/json"}, received.Header.Values("Accept"))
+	tester.Empty(received.Header.Get("X-Debug"))
+	tester.Empty(received.Header.Get("X-Hop"))
+	tester.Empty(received.Header.Get("Cookie"))
+	tester.Equal("192.0.2.1", received.Header.Get("X-Forwarded-For"))
+	tester.Equal("gateway.io", received.Header.Get("X-Forwarded-Host"))
+	tester.Equal("http", received.Header.Get("X-Forwarded-Proto"))
+	tester.Equal("no-store", resp.Header.Get("Cache-Control"))
+	tester.Empty(resp.Header.Get("X-Internal"))
+	tester.Empty(resp.Header.Get("Keep-Alive"))
+	// 原始请求的Header不被修改
+	tester.Equal("sid=1", request.Header.Get("Cookie"))
+}
+
+// trustedWebContext 模拟直连地址属于可信代理的请求
+type trustedWebContext struct {
+	flux.WebContext
+}
+
+func (c *trustedWebContext) TrustedProxy() bool {
+	return true
+}
+
+func TestForwardHeaders(t *testing.T) {
+	tester := assert.New(t)
+	newRequest := func() *http.Request {
+		request := httptest.NewRequest("GET", "http://gateway.io/api/users/1", nil)
+		request.Header.Set("X-Forwarded-For", "10.0.0.1")
+		request.Header.Set("X-Forwarded-Host", "www.example.com")
+		request.Header.Set("X-Forwarded-Proto", "https")
+		return request
+	}
+	// 直连地址不属于可信代理，覆盖客户端提交的值
+	request := newRequest()
+	header := request.Header.Clone()
+	ForwardHeaders(newRewriteContext(request, flux.ServiceSpec{}, nil), header)
+	tester.Equal("192.0.2.1", header.Get("X-Forwarded-For"))
+	tester.Equal("gateway.io", header.Get("X-Forwarded-Host"))
+	tester.Equal("http", header.Get("X-Forwarded-Proto"))
+	// 直连地址属于可信代理，保留代理转发的值
+	request = newRequest()
+	header = request.Header.Clone()
+	ctx := internal.NewContext()
+	ctx.Reset(&trustedWebContext{WebContext: listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil)},
+		&flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/api/users/:id"})
+	ForwardHeaders(ctx, header)
+	tester.Equal("10.0.0.1, 192.0.2.1", header.Get("X-Forwarded-For"))
+	tester.Equal("www.example.com", header.Get("X-Forwarded-Host"))
+	tester.Equal("https", header.Get("X-Forwarded-Proto"))
+	// 可信代理未提交的值，由网关添加
+	request.Header.Del("X-Forwarded-Host")
+	request.Header.Del("X-Forwarded-Proto")
+	header = request.Header.Clone()
+	ForwardHeaders(ctx, header)
+	tester.Equal("gateway.io", header.Get("X-Forwarded-Host"))
+	tester.Equal("http", header.Get("X-Forwarded-Proto"))
+}
@@ -1,9 +1,14 @@
 package http
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +17,9 @@ import (
 	"github.com/bytepowered/fluxgo/pkg/flux"
 	"github.com/bytepowered/fluxgo/pkg/logger"
 	"github.com/bytepowered/fluxgo/pkg/toolkit"
+	"github.com/bytepowered/fluxgo/pkg/tracing"
+	"github.com/bytepowered/fluxgo/pkg/transporter"
+	"github.com/spf13/cast"
 )
 
 func init() {
@@ -26,6 +34,8 @@ func init() {
 
 var _ flux.Transporter = new(RpcTransporter)
 var _ flux.Initializer = new(RpcTransporter)
+var _ flux.Startuper = new(RpcTransporter)
+var _ flux.Shutdowner = new(RpcTransporter)
 
 type (
 	// Option 配置函数
@@ -37,11 +47,27 @@ type (
 )
 
 type RpcTransporter struct {
-	client          *http.Client
+	client *http.Client
+	// 通过 WithHttpClient 配置的客户端，不使用传输配置创建
+	clientFixed     bool
 	codec           flux.TransportCodecFunc
 	trace           bool
+	timeout         time.Duration
 	assembleRequest AssembleRequestFunc
 	assembleHeader  AssemblyHeadersFunc
+	// 命名Upstream，定义在 upstreams 配置中
+	upstreams map[string]*Upstream
+	// 由 flux.go/http.targets 注解创建的Upstream
+	inlines sync.Map
+	// 命名传输配置的客户端
+	transports map[string]*transport
+	// WebSocket代理配置
+	websocket WebSocketConfig
+}
+
+type transport struct {
+	client  *http.Client
+	timeout time.Duration
 }
 
 func NewTransporter() *RpcTransporter {
@@ -50,8 +76,12 @@ func NewTransporter() *RpcTransporter {
 			Timeout: time.Second * 10,
 		},
 		codec:           NewTransportCodecFunc(),
+		timeout:         time.Second * 10,
 		assembleRequest: DefaultAssembleRequest,
 		assembleHeader:  DefaultAssembleHeaders,
+		upstreams:       make(map[string]*Upstream, 0),
+		transports:      make(map[string]*transport, 0),
+		websocket:       NewWebSocketConfig(nil),
 	}
 }
 
@@ -67,6 +97,7 @@ func NewTransporterWith(opts ...Option) *RpcTransporter {
 func WithHttpClient(client *http.Client) Option {
 	return func(s *RpcTransporter) {
 		s.client = client
+		s.clientFixed = true
 	}
 }
 
@@ -96,10 +127,64 @@ func (b *RpcTransporter) OnInit(config *flux.Configuration) error {
 	flux.AssertNotNil(b.codec, "<TransportCodecFunc> MUST NOT nil")
 	flux.AssertNotNil(b.assembleHeader, "<AssemblyHeadersFunc> MUST NOT nil")
 	flux.AssertNotNil(b.assembleRequest, "<AssembleRequestFunc> MUST NOT nil")
+	// transport
+	cc := NewClientConfig(config, DefaultClientConfig())
+	b.timeout = cc.Timeout
+	if !b.clientFixed {
+		client, err := NewHttpClient(cc)
+		if nil != err {
+			return err
+		}
+		b.client = client
+	}
+	for name, values := range cast.ToStringMap(config.Get(ConfigKeyTransports)) {
+		tc := NewClientConfig(flux.NewVarsConfiguration(cast.ToStringMap(values)), cc)
+		client, err := NewHttpClient(tc)
+		if nil != err {
+			return fmt.Errorf("http transport: %s, %w", name, err)
+		}
+		b.transports[toolkit.LowerKey(name)] = &transport{client: client, timeout: tc.Timeout}
+	}
+	// upstream
+	for name, values := range cast.ToStringMap(config.Get(ConfigKeyUpstreams)) {
+		uc, err := NewUpstreamConfig(name, cast.ToStringMap(values))
+		if nil != err {
+			return err
+		}
+		t, err := b.transportByName(uc.Transport)
+		if nil != err {
+			return fmt.Errorf("http upstream: %s, %w", name, err)
+		}
+		uc.Client = t.client
+		up, err := NewUpstream(uc)
+		if nil != err {
+			return err
+		}
+		b.upstreams[toolkit.LowerKey(name)] = up
+	}
+	// websocket
+	b.websocket = NewWebSocketConfig(cast.ToStringMap(config.Get(ConfigKeyWebSocket)))
+	return nil
+}
+
+func (b *RpcTransporter) OnStartup() error {
+	for _, up := range b.upstreams {
+		up.startHealthCheck()
+	}
+	return nil
+}
+
+func (b *RpcTransporter) OnShutdown(_ context.Context) error {
+	for _, up := range b.upstreams {
+		up.stopHealthCheck()
+	}
 	return nil
 }
 
 func (b *RpcTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	if IsWebSocketProxy(ctx, service) {
+		return b.invokeWebSocket(ctx, service)
+	}
 	invret, inverr := b.invoke0(ctx, service)
 	if inverr != nil {
 		return nil, inverr
@@ -118,10 +203,69 @@ func (b *RpcTransporter) DoInvoke(ctx flux.Context, service flux.ServiceSpec) (*
 }
 
 func (b *RpcTransporter) invoke0(ctx flux.Context, service flux.ServiceSpec) (interface{}, *flux.ServeError) {
-	flux.AssertNotEmpty(service.Url, "<service.url> MUST NOT empty in http transporter")
+	// transport
+	transport, err := b.transportOf(service)
+	if nil != err {
+		logger.TraceExtras(ctx.RequestId(), map[string]string{
+			"invoke.service": service.ServiceID(),
+		}).Errorw("TRANSPORTER:HTTP:TRANSPORT", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportHttpAssembleFailed,
+			CauseError: err,
+		}
+	}
+	// upstream
+	upstream, err := b.upstreamOf(service)
+	if nil != err {
+		logger.TraceExtras(ctx.RequestId(), map[string]string{
+			"invoke.service": service.ServiceID(),
+		}).Errorw("TRANSPORTER:HTTP:UPSTREAM", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportHttpAssembleFailed,
+			CauseError: err,
+		}
+	}
+	// rewrite
+	if service.Url, err = RewritePath(ctx, service); nil != err {
+		logger.TraceExtras(ctx.RequestId(), map[string]string{
+			"invoke.service": service.ServiceID(),
+		}).Errorw("TRANSPORTER:HTTP:REWRITE", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusServerError,
+			ErrorCode:  flux.ErrorCodeGatewayInternal,
+			Message:    flux.ErrorMessageTransportHttpAssembleFailed,
+			CauseError: err,
+		}
+	}
+	var target *Target
+	if upstream != nil {
+		target = upstream.Select(ctx)
+		service.Url, err = target.Resolve(service.Url)
+	} else {
+		flux.AssertNotEmpty(service.Url, "<service.url> MUST NOT empty in http transporter")
+	}
+	// 调用结束时，释放上游目标并记录调用结果
+	done := func(success bool) {
+		if target != nil {
+			upstream.Done(target, success)
+		}
+	}
+	release := func() {
+		if target != nil {
+			upstream.release(target)
+		}
+	}
 	// request
-	newRequest, err := b.assembleRequest(ctx, &service)
+	var newRequest *http.Request
+	if nil == err {
+		newRequest, err = b.assembleRequest(ctx, &service)
+	}
 	if nil != err {
+		release()
 		logger.TraceExtras(ctx.RequestId(), map[string]string{
 			"invoke.service":     service.ServiceID(),
 			"invoke.service.url": service.Url,
@@ -140,6 +284,7 @@ func (b *RpcTransporter) invoke0(ctx flux.Context, service flux.ServiceSpec) (in
 	// header
 	header, err := b.assembleHeader(ctx)
 	if err != nil {
+		release()
 		trace.Errorw("TRANSPORTER:HTTP:ASSEMBLE/header", "error", err)
 		return nil, &flux.ServeError{
 			StatusCode: flux.StatusServerError,
@@ -153,17 +298,60 @@ func (b *RpcTransporter) invoke0(ctx flux.Context, service flux.ServiceSpec) (in
 			newRequest.Header.Add(k, v)
 		}
 	}
+	ApplyHeaderRules(newRequest.Header, RequestHeaderRules(service))
+	// 服务超时时长不超过网关请求的剩余时长，约束等待响应头阶段；普通请求的响应体沿用网关请求的截止时间；
+	// SSE请求的响应体以流式读取，由客户端取消请求或关闭响应体结束，不受截止时间限制。
+	timeout := b.timeoutOf(service, transport.timeout)
+	if remaining, ok := transporter.RemainingTimeout(ctx.Context()); ok && remaining < timeout {
+		timeout = remaining
+	}
+	var client <-chan struct{}
+	reqctx, cancel := context.WithCancel(ctx.Context())
+	if isEventStream(ctx) {
+		reqctx, cancel = context.WithCancel(detachedContext{parent: ctx.Context()})
+		if request := ctx.Request(); request != nil {
+			client = request.Context().Done()
+		}
+	}
+	newRequest = newRequest.WithContext(reqctx)
+	newRequest.Header.Set(flux.HeaderXRequestTimeout, transporter.FormatTimeout(timeout))
+	tracing.InjectHeader(reqctx, newRequest.Header)
 	if b.trace {
 		bodys := string(toolkit.ReadReaderBytes(ctx.BodyReader()))
 		trace.Infow("TRANSPORTER:HTTP:INVOKE/args",
 			"arg-query", newRequest.URL.RawQuery, "arg-body", bodys, "arg-header", header)
 	}
-	return b.execute(newRequest)
+	var expired int32
+	timer := time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&expired, 1)
+		cancel()
+	})
+	resp, serr := b.execute(ctx, transport.client, newRequest)
+	timer.Stop()
+	if serr != nil {
+		cancel()
+		done(false)
+		if atomic.LoadInt32(&expired) == 1 && !transporter.IsRequestTimeout(ctx) {
+			serr.CauseError = fmt.Errorf("response header timeout: %s, %w", timeout, serr.CauseError)
+		}
+		return nil, serr
+	}
+	toolkit.RemoveHopByHopHeaders(resp.Header)
+	ApplyHeaderRules(resp.Header, ResponseHeaderRules(service))
+	success := !isUpstreamFailure(resp.StatusCode)
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, client: client, cancel: func() {
+		cancel()
+		done(success)
+	}}
+	return resp, nil
 }
 
-func (b *RpcTransporter) execute(request *http.Request) (interface{}, *flux.ServeError) {
-	resp, err := b.client.Do(request)
+func (b *RpcTransporter) execute(ctx flux.Context, client *http.Client, request *http.Request) (*http.Response, *flux.ServeError) {
+	resp, err := client.Do(request)
 	if nil != err {
+		if transporter.IsRequestTimeout(ctx) {
+			return nil, transporter.NewRequestTimeoutError(ctx)
+		}
 		msg := flux.ErrorMessageTransportHttpInvokeFailed
 		if uErr, ok := err.(*url.Error); ok {
 			msg = fmt.Sprintf("HTTPEX:REMOTE_ERROR:%s", uErr.Error())
@@ -177,3 +365,126 @@ func (b *RpcTransporter) execute(request *http.Request) (interface{}, *flux.Serv
 	}
 	return resp, nil
 }
+
+func (b *RpcTransporter) timeoutOf(service flux.ServiceSpec, timeout time.Duration) time.Duration {
+	if to := service.Annotation(flux.ServiceAnnotationRpcTimeout); to.IsValid() {
+		if d, err := cast.ToDurationE(to.GetString()); err == nil && d > 0 {
+			return d
+		}
+	}
+	return timeout
+}
+
+// transportOf 返回Service声明的命名传输配置；未声明时返回默认配置
+func (b *RpcTransporter) transportOf(service flux.ServiceSpec) (*transport, error) {
+	name := service.Annotation(flux.ServiceAnnotationHttpTransport)
+	if !name.IsValid() {
+		return b.transportByName("")
+	}
+	return b.transportByName(name.GetString())
+}
+
+// transportByName 返回命名传输配置；名称为空时返回默认配置
+func (b *RpcTransporter) transportByName(name string) (*transport, error) {
+	if name == "" {
+		return &transport{client: b.client, timeout: b.timeout}, nil
+	}
+	if t, ok := b.transports[toolkit.LowerKey(name)]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("transport not found, name: %s", name)
+}
+
+// upstreamOf 返回Service声明的Upstream；未声明时返回nil
+func (b *RpcTransporter) upstreamOf(service flux.ServiceSpec) (*Upstream, error) {
+	if name := service.Annotation(flux.ServiceAnnotationHttpUpstream); name.IsValid() {
+		if up, ok := b.upstreams[toolkit.LowerKey(name.GetString())]; ok {
+			return up, nil
+		}
+		return nil, fmt.Errorf("upstream not found, name: %s", name.GetString())
+	}
+	targets := service.Annotation(flux.ServiceAnnotationHttpTargets)
+	if !targets.IsValid() {
+		return nil, nil
+	}
+	balancer := service.Annotation(flux.ServiceAnnotationHttpBalancer).GetString()
+	hashKey := service.Annotation(flux.ServiceAnnotationHttpHashKey).GetString()
+	key := targets.GetString() + "|" + balancer + "|" + hashKey
+	if up, ok := b.inlines.Load(key); ok {
+		return up.(*Upstream), nil
+	}
+	exprs := strings.Split(targets.GetString(), ",")
+	items := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		items[i] = expr
+	}
+	uc, err := NewUpstreamConfig(targets.GetString(), map[string]interface{}{
+		ConfigKeyUpstreamTargets:  items,
+		ConfigKeyUpstreamBalancer: balancer,
+		ConfigKeyUpstreamHashKey:  hashKey,
+	})
+	if nil != err {
+		return nil, err
+	}
+	up, err := NewUpstream(uc)
+	if nil != err {
+		return nil, err
+	}
+	actual, _ := b.inlines.LoadOrStore(key, up)
+	return actual.(*Upstream), nil
+}
+
+// isUpstreamFailure 网关类错误响应计为上游目标调用失败
+func isUpstreamFailure(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// isEventStream 判断客户端是否请求SSE事件流
+func isEventStream(ctx flux.Context) bool {
+	return strings.Contains(ctx.HeaderVar(flux.HeaderAccept), flux.MIMETextEventStream)
+}
+
+// detachedContext 仅继承父Context的值，不继承截止时间和取消信号
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// cancelReadCloser 关闭响应体时，释放后端调用的Context；
+// 读取响应体时检查客户端是否已取消请求，已取消时结束读取。
+type cancelReadCloser struct {
+	io.ReadCloser
+	client <-chan struct{}
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (r *cancelReadCloser) Read(p []byte) (int, error) {
+	select {
+	case <-r.client:
+		r.once.Do(r.cancel)
+		return 0, context.Canceled
+	default:
+		return r.ReadCloser.Read(p)
+	}
+}
+
+func (r *cancelReadCloser) Close() error {
+	defer r.once.Do(r.cancel)
+	return r.ReadCloser.Close()
+}
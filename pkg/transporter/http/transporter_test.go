@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransporterStreamTimeout(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Millisecond * 200)
+		}
+		w.Header().Set(flux.HeaderContentType, flux.MIMETextEventStream)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for i := 0; i < 4; i++ {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond * 50):
+			}
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer backend.Close()
+	tr := NewTransporter()
+	tester.NoError(tr.OnInit(flux.NewConfiguration("http_stream_test")))
+	invoke := func(client context.Context, path string, timeout time.Duration, accept string) (interface{}, *flux.ServeError) {
+		request := httptest.NewRequest("GET", "/events", nil).WithContext(client)
+		request.Header.Set(flux.HeaderAccept, accept)
+		request.GetBody = func() (io.ReadCloser, error) {
+			return http.NoBody, nil
+		}
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+			&flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/events"})
+		// 网关请求的截止时间
+		toctx, cancel := context.WithTimeout(ctx.Context(), timeout)
+		t.Cleanup(cancel)
+		ctx.SetContext(toctx)
+		return tr.invoke0(ctx, flux.ServiceSpec{
+			Url: backend.URL + path, Method: "GET",
+			Annotations: flux.Annotations{flux.ServiceAnnotationRpcTimeout: timeout.String()},
+		})
+	}
+	// 响应头在超时时长内返回，响应体持续读取超过超时时长
+	start := time.Now()
+	ret, serr := invoke(context.Background(), "/events", time.Millisecond*100, flux.MIMETextEventStream)
+	tester.Nil(serr)
+	resp := ret.(*http.Response)
+	data, err := ioutil.ReadAll(resp.Body)
+	tester.NoError(err)
+	tester.NoError(resp.Body.Close())
+	tester.Equal("data: 0\n\ndata: 1\n\ndata: 2\n\ndata: 3\n\n", string(data))
+	tester.True(time.Since(start) >= time.Millisecond*200)
+	// 超时时长内未返回响应头
+	_, serr = invoke(context.Background(), "/slow", time.Millisecond*100, flux.MIMETextEventStream)
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorCodeRequestTimeout, serr.ErrorCode)
+	// 客户端取消请求时，取消响应体读取
+	client, cancel := context.WithCancel(context.Background())
+	ret, serr = invoke(client, "/events", time.Millisecond*100, flux.MIMETextEventStream)
+	tester.Nil(serr)
+	resp = ret.(*http.Response)
+	line := make([]byte, len("data: 0\n\n"))
+	_, err = io.ReadFull(resp.Body, line)
+	tester.NoError(err)
+	cancel()
+	data, err = ioutil.ReadAll(resp.Body)
+	tester.Error(err)
+	tester.False(strings.Contains(string(data), "data: 3"))
+	tester.NoError(resp.Body.Close())
+	// 非SSE请求，响应体读取受网关请求的截止时间限制
+	ret, serr = invoke(context.Background(), "/events", time.Millisecond*100, flux.MIMEApplicationJSON)
+	tester.Nil(serr)
+	resp = ret.(*http.Response)
+	data, err = ioutil.ReadAll(resp.Body)
+	tester.Error(err)
+	tester.False(strings.Contains(string(data), "data: 3"))
+	tester.NoError(resp.Body.Close())
+}
@@ -0,0 +1,273 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/spf13/cast"
+)
+
+const (
+	ConfigKeyUpstreams            = "upstreams"
+	ConfigKeyUpstreamTargets      = "targets"
+	ConfigKeyUpstreamBalancer     = "balancer"
+	ConfigKeyUpstreamHashKey      = "hash_key"
+	ConfigKeyUpstreamMaxFailures  = "max_failures"
+	ConfigKeyUpstreamEjectTimeout = "eject_duration"
+	ConfigKeyUpstreamHealthCheck  = "health_check"
+	ConfigKeyUpstreamTransport    = "transport"
+)
+
+var (
+	ErrNoUpstreamTarget = errors.New("upstream: no target")
+)
+
+// Target 上游目标
+type Target struct {
+	URL    *url.URL
+	Weight int
+	// 当前进行中的请求数
+	active int64
+	// 主动健康检查标记为不健康
+	unhealthy int32
+	// 被动健康检查状态
+	mu           sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+}
+
+// NewTarget 解析目标地址，格式：URL[;weight=N]
+func NewTarget(expr string) (*Target, error) {
+	parts := strings.Split(strings.TrimSpace(expr), ";")
+	weight := 1
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 && strings.EqualFold(kv[0], "weight") {
+			weight = cast.ToInt(kv[1])
+		}
+	}
+	return newTarget(parts[0], weight)
+}
+
+func newTarget(rawurl string, weight int) (*Target, error) {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if nil != err {
+		return nil, fmt.Errorf("upstream: invalid target url: %s, error: %w", rawurl, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("upstream: target url requires scheme and host: %s", rawurl)
+	}
+	if weight <= 0 {
+		weight = 1
+	}
+	return &Target{URL: u, Weight: weight}, nil
+}
+
+// Active 返回当前进行中的请求数
+func (t *Target) Active() int64 {
+	return atomic.LoadInt64(&t.active)
+}
+
+// Available 返回目标是否可用：未被主动健康检查标记为不健康，且未被被动健康检查驱逐
+func (t *Target) Available(now time.Time) bool {
+	if atomic.LoadInt32(&t.unhealthy) == 1 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !now.Before(t.ejectedUntil)
+}
+
+// Resolve 使用目标地址的协议、主机和路径前缀，替换Service的URL
+func (t *Target) Resolve(serviceUrl string) (string, error) {
+	su, err := url.Parse(serviceUrl)
+	if nil != err {
+		return "", err
+	}
+	out := *t.URL
+	out.Path = joinURLPath(t.URL.Path, su.Path)
+	out.RawPath = ""
+	switch {
+	case out.RawQuery == "":
+		out.RawQuery = su.RawQuery
+	case su.RawQuery != "":
+		out.RawQuery += "&" + su.RawQuery
+	}
+	return out.String(), nil
+}
+
+// onResult 记录调用结果；连续失败达到阈值时，驱逐目标
+func (t *Target) onResult(success bool, maxFailures int, eject time.Duration) (ejected bool) {
+	if maxFailures <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if success {
+		t.failures = 0
+		return false
+	}
+	t.failures++
+	if t.failures >= maxFailures {
+		t.failures = 0
+		t.ejectedUntil = time.Now().Add(eject)
+		return true
+	}
+	return false
+}
+
+func joinURLPath(base, path string) string {
+	switch {
+	case base == "" || base == "/":
+		return path
+	case path == "" || path == "/":
+		return base
+	default:
+		return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+}
+
+// UpstreamConfig 上游服务池配置
+type UpstreamConfig struct {
+	Name     string
+	Targets  []*Target
+	Balancer string
+	// 一致性Hash的查找表达式
+	HashKey string
+	// 被动健康检查：连续失败次数达到阈值时驱逐目标；为0时不启用
+	MaxFailures int
+	// 被动健康检查：目标被驱逐的时长
+	EjectDuration time.Duration
+	// 主动健康检查；Path为空时不启用
+	HealthCheck HealthCheckConfig
+	// 主动健康检查使用的命名传输配置；为空时使用默认配置
+	Transport string
+	// 主动健康检查使用的Http客户端，由Transport解析；检查请求仅覆盖其超时时长
+	Client *http.Client
+}
+
+// Upstream 上游服务池
+type Upstream struct {
+	name          string
+	targets       []*Target
+	balancer      Balancer
+	maxFailures   int
+	ejectDuration time.Duration
+	checker       *healthChecker
+}
+
+func NewUpstream(c UpstreamConfig) (*Upstream, error) {
+	if len(c.Targets) == 0 {
+		return nil, fmt.Errorf("%w, upstream: %s", ErrNoUpstreamTarget, c.Name)
+	}
+	balancer, err := NewBalancer(c.Balancer, c.Targets, c.HashKey)
+	if nil != err {
+		return nil, err
+	}
+	u := &Upstream{
+		name:          c.Name,
+		targets:       c.Targets,
+		balancer:      balancer,
+		maxFailures:   c.MaxFailures,
+		ejectDuration: c.EjectDuration,
+	}
+	if "" != c.HealthCheck.Path {
+		u.checker = newHealthChecker(u, c.HealthCheck, c.Client)
+	}
+	return u, nil
+}
+
+// NewUpstreamConfig 从配置数据中解析上游服务池配置
+func NewUpstreamConfig(name string, values map[string]interface{}) (UpstreamConfig, error) {
+	c := UpstreamConfig{
+		Name:          name,
+		Balancer:      cast.ToString(values[ConfigKeyUpstreamBalancer]),
+		HashKey:       cast.ToString(values[ConfigKeyUpstreamHashKey]),
+		Transport:     cast.ToString(values[ConfigKeyUpstreamTransport]),
+		MaxFailures:   3,
+		EjectDuration: time.Second * 30,
+	}
+	if v, ok := values[ConfigKeyUpstreamMaxFailures]; ok {
+		c.MaxFailures = cast.ToInt(v)
+	}
+	if v, ok := values[ConfigKeyUpstreamEjectTimeout]; ok {
+		c.EjectDuration = cast.ToDuration(v)
+	}
+	for _, item := range cast.ToSlice(values[ConfigKeyUpstreamTargets]) {
+		var target *Target
+		var err error
+		if expr, ok := item.(string); ok {
+			target, err = NewTarget(expr)
+		} else {
+			m := cast.ToStringMap(item)
+			target, err = newTarget(cast.ToString(m["url"]), cast.ToInt(m["weight"]))
+		}
+		if nil != err {
+			return c, err
+		}
+		c.Targets = append(c.Targets, target)
+	}
+	if v, ok := values[ConfigKeyUpstreamHealthCheck]; ok {
+		c.HealthCheck = NewHealthCheckConfig(cast.ToStringMap(v))
+	}
+	return c, nil
+}
+
+// Select 选择上游目标，并计入进行中的请求；调用完成后必须调用 Done。
+// 全部目标不可用时，从全部目标中选择。
+func (u *Upstream) Select(ctx flux.Context) *Target {
+	now := time.Now()
+	available := make([]*Target, 0, len(u.targets))
+	for _, t := range u.targets {
+		if t.Available(now) {
+			available = append(available, t)
+		}
+	}
+	if len(available) == 0 {
+		logger.Trace(ctx.RequestId()).Warnw("TRANSPORTER:HTTP:UPSTREAM:NO_AVAILABLE", "upstream", u.name)
+		available = u.targets
+	}
+	t := u.balancer.Select(ctx, available)
+	atomic.AddInt64(&t.active, 1)
+	return t
+}
+
+// Done 结束目标的调用，记录被动健康检查结果
+func (u *Upstream) Done(t *Target, success bool) {
+	atomic.AddInt64(&t.active, -1)
+	if t.onResult(success, u.maxFailures, u.ejectDuration) {
+		logger.Warnw("TRANSPORTER:HTTP:UPSTREAM:EJECT", "upstream", u.name, "target", t.URL.String(), "duration", u.ejectDuration)
+	}
+}
+
+// release 结束目标的调用，不记录调用结果
+func (u *Upstream) release(t *Target) {
+	atomic.AddInt64(&t.active, -1)
+}
+
+// Targets 返回全部上游目标
+func (u *Upstream) Targets() []*Target {
+	return u.targets
+}
+
+func (u *Upstream) startHealthCheck() {
+	if u.checker != nil {
+		u.checker.start()
+	}
+}
+
+func (u *Upstream) stopHealthCheck() {
+	if u.checker != nil {
+		u.checker.stop()
+	}
+}
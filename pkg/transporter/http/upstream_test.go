@@ -0,0 +1,231 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/common"
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContext(headers map[string]string) flux.Context {
+	request := httptest.NewRequest("GET", "/orders", nil)
+	request.GetBody = func() (io.ReadCloser, error) {
+		return http.NoBody, nil
+	}
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	ctx := internal.NewContext()
+	ctx.Reset(listener.NewWebContext(echo.New().NewContext(request, httptest.NewRecorder()), "test-id", nil),
+		&flux.EndpointSpec{HttpMethod: "GET", HttpPattern: "/orders"})
+	return ctx
+}
+
+func TestTargetResolve(t *testing.T) {
+	tester := assert.New(t)
+	target, err := NewTarget(" http://10.0.0.1:8080/api?v=1; weight=5 ")
+	tester.NoError(err)
+	tester.Equal(5, target.Weight)
+	cases := map[string]string{
+		"":                              "http://10.0.0.1:8080/api?v=1",
+		"/orders/1":                     "http://10.0.0.1:8080/api/orders/1?v=1",
+		"http://orders.svc/orders?id=2": "http://10.0.0.1:8080/api/orders?v=1&id=2",
+	}
+	for service, expected := range cases {
+		resolved, err := target.Resolve(service)
+		tester.NoError(err)
+		tester.Equal(expected, resolved, service)
+	}
+	_, err = NewTarget("/orders")
+	tester.Error(err)
+}
+
+func TestUpstreamBalancers(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLookupScopedValueFunc(common.LookupValueByScoped)
+	newUpstream := func(balancer string) *Upstream {
+		c, err := NewUpstreamConfig("orders", map[string]interface{}{
+			ConfigKeyUpstreamTargets: []interface{}{
+				"http://a.svc;weight=3",
+				map[string]interface{}{"url": "http://b.svc", "weight": 1},
+			},
+			ConfigKeyUpstreamBalancer:     balancer,
+			ConfigKeyUpstreamHashKey:      "HEADER:X-User-Id",
+			ConfigKeyUpstreamMaxFailures:  2,
+			ConfigKeyUpstreamEjectTimeout: "1m",
+		})
+		tester.NoError(err)
+		u, err := NewUpstream(c)
+		tester.NoError(err)
+		return u
+	}
+	// 平滑加权轮询
+	rr := newUpstream(BalancerRoundRobin)
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		target := rr.Select(newTestContext(nil))
+		counts[target.URL.Host]++
+		rr.Done(target, true)
+	}
+	tester.Equal(map[string]int{"a.svc": 6, "b.svc": 2}, counts)
+	// 最少连接：按连接数与权重的比值选择
+	lc := newUpstream(BalancerLeastConn)
+	for i := 0; i < 4; i++ {
+		lc.Select(newTestContext(nil))
+	}
+	tester.Equal(int64(3), lc.Targets()[0].Active())
+	tester.Equal(int64(1), lc.Targets()[1].Active())
+	// 一致性Hash：相同查找值选择相同目标
+	ch := newUpstream(BalancerConsistentHash)
+	selected := ch.Select(newTestContext(map[string]string{"X-User-Id": "u-1001"}))
+	for i := 0; i < 10; i++ {
+		tester.Equal(selected, ch.Select(newTestContext(map[string]string{"X-User-Id": "u-1001"})))
+	}
+	// 被动健康检查：连续失败后驱逐目标
+	ch.Done(selected, false)
+	ch.Done(selected, false)
+	tester.False(selected.Available(time.Now()))
+	tester.NotEqual(selected, ch.Select(newTestContext(map[string]string{"X-User-Id": "u-1001"})))
+	_, err := NewBalancer(BalancerConsistentHash, nil, "")
+	tester.Error(err)
+	_, err = NewBalancer("unknown", nil, "")
+	tester.Error(err)
+}
+
+func TestUpstreamHealthCheck(t *testing.T) {
+	tester := assert.New(t)
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthy && r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	c, err := NewUpstreamConfig("orders", map[string]interface{}{
+		ConfigKeyUpstreamTargets: []interface{}{server.URL},
+		ConfigKeyUpstreamHealthCheck: map[string]interface{}{
+			"path": "/health", "healthy_threshold": 1, "unhealthy_threshold": 2,
+		},
+	})
+	tester.NoError(err)
+	u, err := NewUpstream(c)
+	tester.NoError(err)
+	target := u.Targets()[0]
+	healthy = false
+	u.checker.checkAll()
+	tester.True(target.Available(time.Now()))
+	u.checker.checkAll()
+	tester.False(target.Available(time.Now()))
+	healthy = true
+	u.checker.checkAll()
+	tester.True(target.Available(time.Now()))
+}
+
+func TestUpstreamHealthCheckTransport(t *testing.T) {
+	tester := assert.New(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	newChecker := func(transport string) *healthChecker {
+		config := flux.NewConfiguration("http_health_transport_test")
+		config.Set(ConfigKeyTransports, map[string]interface{}{
+			"insecure": map[string]interface{}{ConfigKeyTLSInsecureSkipVerify: true},
+		})
+		config.Set(ConfigKeyUpstreams, map[string]interface{}{
+			"orders": map[string]interface{}{
+				ConfigKeyUpstreamTargets:     []interface{}{server.URL},
+				ConfigKeyUpstreamTransport:   transport,
+				ConfigKeyUpstreamHealthCheck: map[string]interface{}{"path": "/health", "timeout": "1s", "unhealthy_threshold": 1},
+			},
+		})
+		tr := NewTransporter()
+		tester.NoError(tr.OnInit(config))
+		checker := tr.upstreams["orders"].checker
+		tester.Equal(time.Second, checker.client.Timeout)
+		return checker
+	}
+	// 默认传输配置不信任测试证书，检查失败
+	checker := newChecker("")
+	checker.checkAll()
+	tester.False(checker.upstream.Targets()[0].Available(time.Now()))
+	// 使用命名传输配置的TLS设置
+	checker = newChecker("insecure")
+	checker.checkAll()
+	tester.True(checker.upstream.Targets()[0].Available(time.Now()))
+	// 未定义的传输配置
+	config := flux.NewConfiguration("http_health_transport_test")
+	config.Set(ConfigKeyUpstreams, map[string]interface{}{
+		"orders": map[string]interface{}{ConfigKeyUpstreamTargets: []interface{}{server.URL}, ConfigKeyUpstreamTransport: "none"},
+	})
+	tester.Error(NewTransporter().OnInit(config))
+}
+
+func TestTransporterInvokeUpstream(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	newServer := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Server", name)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(r.URL.Path))
+		}))
+	}
+	good, bad := newServer("good", http.StatusOK), newServer("bad", http.StatusBadGateway)
+	defer good.Close()
+	defer bad.Close()
+	config := flux.NewConfiguration("http_upstream_test")
+	config.Set(ConfigKeyUpstreams, map[string]interface{}{
+		"orders": map[string]interface{}{
+			ConfigKeyUpstreamTargets:     []interface{}{good.URL, bad.URL},
+			ConfigKeyUpstreamMaxFailures: 1,
+		},
+	})
+	tr := NewTransporter()
+	tester.NoError(tr.OnInit(config))
+	invoke := func(annotations flux.Annotations) (*http.Response, *flux.ServeError) {
+		ret, serr := tr.invoke0(newTestContext(nil), flux.ServiceSpec{
+			Url: "/orders/1", Method: "GET", Annotations: annotations,
+		})
+		if serr != nil {
+			return nil, serr
+		}
+		resp := ret.(*http.Response)
+		tester.NoError(resp.Body.Close())
+		return resp, nil
+	}
+	servers := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		resp, serr := invoke(flux.Annotations{flux.ServiceAnnotationHttpUpstream: "orders"})
+		tester.Nil(serr)
+		servers[resp.Header.Get("X-Server")] = true
+	}
+	tester.Equal(map[string]bool{"good": true, "bad": true}, servers)
+	// 返回502的目标被驱逐；响应体关闭后释放进行中的请求计数
+	for _, target := range tr.upstreams["orders"].Targets() {
+		tester.Equal(target.URL.String() == good.URL, target.Available(time.Now()))
+		tester.Equal(int64(0), target.Active())
+	}
+	resp, serr := invoke(flux.Annotations{flux.ServiceAnnotationHttpTargets: good.URL + "/v1;weight=2"})
+	tester.Nil(serr)
+	tester.Equal("good", resp.Header.Get("X-Server"))
+	// Upstream名称大小写不敏感
+	_, serr = invoke(flux.Annotations{flux.ServiceAnnotationHttpUpstream: "Orders"})
+	tester.Nil(serr)
+	_, serr = invoke(flux.Annotations{flux.ServiceAnnotationHttpUpstream: "unknown"})
+	tester.NotNil(serr)
+}
@@ -0,0 +1,345 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cast"
+)
+
+const (
+	// ConfigKeyWebSocket WebSocket代理配置
+	ConfigKeyWebSocket                  = "websocket"
+	ConfigKeyWebSocketIdleTimeout       = "idle_timeout"
+	ConfigKeyWebSocketMaxMessageSize    = "max_message_size"
+	ConfigKeyWebSocketHandshakeTimeout  = "handshake_timeout"
+	ConfigKeyWebSocketWriteTimeout      = "write_timeout"
+	ConfigKeyWebSocketReadBufferSize    = "read_buffer_size"
+	ConfigKeyWebSocketWriteBufferSize   = "write_buffer_size"
+	defaultWebSocketIdleTimeout         = time.Second * 60
+	defaultWebSocketMaxMessageSize      = 1024 * 1024
+	defaultWebSocketHandshakeTimeout    = time.Second * 10
+	defaultWebSocketWriteTimeout        = time.Second * 10
+	defaultWebSocketBufferSize          = 4096
+	webSocketCloseReasonIdleTimeout     = "idle timeout"
+	webSocketCloseReasonUpstreamFailure = "upstream failure"
+)
+
+// 握手阶段由Dialer生成的Header，不从客户端请求中转发
+var webSocketHandshakeHeaders = []string{
+	"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions",
+}
+
+// WebSocketConfig WebSocket代理配置
+type WebSocketConfig struct {
+	// 连接空闲超时时长：任一方向在此时长内没有收到消息（包括Ping/Pong）时，关闭连接
+	IdleTimeout time.Duration
+	// 单个消息的最大字节数；超出时以1009状态码关闭连接
+	MaxMessageSize int64
+	// 与后端服务握手的超时时长
+	HandshakeTimeout time.Duration
+	// 写入消息的超时时长
+	WriteTimeout    time.Duration
+	ReadBufferSize  int
+	WriteBufferSize int
+}
+
+func NewWebSocketConfig(values map[string]interface{}) WebSocketConfig {
+	c := WebSocketConfig{
+		IdleTimeout:      cast.ToDuration(values[ConfigKeyWebSocketIdleTimeout]),
+		MaxMessageSize:   cast.ToInt64(values[ConfigKeyWebSocketMaxMessageSize]),
+		HandshakeTimeout: cast.ToDuration(values[ConfigKeyWebSocketHandshakeTimeout]),
+		WriteTimeout:     cast.ToDuration(values[ConfigKeyWebSocketWriteTimeout]),
+		ReadBufferSize:   cast.ToInt(values[ConfigKeyWebSocketReadBufferSize]),
+		WriteBufferSize:  cast.ToInt(values[ConfigKeyWebSocketWriteBufferSize]),
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defaultWebSocketIdleTimeout
+	}
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = defaultWebSocketMaxMessageSize
+	}
+	if c.HandshakeTimeout <= 0 {
+		c.HandshakeTimeout = defaultWebSocketHandshakeTimeout
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = defaultWebSocketWriteTimeout
+	}
+	if c.ReadBufferSize <= 0 {
+		c.ReadBufferSize = defaultWebSocketBufferSize
+	}
+	if c.WriteBufferSize <= 0 {
+		c.WriteBufferSize = defaultWebSocketBufferSize
+	}
+	return c
+}
+
+// IsWebSocketProxy 判断请求是否为需要代理的WebSocket升级请求：
+// 请求为WebSocket升级请求，并且Service.Url为ws/wss协议，或者声明了 flux.go/http.websocket 注解。
+func IsWebSocketProxy(ctx flux.Context, service flux.ServiceSpec) bool {
+	if !websocket.IsWebSocketUpgrade(ctx.Request()) {
+		return false
+	}
+	if anno := service.Annotation(flux.ServiceAnnotationHttpWebSocket); anno.IsValid() {
+		return anno.GetBoolean()
+	}
+	lower := strings.ToLower(service.Url)
+	return strings.HasPrefix(lower, "ws://") || strings.HasPrefix(lower, "wss://")
+}
+
+// invokeWebSocket 与后端服务完成WebSocket握手后，接管客户端连接，并双向转发消息直到任一方关闭连接。
+// 升级请求在此之前已经过Filter链处理（认证、限流等）。
+func (b *RpcTransporter) invokeWebSocket(ctx flux.Context, service flux.ServiceSpec) (*flux.ServeResponse, *flux.ServeError) {
+	trace := logger.TraceExtras(ctx.RequestId(), map[string]string{
+		"invoke.service": service.ServiceID(),
+	})
+	transport, err := b.transportOf(service)
+	if nil != err {
+		trace.Errorw("TRANSPORTER:HTTP:WEBSOCKET:TRANSPORT", "error", err)
+		return nil, newAssembleError(err)
+	}
+	upstream, err := b.upstreamOf(service)
+	if nil != err {
+		trace.Errorw("TRANSPORTER:HTTP:WEBSOCKET:UPSTREAM", "error", err)
+		return nil, newAssembleError(err)
+	}
+	if service.Url, err = RewritePath(ctx, service); nil != err {
+		trace.Errorw("TRANSPORTER:HTTP:WEBSOCKET:REWRITE", "error", err)
+		return nil, newAssembleError(err)
+	}
+	var target *Target
+	if upstream != nil {
+		target = upstream.Select(ctx)
+		service.Url, err = target.Resolve(service.Url)
+	} else {
+		flux.AssertNotEmpty(service.Url, "<service.url> MUST NOT empty in http transporter")
+	}
+	done := func(success bool) {
+		if target != nil {
+			upstream.Done(target, success)
+		}
+	}
+	release := func() {
+		if target != nil {
+			upstream.release(target)
+		}
+	}
+	var wsurl string
+	if nil == err {
+		wsurl, err = webSocketURLOf(service.Url, ctx.URL().RawQuery)
+	}
+	var header http.Header
+	if nil == err {
+		header, err = b.assembleHeader(ctx)
+	}
+	if nil != err {
+		release()
+		trace.Errorw("TRANSPORTER:HTTP:WEBSOCKET:ASSEMBLE", "error", err)
+		return nil, newAssembleError(err)
+	}
+	for _, name := range webSocketHandshakeHeaders {
+		header.Del(name)
+	}
+	ApplyHeaderRules(header, RequestHeaderRules(service))
+	trace = logger.TraceExtras(ctx.RequestId(), map[string]string{
+		"invoke.service":   service.ServiceID(),
+		"invoke.websocket": wsurl,
+	})
+	// handshake
+	config := b.websocket
+	dialer := newWebSocketDialer(transport.client, config)
+	dialctx, cancel := context.WithTimeout(ctx.Context(), config.HandshakeTimeout)
+	upconn, upresp, err := dialer.DialContext(dialctx, wsurl, header)
+	cancel()
+	if nil != err {
+		done(false)
+		trace.Errorw("TRANSPORTER:HTTP:WEBSOCKET:HANDSHAKE", "error", err)
+		status := http.StatusBadGateway
+		if upresp != nil && upresp.StatusCode != http.StatusSwitchingProtocols {
+			status = upresp.StatusCode
+		}
+		return nil, &flux.ServeError{
+			StatusCode: status,
+			ErrorCode:  flux.ErrorCodeGatewayTransporter,
+			Message:    flux.ErrorMessageTransportHttpWebSocketHandshake,
+			CauseError: err,
+		}
+	}
+	// upgrade
+	respHeader := make(http.Header)
+	if proto := upconn.Subprotocol(); proto != "" {
+		respHeader.Set("Sec-Websocket-Protocol", proto)
+	}
+	ApplyHeaderRules(respHeader, ResponseHeaderRules(service))
+	upgrader := &websocket.Upgrader{
+		ReadBufferSize:  config.ReadBufferSize,
+		WriteBufferSize: config.WriteBufferSize,
+		// 跨域检查由Filter链负责
+		CheckOrigin: func(r *http.Request) bool { return true },
+		// 升级失败时，由Dispatcher写入错误响应
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {},
+	}
+	downconn, err := upgrader.Upgrade(ctx.ResponseWriter(), ctx.Request(), respHeader)
+	if nil != err {
+		_ = upconn.Close()
+		release()
+		trace.Errorw("TRANSPORTER:HTTP:WEBSOCKET:UPGRADE", "error", err)
+		return nil, &flux.ServeError{
+			StatusCode: flux.StatusBadRequest,
+			ErrorCode:  flux.ErrorCodeRequestInvalid,
+			Message:    flux.ErrorMessageTransportHttpWebSocketUpgrade,
+			CauseError: err,
+		}
+	}
+	trace.Infow("TRANSPORTER:HTTP:WEBSOCKET:CONNECTED")
+	err = pumpWebSocket(downconn, upconn, config)
+	done(true)
+	trace.Infow("TRANSPORTER:HTTP:WEBSOCKET:CLOSED", "reason", err)
+	return flux.NewHijackedServeResponse(), nil
+}
+
+func newAssembleError(err error) *flux.ServeError {
+	return &flux.ServeError{
+		StatusCode: flux.StatusServerError,
+		ErrorCode:  flux.ErrorCodeGatewayInternal,
+		Message:    flux.ErrorMessageTransportHttpAssembleFailed,
+		CauseError: err,
+	}
+}
+
+// webSocketURLOf 将Http协议的服务地址转换为WebSocket协议地址，并合并客户端请求的Query参数
+func webSocketURLOf(serviceUrl string, rawQuery string) (string, error) {
+	u, err := url.Parse(serviceUrl)
+	if nil != err {
+		return "", err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("websocket: unsupported scheme: %s", u.Scheme)
+	}
+	if rawQuery != "" {
+		if u.RawQuery != "" {
+			u.RawQuery = u.RawQuery + "&" + rawQuery
+		} else {
+			u.RawQuery = rawQuery
+		}
+	}
+	return u.String(), nil
+}
+
+// newWebSocketDialer 使用传输配置的代理、TLS和拨号配置创建Dialer
+func newWebSocketDialer(client *http.Client, config WebSocketConfig) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: config.HandshakeTimeout,
+		ReadBufferSize:   config.ReadBufferSize,
+		WriteBufferSize:  config.WriteBufferSize,
+	}
+	if tr, ok := client.Transport.(*http.Transport); ok {
+		dialer.Proxy = tr.Proxy
+		dialer.NetDialContext = tr.DialContext
+		if tr.TLSClientConfig != nil {
+			// WebSocket不支持HTTP/2协商
+			tc := tr.TLSClientConfig.Clone()
+			tc.NextProtos = nil
+			dialer.TLSClientConfig = tc
+		}
+	}
+	return dialer
+}
+
+// pumpWebSocket 双向转发消息；任一方关闭或出错时，向另一方传递关闭帧，并关闭两端连接。
+func pumpWebSocket(downconn, upconn *websocket.Conn, config WebSocketConfig) error {
+	defer downconn.Close()
+	defer upconn.Close()
+	touch := func() {
+		deadline := time.Now().Add(config.IdleTimeout)
+		_ = downconn.SetReadDeadline(deadline)
+		_ = upconn.SetReadDeadline(deadline)
+	}
+	for _, conn := range []*websocket.Conn{downconn, upconn} {
+		conn.SetReadLimit(config.MaxMessageSize)
+	}
+	touch()
+	// 每个连接的消息仅由一个协程写入；控制帧写入允许并发
+	forwardControl(downconn, upconn, touch, config)
+	forwardControl(upconn, downconn, touch, config)
+	errc := make(chan error, 2)
+	go func() { errc <- copyWebSocket(upconn, downconn, touch, config) }()
+	go func() { errc <- copyWebSocket(downconn, upconn, touch, config) }()
+	err := <-errc
+	// 等待另一方响应关闭帧
+	select {
+	case <-errc:
+	case <-time.After(config.WriteTimeout):
+	}
+	return err
+}
+
+// forwardControl 将src收到的Ping/Pong转发到dst
+func forwardControl(src, dst *websocket.Conn, touch func(), config WebSocketConfig) {
+	forward := func(messageType int) func(string) error {
+		return func(data string) error {
+			touch()
+			err := dst.WriteControl(messageType, []byte(data), time.Now().Add(config.WriteTimeout))
+			if err == websocket.ErrCloseSent {
+				return nil
+			}
+			return err
+		}
+	}
+	src.SetPingHandler(forward(websocket.PingMessage))
+	src.SetPongHandler(forward(websocket.PongMessage))
+}
+
+// copyWebSocket 将src的消息写入dst；src读取失败时，向dst发送对应的关闭帧
+func copyWebSocket(dst, src *websocket.Conn, touch func(), config WebSocketConfig) error {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if nil != err {
+			_ = dst.WriteControl(websocket.CloseMessage, closeMessageOf(err), time.Now().Add(config.WriteTimeout))
+			return err
+		}
+		touch()
+		_ = dst.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+		if err = dst.WriteMessage(messageType, data); nil != err {
+			_ = src.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, webSocketCloseReasonUpstreamFailure),
+				time.Now().Add(config.WriteTimeout))
+			return err
+		}
+	}
+}
+
+// closeMessageOf 根据读取错误，返回传递给另一方的关闭帧
+func closeMessageOf(err error) []byte {
+	if ce, ok := err.(*websocket.CloseError); ok {
+		// 1005/1006/1015 为保留状态码，不允许在关闭帧中发送
+		switch ce.Code {
+		case websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure, websocket.CloseTLSHandshake:
+			return websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+		}
+		return websocket.FormatCloseMessage(ce.Code, ce.Text)
+	}
+	if err == websocket.ErrReadLimit {
+		return websocket.FormatCloseMessage(websocket.CloseMessageTooBig, "")
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return websocket.FormatCloseMessage(websocket.CloseGoingAway, webSocketCloseReasonIdleTimeout)
+	}
+	return websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+}
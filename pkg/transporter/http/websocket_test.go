@@ -0,0 +1,170 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/bytepowered/fluxgo/pkg/ext"
+	"github.com/bytepowered/fluxgo/pkg/flux"
+	"github.com/bytepowered/fluxgo/pkg/internal"
+	"github.com/bytepowered/fluxgo/pkg/listener"
+	"github.com/bytepowered/fluxgo/pkg/logger"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebSocketGateway(tr *RpcTransporter, service flux.ServiceSpec, serrc chan<- *flux.ServeError) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.GetBody = func() (io.ReadCloser, error) {
+			return http.NoBody, nil
+		}
+		ctx := internal.NewContext()
+		ctx.Reset(listener.NewWebContext(echo.New().NewContext(r, w), "test-id", nil),
+			&flux.EndpointSpec{HttpMethod: r.Method, HttpPattern: "/ws", Service: service})
+		ret, serr := tr.DoInvoke(ctx, service)
+		if serr == nil && !ret.IsHijacked() {
+			serr = &flux.ServeError{StatusCode: http.StatusInternalServerError}
+		}
+		if serr != nil {
+			w.WriteHeader(serr.StatusCode)
+		}
+		serrc <- serr
+	}))
+}
+
+func TestTransporterWebSocket(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	var received *http.Request
+	closec := make(chan *websocket.CloseError, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		conn, err := (&websocket.Upgrader{Subprotocols: []string{"chat"}}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				if ce, ok := err.(*websocket.CloseError); ok {
+					closec <- ce
+				}
+				return
+			}
+			if string(data) == "bye" {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4000, "server bye"))
+				continue
+			}
+			_ = conn.WriteMessage(mt, append([]byte("echo:"), data...))
+		}
+	}))
+	defer backend.Close()
+	tr := NewTransporter()
+	tester.NoError(tr.OnInit(flux.NewVarsConfiguration(map[string]interface{}{
+		ConfigKeyWebSocket: map[string]interface{}{
+			ConfigKeyWebSocketIdleTimeout:    "300ms",
+			ConfigKeyWebSocketMaxMessageSize: 16,
+		},
+	})))
+	service := flux.ServiceSpec{Url: "ws" + strings.TrimPrefix(backend.URL, "http") + "/chat"}
+	serrc := make(chan *flux.ServeError, 1)
+	gateway := newWebSocketGateway(tr, service, serrc)
+	defer gateway.Close()
+	wsurl := "ws" + strings.TrimPrefix(gateway.URL, "http") + "/ws?room=1"
+	dial := func() *websocket.Conn {
+		dialer := &websocket.Dialer{Subprotocols: []string{"chat"}}
+		conn, resp, err := dialer.Dial(wsurl, http.Header{"X-User": []string{"u1"}})
+		tester.NoError(err)
+		tester.Equal(http.StatusSwitchingProtocols, resp.StatusCode)
+		tester.Equal("chat", conn.Subprotocol())
+		return conn
+	}
+
+	// 消息双向转发
+	conn := dial()
+	tester.Equal("/chat", received.URL.Path)
+	tester.Equal("room=1", received.URL.RawQuery)
+	tester.Equal("u1", received.Header.Get("X-User"))
+	tester.NoError(conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, data, err := conn.ReadMessage()
+	tester.NoError(err)
+	tester.Equal("echo:hello", string(data))
+	// 后端关闭连接，关闭帧传递到客户端
+	tester.NoError(conn.WriteMessage(websocket.TextMessage, []byte("bye")))
+	_, _, err = conn.ReadMessage()
+	tester.True(websocket.IsCloseError(err, 4000), "error: %v", err)
+	// 客户端应答的关闭帧传递到后端
+	tester.Equal(4000, (<-closec).Code)
+	tester.Nil(<-serrc)
+	_ = conn.Close()
+
+	// 客户端关闭连接，关闭帧传递到后端
+	conn = dial()
+	tester.NoError(conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "client bye")))
+	ce := <-closec
+	tester.Equal(websocket.CloseNormalClosure, ce.Code)
+	tester.Equal("client bye", ce.Text)
+	tester.Nil(<-serrc)
+	_ = conn.Close()
+
+	// 超出最大消息长度
+	conn = dial()
+	tester.NoError(conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 32))))
+	_, _, err = conn.ReadMessage()
+	tester.True(websocket.IsCloseError(err, websocket.CloseMessageTooBig), "error: %v", err)
+	tester.Equal(websocket.CloseMessageTooBig, (<-closec).Code)
+	tester.Nil(<-serrc)
+	_ = conn.Close()
+
+	// 空闲超时
+	conn = dial()
+	start := time.Now()
+	_, _, err = conn.ReadMessage()
+	tester.True(websocket.IsCloseError(err, websocket.CloseGoingAway), "error: %v", err)
+	tester.True(time.Since(start) >= 300*time.Millisecond)
+	tester.Equal(websocket.CloseGoingAway, (<-closec).Code)
+	tester.Nil(<-serrc)
+	_ = conn.Close()
+}
+
+func TestTransporterWebSocketHandshakeFailed(t *testing.T) {
+	tester := assert.New(t)
+	ext.SetLoggerFactory(logger.DefaultFactory)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer backend.Close()
+	tr := NewTransporter()
+	tester.NoError(tr.OnInit(flux.NewConfiguration("http_websocket_test")))
+	// 后端为Http协议时，通过注解启用WebSocket代理
+	service := flux.ServiceSpec{Url: backend.URL, Annotations: flux.Annotations{flux.ServiceAnnotationHttpWebSocket: true}}
+	serrc := make(chan *flux.ServeError, 1)
+	gateway := newWebSocketGateway(tr, service, serrc)
+	defer gateway.Close()
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(gateway.URL, "http"), nil)
+	tester.Error(err)
+	tester.Equal(http.StatusForbidden, resp.StatusCode)
+	serr := <-serrc
+	tester.NotNil(serr)
+	tester.Equal(flux.ErrorMessageTransportHttpWebSocketHandshake, serr.Message)
+}
+
+func TestWebSocketURLOf(t *testing.T) {
+	tester := assert.New(t)
+	u, err := webSocketURLOf("https://chat.svc/rooms?v=1", "room=1")
+	tester.NoError(err)
+	tester.Equal("wss://chat.svc/rooms?v=1&room=1", u)
+	u, err = webSocketURLOf("http://chat.svc/rooms", "")
+	tester.NoError(err)
+	tester.Equal("ws://chat.svc/rooms", u)
+	_, err = webSocketURLOf("ftp://chat.svc", "")
+	tester.Error(err)
+}